@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Format is an output format chosen by SetupAuto.
+type Format string
+
+const (
+	// FormatPretty is colorized, indented output for humans at Debug level.
+	FormatPretty Format = "pretty"
+	// FormatText is the output of NewHandler without colors at Info level.
+	FormatText Format = "text"
+	// FormatJSON is one JSON object per line at Info level, for log collectors.
+	FormatJSON Format = "json"
+)
+
+// The environment probes of DetectFormat. They're variables so that they can
+// be replaced, like in tests that must not depend on where they run.
+var (
+	// IsTTY reports whether stdout is a terminal.
+	IsTTY = func() bool {
+		return isTerminal(os.Stdout)
+	}
+
+	// InKubernetes reports whether the process runs in a Kubernetes pod.
+	InKubernetes = func() bool {
+		return os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+	}
+
+	// InContainer reports whether the process runs in a container, judging by
+	// /.dockerenv or the cgroups of the init process.
+	InContainer = func() bool {
+		if _, err := os.Stat("/.dockerenv"); err == nil {
+			return true
+		}
+		cgroup, err := os.ReadFile("/proc/1/cgroup")
+		if err != nil {
+			return false
+		}
+		for _, marker := range []string{"docker", "containerd", "kubepods", "libpod"} {
+			if bytes.Contains(cgroup, []byte(marker)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// InCI reports whether the process runs in a CI pipeline.
+	InCI = func() bool {
+		return os.Getenv("CI") == "true"
+	}
+)
+
+// DetectFormat returns the format named by LOG_FORMAT if it is set to pretty,
+// text or json. Otherwise it returns FormatPretty when stdout is a terminal,
+// FormatJSON in Kubernetes, containers and CI, and FormatText elsewhere.
+func DetectFormat() Format {
+	switch f := Format(strings.ToLower(os.Getenv("LOG_FORMAT"))); f {
+	case FormatPretty, FormatText, FormatJSON:
+		return f
+	}
+
+	switch {
+	case IsTTY():
+		return FormatPretty
+	case InKubernetes(), InContainer(), InCI():
+		return FormatJSON
+	default:
+		return FormatText
+	}
+}
+
+// SetupAuto sets the default logger up with the format returned by
+// DetectFormat and returns it, at the level named by LOG_LEVEL if it is set to
+// a name ParseLevel accepts. The opts functions are applied to the handler
+// options before the handler is created; FormatJSON sets JSON, so all other
+// options apply to the JSON output as well.
+func SetupAuto(opts ...func(*HandlerOptions)) Format {
+	format := DetectFormat()
+
+	o := &HandlerOptions{HandlerOptions: &slog.HandlerOptions{}}
+	switch format {
+	case FormatPretty:
+		o.Level = slog.LevelDebug
+		o.Colorize = true
+		o.PrettyPrint = true
+	case FormatJSON:
+		o.JSON = true
+	}
+	if level, err := ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		o.Level = level
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	slog.SetDefault(slog.New(NewHandler(o)))
+	return format
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// stubProbes replaces the environment probes of DetectFormat for the test.
+func stubProbes(t *testing.T, tty, kubernetes, container, ci bool) {
+	t.Helper()
+	saved := []*func() bool{&IsTTY, &InKubernetes, &InContainer, &InCI}
+	old := []func() bool{IsTTY, InKubernetes, InContainer, InCI}
+	t.Cleanup(func() {
+		for i, p := range saved {
+			*p = old[i]
+		}
+	})
+	IsTTY = func() bool { return tty }
+	InKubernetes = func() bool { return kubernetes }
+	InContainer = func() bool { return container }
+	InCI = func() bool { return ci }
+}
+
+func TestDetectFormat(t *testing.T) {
+	for _, tc := range []struct {
+		logFormat                      string
+		tty, kubernetes, container, ci bool
+		want                           Format
+	}{
+		{"json", true, false, false, false, FormatJSON},
+		{"TEXT", false, true, false, false, FormatText},
+		{"pretty", false, false, false, true, FormatPretty},
+		{"", true, true, true, true, FormatPretty},
+		{"", false, true, false, false, FormatJSON},
+		{"", false, false, true, false, FormatJSON},
+		{"", false, false, false, true, FormatJSON},
+		{"", false, false, false, false, FormatText},
+		{"yaml", false, false, false, false, FormatText},
+	} {
+		t.Setenv("LOG_FORMAT", tc.logFormat)
+		stubProbes(t, tc.tty, tc.kubernetes, tc.container, tc.ci)
+		if got := DetectFormat(); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc, got, tc.want)
+		}
+	}
+}
+
+func TestProbes(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("CI", "true")
+	if !InKubernetes() || !InCI() {
+		t.Error("KUBERNETES_SERVICE_HOST and CI aren't detected")
+	}
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("CI", "")
+	if InKubernetes() || InCI() {
+		t.Error("empty KUBERNETES_SERVICE_HOST and CI are detected")
+	}
+}
+
+func TestSetupAuto(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	for _, tc := range []struct {
+		format Format
+		opt    func(*HandlerOptions)
+	}{
+		{FormatJSON, nil},
+		{FormatJSON, func(o *HandlerOptions) { o.HandlerOptions = nil }},
+		{FormatText, nil},
+		{FormatText, func(o *HandlerOptions) { o.HandlerOptions = nil }},
+	} {
+		t.Setenv("LOG_FORMAT", string(tc.format))
+		t.Setenv("LOG_LEVEL", "warn")
+		var buf bytes.Buffer
+		opts := []func(*HandlerOptions){func(o *HandlerOptions) { o.Writer = &buf }}
+		if tc.opt != nil {
+			opts = append(opts, tc.opt)
+		}
+		if got := SetupAuto(opts...); got != tc.format {
+			t.Errorf("got %q, want %q", got, tc.format)
+		}
+		slog.Info("hidden")
+		slog.Warn("shown", "n", 1)
+
+		out := strings.TrimSpace(buf.String())
+		if strings.Contains(out, "hidden") != (tc.opt != nil) {
+			t.Errorf("%s, cleared options %v: got %q", tc.format, tc.opt != nil, out)
+		}
+		last := out[strings.LastIndexByte(out, '\n')+1:]
+		if tc.format == FormatJSON {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(last), &m); err != nil || m["msg"] != "shown" || m["level"] != "WARN" {
+				t.Errorf("JSON line %q: %v", last, err)
+			}
+		} else if !strings.Contains(last, `WARN: shown {"n":1}`) {
+			t.Errorf("text line %q", last)
+		}
+	}
+}
+
+func TestSetupAutoJSONOptions(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	t.Setenv("LOG_FORMAT", "json")
+	t.Setenv("LOG_LEVEL", "")
+	const key = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	var buf bytes.Buffer
+	SetupAuto(func(o *HandlerOptions) {
+		o.Writer = &buf
+		o.SecretScan = &SecretScan{Mask: true}
+		o.ContextExtractors = []ContextExtractor{func(context.Context) []slog.Attr {
+			return []slog.Attr{slog.String("service", "api")}
+		}}
+		o.MaxAttrs = 2
+		o.SortKeys = true
+		o.TimeEncoding = TimeEpochMillis
+	})
+	slog.Info("configured", "region", "eu-west-1", "credential", key, "extra", 1)
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("%q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level": "INFO", "msg": "configured", "region": "eu-west-1", "credential": "[REDACTED]",
+		"service": "api", "_truncated_attrs": 1.0,
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s: got %v, want %v in %q", k, m[k], v, buf.String())
+		}
+	}
+	if _, ok := m["time"].(float64); !ok {
+		t.Errorf("time %v isn't in epoch millis", m["time"])
+	}
+}
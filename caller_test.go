@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logError logs msg at Error level, reporting the caller of the function skip
+// levels above it the way slog.Logger does.
+func logError(l *slog.Logger, skip int, msg string) {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:]) // skip runtime.Callers and logError
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	_ = l.Handler().Handle(context.Background(), r)
+}
+
+func wrapper(l *slog.Logger, msg string)      { logError(l, 1, msg) }
+func outerWrapper(l *slog.Logger, msg string) { innerWrapper(l, msg) }
+func innerWrapper(l *slog.Logger, msg string) { logError(l, 2, msg) }
+
+// line returns the line of its call.
+func line() int {
+	_, _, n, _ := runtime.Caller(1)
+	return n
+}
+
+func TestCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf}))
+	prev := slog.Default()
+	slog.SetDefault(l)
+	defer slog.SetDefault(prev)
+
+	for _, tc := range []struct {
+		name string
+		log  func() int
+	}{
+		{"slog.Error", func() int { slog.Error("failed"); return line() }},
+		{"Logger.Error", func() int { l.Error("failed"); return line() }},
+		{"Logger.ErrorContext", func() int { l.ErrorContext(t.Context(), "failed"); return line() }},
+		{"LogAttrs", func() int { l.LogAttrs(t.Context(), slog.LevelError, "failed"); return line() }},
+		{"derived", func() int { l.With("k", 1).WithGroup("g").Error("failed"); return line() }},
+		{"nested groups", func() int { l.WithGroup("a").WithGroup("b").Error("failed", "k", 1); return line() }},
+		{"wrapper", func() int { wrapper(l, "failed"); return line() }},
+		{"two-level wrapper", func() int { outerWrapper(l, "failed"); return line() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			want := tc.log()
+			records := parseLines(t, buf.Bytes())
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+			r := records[0]
+			if file, _ := r["file"].(string); !strings.HasSuffix(file, "/caller_test.go") {
+				t.Errorf("file = %v, want caller_test.go", r["file"])
+			}
+			if got, _ := r["line"].(float64); int(got) != want {
+				t.Errorf("line = %v, want %d", r["line"], want)
+			}
+			if fn, _ := r["function"].(string); !strings.HasPrefix(fn, "github.com/corray333/go-log.TestCaller") {
+				t.Errorf("function = %v, want TestCaller", r["function"])
+			}
+			// The caller stays out of the groups of the logger.
+			if want := map[string]any{"b": map[string]any{"k": 1.0}}; tc.name == "nested groups" && !reflect.DeepEqual(r["a"], want) {
+				t.Errorf("group a = %v, want %v", r["a"], want)
+			}
+		})
+	}
+}
+
+func TestCompactCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, CompactCaller: true}))
+	l.Error("failed")
+	want := line() - 1
+
+	r := parseLines(t, buf.Bytes())[0]
+	if got := r["caller"]; got != "caller_test.go:"+strconv.Itoa(want)+" TestCompactCaller" {
+		t.Errorf("caller = %v, want caller_test.go:%d TestCompactCaller", got, want)
+	}
+	if _, ok := r["file"]; ok {
+		t.Error("file logged alongside the compact caller")
+	}
+}
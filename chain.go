@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SigningOptions configures a SigningWriter.
+type SigningOptions struct {
+	// Key is the HMAC key. It is required.
+	Key []byte
+	// HeadFile is a file storing the signature of the last record, so that the
+	// chain continues when the process restarts or the log file is rotated. It
+	// is written and synced to disk by Flush and Close, so records written after
+	// the last Flush break the chain when the process crashes. Without it, every
+	// SigningWriter starts a new chain, which VerifyChain reports as a break
+	// when it is appended to an existing file.
+	HeadFile string
+}
+
+// SigningWriter makes a log file tamper-evident by chaining its records: each
+// record gets a signature computed as HMAC-SHA256(key, previous signature ||
+// record), so that editing, inserting or deleting a record breaks the chain at
+// that record. VerifyChain validates a file.
+//
+// Records must arrive in single Writes without line breaks other than their
+// line ending, as written by NewHandler without PrettyPrint and without
+// colors, or by slog.JSONHandler. Records ending with a JSON object get a "sig"
+// field, others " sig=" and the signature.
+type SigningWriter struct {
+	m    sync.Mutex
+	w    io.Writer
+	key  []byte
+	head string
+	prev []byte
+	buf  []byte
+	// saved is set when the head file holds prev.
+	saved bool
+}
+
+// sigLen is the length of a signature in hex.
+const sigLen = 2 * sha256.Size
+
+// NewSigningWriter returns a SigningWriter writing to w, continuing the chain
+// from opts.HeadFile if it exists.
+func NewSigningWriter(w io.Writer, opts SigningOptions) (*SigningWriter, error) {
+	if len(opts.Key) == 0 {
+		return nil, errors.New("signing key is empty")
+	}
+	sw := &SigningWriter{w: w, key: opts.Key, head: opts.HeadFile, saved: true}
+	if sw.head != "" {
+		prev, err := os.ReadFile(sw.head)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if prev = bytes.TrimSpace(prev); len(prev) > 0 {
+			if len(prev) != sigLen {
+				return nil, errors.New("invalid chain head in " + sw.head)
+			}
+			sw.prev = prev
+		}
+	}
+	return sw, nil
+}
+
+// Write signs and writes the record p.
+func (sw *SigningWriter) Write(p []byte) (int, error) {
+	return sw.writeLevel(slog.LevelInfo, p)
+}
+
+// writeLevel signs and writes p, a record at level, passing the level on to
+// writers like FileWriter.
+func (sw *SigningWriter) writeLevel(level slog.Level, p []byte) (int, error) {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	rec, ending := cutLineEnding(p)
+	sig := sign(sw.key, sw.prev, rec)
+	sw.buf = appendSig(sw.buf[:0], rec, sig)
+	sw.buf = append(sw.buf, ending...)
+	if _, err := writeLevel(sw.w, level, sw.buf); err != nil {
+		return 0, err
+	}
+	sw.prev, sw.saved = sig, false
+	return len(p), nil
+}
+
+// saveHead writes the signature of the last record to the head file, if it
+// changed since it was last saved. sw.m must be held.
+func (sw *SigningWriter) saveHead() error {
+	if sw.head == "" || sw.saved {
+		return nil
+	}
+	if err := writeHead(sw.head, sw.prev); err != nil {
+		return err
+	}
+	sw.saved = true
+	return nil
+}
+
+// writeHead replaces the head file with sig through a temporary file and syncs
+// both the file and its directory, so that a crash can't leave it truncated or
+// lose the rename, which would keep the chain from resuming.
+func writeHead(path string, sig []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(sig)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if cerr := dir.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Flush flushes the underlying writer if it is a Flusher, and then saves the
+// chain head to the head file.
+func (sw *SigningWriter) Flush(ctx context.Context) error {
+	var err error
+	if f, ok := sw.w.(Flusher); ok {
+		err = f.Flush(ctx)
+	}
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	return errors.Join(err, sw.saveHead())
+}
+
+// Close saves the chain head to the head file and closes the underlying
+// writer if it is an io.Closer.
+func (sw *SigningWriter) Close() error {
+	sw.m.Lock()
+	err := sw.saveHead()
+	sw.m.Unlock()
+	if c, ok := sw.w.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// VerifyChain reads the records signed by a SigningWriter with key from r and
+// returns the index of the first record, counting from 0, whose signature
+// doesn't match, or -1 if all do. The first record must start the chain, so
+// rotated files are verified together, in order, with io.MultiReader.
+func VerifyChain(r io.Reader, key []byte) (breakIndex int, err error) {
+	br := bufio.NewReader(r)
+	var prev []byte
+	for i := 0; ; i++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 {
+			if err == io.EOF {
+				return -1, nil
+			}
+			return i, err
+		}
+		if err != nil && err != io.EOF {
+			return i, err
+		}
+
+		rec, _ := cutLineEnding(line)
+		rec, sig, ok := cutSig(rec)
+		if !ok {
+			return i, nil
+		}
+		want := sign(key, prev, rec)
+		if !hmac.Equal(sig, want) {
+			return i, nil
+		}
+		prev = sig
+	}
+}
+
+func sign(key, prev, rec []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(prev)
+	mac.Write(rec)
+	return hex.AppendEncode(nil, mac.Sum(nil))
+}
+
+// cutLineEnding splits p into the record and its line ending.
+func cutLineEnding(p []byte) (rec, ending []byte) {
+	rec = bytes.TrimSuffix(p, []byte("\n"))
+	rec = bytes.TrimSuffix(rec, []byte("\r"))
+	return rec, p[len(rec):]
+}
+
+// appendSig appends rec with sig added as a "sig" field of its trailing JSON
+// object, or as " sig=" and sig.
+func appendSig(b, rec, sig []byte) []byte {
+	if len(rec) >= 2 && rec[len(rec)-1] == '}' {
+		b = append(b, rec[:len(rec)-1]...)
+		if rec[len(rec)-2] != '{' {
+			b = append(b, ',')
+		}
+		b = append(b, `"sig":"`...)
+		b = append(b, sig...)
+		return append(b, `"}`...)
+	}
+	b = append(b, rec...)
+	b = append(b, " sig="...)
+	return append(b, sig...)
+}
+
+// cutSig reverses appendSig, returning the record as it was signed and the
+// signature.
+func cutSig(line []byte) (rec, sig []byte, ok bool) {
+	const field = `"sig":"`
+	if n := len(line) - len(field) - sigLen - 2; n > 0 && bytes.HasSuffix(line, []byte(`"}`)) &&
+		string(line[n:n+len(field)]) == field {
+		sig = line[n+len(field) : n+len(field)+sigLen]
+		rec = line[:n]
+		if rec[n-1] == ',' {
+			rec = rec[:n-1]
+		}
+		return append(rec[:len(rec):len(rec)], '}'), sig, true
+	}
+	const suffix = " sig="
+	if n := len(line) - len(suffix) - sigLen; n >= 0 && string(line[n:n+len(suffix)]) == suffix {
+		return line[:n], line[n+len(suffix):], true
+	}
+	return nil, nil, false
+}
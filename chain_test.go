@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var chainKey = []byte("chain key")
+
+func signedLog(t *testing.T, w io.Writer, head string, msgs ...string) {
+	t.Helper()
+	sw, err := NewSigningWriter(w, SigningOptions{Key: chainKey, HeadFile: head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(NewHandler(&HandlerOptions{Writer: sw}))
+	for _, msg := range msgs {
+		l.Info(msg, "n", len(msg))
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	var buf bytes.Buffer
+	signedLog(t, &buf, "", "one", "two", "three")
+	if i, err := VerifyChain(bytes.NewReader(buf.Bytes()), chainKey); i != -1 || err != nil {
+		t.Errorf("VerifyChain() = %d, %v, want -1", i, err)
+	}
+	if i, _ := VerifyChain(bytes.NewReader(buf.Bytes()), []byte("other key")); i != 0 {
+		t.Errorf("VerifyChain() with another key = %d, want 0", i)
+	}
+}
+
+func TestVerifyChainTampered(t *testing.T) {
+	var buf bytes.Buffer
+	signedLog(t, &buf, "", "one", "two", "three", "four")
+	lines := strings.SplitAfter(buf.String(), "\n")
+	lines = lines[:len(lines)-1]
+
+	for _, tc := range []struct {
+		name  string
+		lines []string
+		want  int
+	}{
+		{"edited", []string{lines[0], strings.Replace(lines[1], `"n":3`, `"n":4`, 1), lines[2], lines[3]}, 1},
+		{"deleted", []string{lines[0], lines[2], lines[3]}, 1},
+		{"inserted", []string{lines[0], lines[1], lines[1], lines[2], lines[3]}, 2},
+		{"reordered", []string{lines[0], lines[2], lines[1], lines[3]}, 1},
+		{"unsigned", []string{lines[0], "[2024-01-15 10:30:45.123] INFO: forged {}\n", lines[1]}, 1},
+		{"first removed", lines[1:], 0},
+	} {
+		if i, err := VerifyChain(strings.NewReader(strings.Join(tc.lines, "")), chainKey); i != tc.want || err != nil {
+			t.Errorf("%s: VerifyChain() = %d, %v, want %d", tc.name, i, err, tc.want)
+		}
+	}
+}
+
+func TestSigningWriterHeadFile(t *testing.T) {
+	dir := t.TempDir()
+	head := filepath.Join(dir, "audit.head")
+	var first, second bytes.Buffer
+	signedLog(t, &first, head, "one", "two")
+	// The chain continues after a restart into a rotated file.
+	signedLog(t, &second, head, "three")
+
+	if i, err := VerifyChain(io.MultiReader(&first, &second), chainKey); i != -1 || err != nil {
+		t.Errorf("VerifyChain() across restarts = %d, %v, want -1", i, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the head file", len(entries))
+	}
+	sig, _ := os.ReadFile(head)
+	if len(sig) != sigLen {
+		t.Errorf("head file holds %q, want a signature", sig)
+	}
+
+	// The head is only saved by Flush and Close.
+	sw, err := NewSigningWriter(io.Discard, SigningOptions{Key: chainKey, HeadFile: head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.Write([]byte("four\n"))
+	if saved, _ := os.ReadFile(head); !bytes.Equal(saved, sig) {
+		t.Error("Write saved the head")
+	}
+	if err := sw.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := os.ReadFile(head); bytes.Equal(saved, sig) || len(saved) != sigLen {
+		t.Errorf("Flush saved %q, want the signature of the new record", saved)
+	}
+
+	if err := os.WriteFile(head, sig[:10], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigningWriter(io.Discard, SigningOptions{Key: chainKey, HeadFile: head}); err == nil {
+		t.Error("NewSigningWriter accepted a truncated head file")
+	}
+}
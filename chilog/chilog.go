@@ -0,0 +1,20 @@
+// Package chilog adapts the go-log HTTP middleware to the chi router.
+package chilog
+
+import (
+	"context"
+
+	"github.com/corray333/go-log/middleware"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestID makes the logger middleware read request IDs set by chi's
+// middleware.RequestID and store generated IDs under chi's context key,
+// so that middleware.GetReqID keeps working downstream.
+func RequestID() middleware.Option {
+	return middleware.WithRequestIDContext(chimiddleware.GetReqID, setReqID)
+}
+
+func setReqID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, chimiddleware.RequestIDKey, id)
+}
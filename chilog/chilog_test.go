@@ -0,0 +1,38 @@
+package chilog
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/middleware"
+	"github.com/corray333/go-log/testutil"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	for _, withChi := range []bool{false, true} {
+		capture := testutil.NewCaptureHandler()
+		var downstream string
+		r := chi.NewRouter()
+		if withChi {
+			r.Use(chimiddleware.RequestID)
+		}
+		r.Use(middleware.NewLoggerMiddleware(slog.New(capture), RequestID()))
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			downstream = chimiddleware.GetReqID(r.Context())
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		recs := capture.Find("request completed")
+		if len(recs) != 1 {
+			t.Fatalf("chi RequestID %v: got %d records", withChi, len(recs))
+		}
+		v, _ := recs[0].Attr("request_id")
+		if downstream == "" || v.String() != downstream {
+			t.Errorf("chi RequestID %v: logged %q, GetReqID %q", withChi, v, downstream)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/corray333/go-log/chilog
+
+go 1.25.2
+
+require (
+	github.com/corray333/go-log v0.0.0-00010101000000-000000000000
+	github.com/go-chi/chi/v5 v5.2.3
+)
+
+replace github.com/corray333/go-log => ../
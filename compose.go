@@ -0,0 +1,289 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HandlerMiddleware wraps a handler, like Dedup. The handlers it returns must
+// wrap the results of next.WithAttrs and next.WithGroup in their own WithAttrs
+// and WithGroup, so that attrs and groups reach the end of a Chain.
+type HandlerMiddleware func(next slog.Handler) slog.Handler
+
+// Chain returns h wrapped in mw, which see records in order: with
+// Chain(base, a, b), records pass through a, then b, then reach base.
+func Chain(h slog.Handler, mw ...HandlerMiddleware) slog.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// Intercept returns a HandlerMiddleware calling fn for each record with the
+// handler next in the chain, derived with the attrs and groups added to the
+// chain. fn may change the record, drop it by not calling next, or pass it on:
+//
+//	logger.Intercept(func(ctx context.Context, r slog.Record, next func(context.Context, slog.Record) error) error {
+//		r.AddAttrs(slog.String("region", region))
+//		return next(ctx, r)
+//	})
+//
+// Attrs added by fn are nested in the groups of the chain, like the attrs of r.
+func Intercept(fn func(ctx context.Context, r slog.Record, next func(context.Context, slog.Record) error) error) HandlerMiddleware {
+	return func(next slog.Handler) slog.Handler {
+		return &interceptHandler{h: next, fn: fn}
+	}
+}
+
+type interceptHandler struct {
+	h  slog.Handler
+	fn func(ctx context.Context, r slog.Record, next func(context.Context, slog.Record) error) error
+}
+
+func (h *interceptHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *interceptHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &interceptHandler{h: h.h.WithAttrs(attrs), fn: h.fn}
+}
+
+func (h *interceptHandler) WithGroup(name string) slog.Handler {
+	return &interceptHandler{h: h.h.WithGroup(name), fn: h.fn}
+}
+
+func (h *interceptHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.fn(ctx, r, h.h.Handle)
+}
+
+// Dedup returns a HandlerMiddleware suppressing repeated records with a
+// RepeatHandler, and the RepeatHandlers it creates, which must be closed to
+// stop their background goroutines:
+//
+//	dedup, repeats := logger.Dedup(nil)
+//	defer repeats.Close()
+//	logger.RegisterFlusher(repeats)
+//	h := logger.Chain(base, dedup)
+func Dedup(opts *RepeatOptions) (HandlerMiddleware, *RepeatHandlers) {
+	hs := &RepeatHandlers{}
+	return func(next slog.Handler) slog.Handler {
+		h := NewRepeatHandler(next, opts)
+		hs.m.Lock()
+		hs.list = append(hs.list, h)
+		hs.m.Unlock()
+		return h
+	}, hs
+}
+
+// RepeatHandlers are the RepeatHandlers created by the HandlerMiddleware of
+// Dedup, one for each handler it wrapped. It implements Flusher, so it can be
+// passed to RegisterFlusher.
+type RepeatHandlers struct {
+	m    sync.Mutex
+	list []*RepeatHandler
+}
+
+func (hs *RepeatHandlers) handlers() []*RepeatHandler {
+	hs.m.Lock()
+	defer hs.m.Unlock()
+	return slices.Clone(hs.list)
+}
+
+// Flush writes the summaries of the records repeated since the last summary.
+func (hs *RepeatHandlers) Flush(ctx context.Context) error {
+	var errs []error
+	for _, h := range hs.handlers() {
+		errs = append(errs, h.Flush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the RepeatHandlers, writing their pending summaries.
+func (hs *RepeatHandlers) Close() error {
+	var errs []error
+	for _, h := range hs.handlers() {
+		errs = append(errs, h.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Redact returns a HandlerMiddleware replacing the values of attrs with the
+// given keys, compared case-insensitively, with "[REDACTED]", also in groups
+// and in the attrs added with WithAttrs. Without keys, the values of keys that
+// look like secrets, like "db_password" or "api_token", are redacted.
+func Redact(keys ...string) HandlerMiddleware {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = true
+	}
+	match := isSensitive
+	if len(set) > 0 {
+		match = func(k string) bool { return set[strings.ToLower(k)] }
+	}
+	return func(next slog.Handler) slog.Handler {
+		return &redactHandler{h: next, match: match}
+	}
+}
+
+type redactHandler struct {
+	h     slog.Handler
+	match func(key string) bool
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &redactHandler{h: h.h.WithAttrs(h.redact(attrs)), match: h.match}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{h: h.h.WithGroup(name), match: h.match}
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(h.redact(attrs)...)
+	return h.h.Handle(ctx, out)
+}
+
+// redact returns a copy of attrs with the values of matching keys redacted.
+// LogValuers are resolved, as their values could hold secrets.
+func (h *redactHandler) redact(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if h.match(a.Key) {
+			a.Value = slog.StringValue(redacted)
+		} else if a.Value = a.Value.Resolve(); a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(h.redact(a.Value.Group())...)
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// Sample returns a HandlerMiddleware passing on a random share rate of the
+// records below Warn level, like 0.1 for one in ten. Records at Warn level and
+// above are always passed on. It panics if rate isn't between 0 and 1.
+func Sample(rate float64) HandlerMiddleware {
+	if !(rate >= 0 && rate <= 1) {
+		panic(fmt.Sprintf("logger: sample rate %v isn't between 0 and 1", rate))
+	}
+	return func(next slog.Handler) slog.Handler {
+		return &sampleHandler{h: next, rate: rate, rand: rand.Float64}
+	}
+}
+
+type sampleHandler struct {
+	h    slog.Handler
+	rate float64
+	rand func() float64
+}
+
+func (h *sampleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampleHandler{h: h.h.WithAttrs(attrs), rate: h.rate, rand: h.rand}
+}
+
+func (h *sampleHandler) WithGroup(name string) slog.Handler {
+	return &sampleHandler{h: h.h.WithGroup(name), rate: h.rate, rand: h.rand}
+}
+
+func (h *sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && h.rand() >= h.rate {
+		return nil
+	}
+	return h.h.Handle(ctx, r)
+}
+
+// RateLimit returns a HandlerMiddleware passing on perSecond records per
+// second on average, in bursts of up to burst records, and dropping the others.
+// The limit is shared by the handlers derived from the wrapped one. The next
+// record passed on after records were dropped gets a "rate_limited" attr
+// counting them.
+func RateLimit(perSecond float64, burst int) HandlerMiddleware {
+	return func(next slog.Handler) slog.Handler {
+		return &rateLimitHandler{h: next, l: &rateLimiter{
+			rate:   perSecond,
+			burst:  float64(max(burst, 1)),
+			tokens: float64(max(burst, 1)),
+			now:    time.Now,
+		}}
+	}
+}
+
+type rateLimitHandler struct {
+	h slog.Handler
+	l *rateLimiter
+}
+
+// rateLimiter is a token bucket.
+type rateLimiter struct {
+	m       sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	dropped uint64
+	now     func() time.Time
+}
+
+// take takes a token, reporting whether there was one and the number of records
+// dropped since the last token was taken.
+func (l *rateLimiter) take() (bool, uint64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		l.dropped++
+		return false, 0
+	}
+	l.tokens--
+	dropped := l.dropped
+	l.dropped = 0
+	return true, dropped
+}
+
+func (h *rateLimitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *rateLimitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &rateLimitHandler{h: h.h.WithAttrs(attrs), l: h.l}
+}
+
+func (h *rateLimitHandler) WithGroup(name string) slog.Handler {
+	return &rateLimitHandler{h: h.h.WithGroup(name), l: h.l}
+}
+
+func (h *rateLimitHandler) Handle(ctx context.Context, r slog.Record) error {
+	ok, dropped := h.l.take()
+	if !ok {
+		return nil
+	}
+	if dropped > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Uint64("rate_limited", dropped))
+	}
+	return h.h.Handle(ctx, r)
+}
@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timeRE matches the timestamp prefix of rendered lines.
+var timeRE = regexp.MustCompile(`(?m)^\[[^\]]*\] `)
+
+// mark returns middleware appending name to the message of records, to check
+// the order records pass through a chain.
+func mark(name string) HandlerMiddleware {
+	return Intercept(func(ctx context.Context, r slog.Record, next func(context.Context, slog.Record) error) error {
+		r.Message += " " + name
+		r.AddAttrs(slog.String("by_"+name, "x"))
+		return next(ctx, r)
+	})
+}
+
+func chainOutput(t *testing.T, mw ...HandlerMiddleware) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	base := NewHandler(&HandlerOptions{Writer: &buf, HandlerOptions: &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}})
+	return slog.New(Chain(base, mw...)), &buf
+}
+
+func TestChainOrder(t *testing.T) {
+	l, buf := chainOutput(t, mark("a"), mark("b"), mark("c"))
+	l.Info("msg")
+	if got, want := buf.String(), "INFO: msg a b c {\"by_a\":\"x\",\"by_b\":\"x\",\"by_c\":\"x\"}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestChainWithAttrsAndGroups(t *testing.T) {
+	l, buf := chainOutput(t, mark("a"), Redact("password"), mark("c"))
+	l = l.With("svc", "api").WithGroup("req").With("id", 7, "password", "hunter2").WithGroup("user")
+	l.Info("login", "name", "alice", "password", "hunter2")
+
+	want := `{"svc":"api","req":{"id":7,"password":"[REDACTED]","user":{"name":"alice","password":"[REDACTED]","by_a":"x","by_c":"x"}}}`
+	if got := buf.String(); !strings.Contains(got, "login a c "+want) {
+		t.Errorf("got %q, want attrs %s", got, want)
+	}
+
+	// Handlers derived at different depths stay independent.
+	buf.Reset()
+	l.WithGroup("other").Info("x")
+	l.Info("y")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"user":{"other":{"by_a":"x","by_c":"x"}}`) || !strings.Contains(lines[1], `"user":{"by_a":"x","by_c":"x"}`) {
+		t.Errorf("derived handlers wrote %q", lines)
+	}
+}
+
+func TestChainEmpty(t *testing.T) {
+	base := NewHandler(nil)
+	if Chain(base) != slog.Handler(base) {
+		t.Error("Chain without middleware wrapped the handler")
+	}
+}
+
+func TestRedactSensitiveKeys(t *testing.T) {
+	l, buf := chainOutput(t, Redact())
+	l.Info("connect", "db_password", "hunter2", "api_token", "t0k3n", "user", "alice",
+		slog.Group("creds", "secret", "s3cr3t"))
+	if got := buf.String(); strings.Contains(got, "hunter2") || strings.Contains(got, "t0k3n") ||
+		strings.Contains(got, "s3cr3t") || !strings.Contains(got, `"user":"alice"`) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSample(t *testing.T) {
+	var n int
+	count := Intercept(func(ctx context.Context, r slog.Record, next func(context.Context, slog.Record) error) error {
+		n++
+		return nil
+	})
+	for _, tc := range []struct {
+		rate     float64
+		min, max int
+	}{{0, 100, 100}, {1, 1100, 1100}, {0.5, 350, 650}} {
+		n = 0
+		l := slog.New(Chain(NewHandler(nil), Sample(tc.rate), count))
+		for range 1000 {
+			l.Info("sampled")
+		}
+		for range 100 {
+			l.Warn("kept")
+		}
+		if n < tc.min || n > tc.max {
+			t.Errorf("rate %v: passed %d records, want %d to %d", tc.rate, n, tc.min, tc.max)
+		}
+	}
+}
+
+func TestSampleInvalidRate(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.5, math.NaN(), math.Inf(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Sample(%v) didn't panic", rate)
+				}
+			}()
+			Sample(rate)
+		}()
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l, buf := chainOutput(t, RateLimit(10, 3))
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	l.Handler().(*rateLimitHandler).l.now = func() time.Time { return now }
+
+	for range 5 {
+		l.Info("burst")
+	}
+	now = now.Add(100 * time.Millisecond)
+	l.Info("refilled")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[3], `refilled {"rate_limited":2}`) {
+		t.Errorf("got %q, want 3 records of the burst and the refilled one", lines)
+	}
+}
+
+func TestDedupClose(t *testing.T) {
+	dedup, repeats := Dedup(&RepeatOptions{Interval: time.Hour})
+	l, buf := chainOutput(t, dedup)
+	for range 3 {
+		l.Warn("disk full")
+	}
+	if err := repeats.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("disk full")
+	if err := repeats.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range repeats.handlers() {
+		select {
+		case <-h.r.done:
+		default:
+			t.Error("Close didn't stop the RepeatHandler")
+		}
+	}
+	want := "WARN: disk full {}\nWARN: last message repeated 2 times {\"message\":\"disk full\"}\nWARN: last message repeated 1 times {\"message\":\"disk full\"}\n"
+	if got := timeRE.ReplaceAllString(buf.String(), ""); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Compressor is a streaming compressor, like *gzip.Writer.
+type Compressor interface {
+	io.WriteCloser
+	// Flush writes the data compressed so far to the underlying writer.
+	Flush() error
+}
+
+// CompressionOptions configures a CompressingWriter.
+type CompressionOptions struct {
+	// NewCompressor returns a compressor writing to w. It defaults to gzip at
+	// the default level; zstd and other formats can be plugged in here.
+	NewCompressor func(w io.Writer) (Compressor, error)
+	// FlushInterval is the time after which the records compressed so far are
+	// flushed, so that readers of the stream aren't starved. It defaults to one
+	// second.
+	FlushInterval time.Duration
+	// FlushRecords flushes after every FlushRecords records when positive.
+	FlushRecords int
+	// CloseWriter closes the underlying writer when its stream ends, on Rotate
+	// and Close, if it is an io.Closer. Set it for writers the CompressingWriter
+	// owns, like files opened for it; others, like os.Stdout, are left open.
+	CloseWriter bool
+}
+
+// CompressingWriter compresses records on the fly, for machine-readable logs
+// written to files and pipes. It implements Flusher, so it can be passed to
+// RegisterFlusher, and must be closed to end the stream. Used by a handler from
+// NewHandler, it passes the compressed data on with the highest level of the
+// records in it, and flushes records at Error level and above right away, so
+// that a FileWriter with SyncOnError syncs them.
+type CompressingWriter struct {
+	m       sync.Mutex
+	w       io.Writer
+	c       Compressor
+	opts    CompressionOptions
+	records int
+	// dirty is set when records were written since the last flush.
+	dirty bool
+	// level is the highest level of the records written since the last flush.
+	level    slog.Level
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCompressingWriter returns a CompressingWriter writing to w.
+func NewCompressingWriter(w io.Writer, opts *CompressionOptions) (*CompressingWriter, error) {
+	cw := &CompressingWriter{w: w}
+	if opts != nil {
+		cw.opts = *opts
+	}
+	if cw.opts.NewCompressor == nil {
+		cw.opts.NewCompressor = func(w io.Writer) (Compressor, error) {
+			return gzip.NewWriter(w), nil
+		}
+	}
+	if cw.opts.FlushInterval <= 0 {
+		cw.opts.FlushInterval = time.Second
+	}
+	c, err := cw.opts.NewCompressor(compressedWriter{cw})
+	if err != nil {
+		return nil, err
+	}
+	cw.c = c
+	cw.stop = make(chan struct{})
+	cw.done = make(chan struct{})
+	go cw.flushEvery(cw.opts.FlushInterval)
+	return cw, nil
+}
+
+func (cw *CompressingWriter) flushEvery(d time.Duration) {
+	defer close(cw.done)
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cw.stop:
+			return
+		case <-ticker.C:
+			_ = cw.Flush(context.Background())
+		}
+	}
+}
+
+// Write compresses the record p.
+func (cw *CompressingWriter) Write(p []byte) (int, error) {
+	return cw.writeLevel(slog.LevelInfo, p)
+}
+
+// writeLevel compresses p, a record at level.
+func (cw *CompressingWriter) writeLevel(level slog.Level, p []byte) (int, error) {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+	if !cw.dirty || level > cw.level {
+		cw.level = level
+	}
+	cw.dirty = true
+	n, err := cw.c.Write(p)
+	if err != nil {
+		return n, err
+	}
+	cw.records++
+	if level >= slog.LevelError || (cw.opts.FlushRecords > 0 && cw.records >= cw.opts.FlushRecords) {
+		err = cw.flush()
+	}
+	return n, err
+}
+
+// Flush writes the records compressed so far to the underlying writer. It
+// does nothing when no records were written since the last flush, as every
+// flush adds a few bytes to the stream.
+func (cw *CompressingWriter) Flush(context.Context) error {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+	if !cw.dirty {
+		return nil
+	}
+	return cw.flush()
+}
+
+func (cw *CompressingWriter) flush() error {
+	err := cw.c.Flush()
+	cw.records, cw.dirty = 0, false
+	return err
+}
+
+// compressedWriter passes the data of the compressor on to the underlying
+// writer with the level of the records in it, or Info for the data framing
+// them.
+type compressedWriter struct {
+	cw *CompressingWriter
+}
+
+func (w compressedWriter) Write(p []byte) (int, error) {
+	level := slog.LevelInfo
+	if w.cw.dirty {
+		level = w.cw.level
+	}
+	return writeLevel(w.cw.w, level, p)
+}
+
+// Rotate ends the stream, closes the underlying writer with CloseWriter, and
+// starts a new stream on w, so that each rotated file is a complete
+// compressed stream of its own.
+func (cw *CompressingWriter) Rotate(w io.Writer) error {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+	err := cw.closeStream()
+	cw.w, cw.records, cw.dirty = w, 0, false
+	c, cerr := cw.opts.NewCompressor(compressedWriter{cw})
+	if cerr != nil {
+		return errors.Join(err, cerr)
+	}
+	cw.c = c
+	return err
+}
+
+// Close stops background flushing, ends the stream and closes the underlying
+// writer with CloseWriter.
+func (cw *CompressingWriter) Close() error {
+	cw.stopOnce.Do(func() { close(cw.stop) })
+	<-cw.done
+	cw.m.Lock()
+	defer cw.m.Unlock()
+	return cw.closeStream()
+}
+
+func (cw *CompressingWriter) closeStream() error {
+	err := cw.c.Close()
+	if c, ok := cw.w.(io.Closer); ok && cw.opts.CloseWriter {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
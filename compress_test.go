@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// closeBuffer is a bytes.Buffer recording whether it was closed.
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Multistream(false)
+	p, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(p)
+}
+
+func TestCompressingWriter(t *testing.T) {
+	var buf closeBuffer
+	cw, err := NewCompressingWriter(&buf, &CompressionOptions{FlushRecords: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.Write([]byte("one\n"))
+	n := buf.Len()
+	cw.Write([]byte("two\n"))
+	if buf.Len() == n {
+		t.Error("FlushRecords didn't flush")
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := gunzip(t, buf.Bytes()); got != "one\ntwo\n" {
+		t.Errorf("got %q", got)
+	}
+	if buf.closed {
+		t.Error("Close closed a writer without CloseWriter")
+	}
+}
+
+func TestCompressingWriterRotate(t *testing.T) {
+	for _, closeWriter := range []bool{false, true} {
+		var first, second closeBuffer
+		cw, err := NewCompressingWriter(&first, &CompressionOptions{CloseWriter: closeWriter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cw.Write([]byte("first\n"))
+		if err := cw.Rotate(&second); err != nil {
+			t.Fatal(err)
+		}
+		cw.Write([]byte("second\n"))
+		if err := cw.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := cw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := gunzip(t, first.Bytes()); got != "first\n" {
+			t.Errorf("first stream %q", got)
+		}
+		if got := gunzip(t, second.Bytes()); got != "second\n" {
+			t.Errorf("second stream %q", got)
+		}
+		if first.closed != closeWriter || second.closed != closeWriter {
+			t.Errorf("CloseWriter %v: closed %v and %v", closeWriter, first.closed, second.closed)
+		}
+	}
+}
+
+func TestCompressingWriterIdleFlush(t *testing.T) {
+	var buf closeBuffer
+	cw, err := NewCompressingWriter(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+	cw.Write([]byte("one\n"))
+	cw.Flush(context.Background())
+	n := buf.Len()
+	for range 10 {
+		if err := cw.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != n {
+		t.Errorf("idle flushes grew the stream from %d to %d bytes", n, buf.Len())
+	}
+}
+
+// syncFile is a file counting its syncs.
+type syncFile struct {
+	closeBuffer
+	syncs int
+}
+
+func (f *syncFile) Sync() error {
+	f.syncs++
+	return nil
+}
+
+func TestCompressingWriterSyncOnError(t *testing.T) {
+	var f syncFile
+	fw := newFileWriter(&f, "app.log.gz", &FileWriterOptions{SyncOnError: true})
+	cw, err := NewCompressingWriter(fw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(NewHandler(&HandlerOptions{Writer: cw}))
+	l.Info("started")
+	if f.syncs != 0 {
+		t.Errorf("synced %d times after an Info record", f.syncs)
+	}
+	l.Error("failed")
+	if f.syncs == 0 {
+		t.Error("Error record wasn't synced")
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := gunzip(t, f.Bytes()); !strings.Contains(got, "INFO: started") || !strings.Contains(got, "ERROR: failed") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	timeType     = reflect.TypeFor[time.Time]()
+	stringerType = reflect.TypeFor[fmt.Stringer]()
+)
+
+// DumpConfig logs cfg, typically a configuration struct, at Info level as
+// "configuration loaded" to the logger of ctx. The "config" attr is a group
+// mirroring the layout of cfg: structs and maps become nested groups, embedded
+// structs are inlined and durations stay durations, written like other duration
+// attrs. Types with a String method are written as strings.
+//
+// Fields tagged `log:"-"` and unexported fields are left out. Fields tagged
+// `log:"mask"` and fields and map keys that look like secrets, like DBPassword or
+// "api_token", are redacted, as are passwords in URLs.
+func DumpConfig(ctx context.Context, cfg any) {
+	FromContext(ctx).LogAttrs(ctx, slog.LevelInfo, "configuration loaded",
+		slog.Attr{Key: "config", Value: configValue(reflect.ValueOf(cfg), 0)})
+}
+
+func configValue(v reflect.Value, depth int) slog.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return slog.AnyValue(nil)
+	}
+	if depth > maxFallbackDepth {
+		return slog.StringValue("...")
+	}
+
+	switch t := v.Type(); {
+	case t == durationType:
+		return slog.DurationValue(time.Duration(v.Int()))
+	case t == timeType:
+		return slog.TimeValue(v.Interface().(time.Time))
+	case t.Implements(stringerType):
+		return slog.StringValue(redactURL(v.Interface().(fmt.Stringer).String()))
+	case t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(stringerType):
+		p := reflect.New(t)
+		p.Elem().Set(v)
+		return slog.StringValue(redactURL(p.Interface().(fmt.Stringer).String()))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return slog.GroupValue(configFields(v, depth)...)
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, i := range sortedIndexes(names) {
+			attrs = append(attrs, configAttr(names[i], v.MapIndex(keys[i]), false, depth))
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return slog.StringValue(string(v.Bytes()))
+		}
+		elems := make([]any, v.Len())
+		for i := range elems {
+			elems[i] = valueAny(configValue(v.Index(i), depth+1))
+		}
+		return slog.AnyValue(elems)
+	case reflect.String:
+		return slog.StringValue(redactURL(v.String()))
+	default:
+		return slog.AnyValue(v.Interface())
+	}
+}
+
+// configFields returns the attrs for the exported fields of the struct v.
+func configFields(v reflect.Value, depth int) []slog.Attr {
+	var attrs []slog.Attr
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("log")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && tag != "mask" {
+			if fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && !fv.Type().Implements(stringerType) {
+				attrs = append(attrs, configFields(fv, depth+1)...)
+				continue
+			}
+		}
+		attrs = append(attrs, configAttr(f.Name, fv, tag == "mask", depth))
+	}
+	return attrs
+}
+
+func configAttr(key string, v reflect.Value, mask bool, depth int) slog.Attr {
+	if mask || isSensitive(key) {
+		return slog.String(key, redacted)
+	}
+	return slog.Attr{Key: key, Value: configValue(v, depth+1)}
+}
+
+// valueAny returns v as a value encoding/json renders like the handler renders
+// v, for elements of slices.
+func valueAny(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindGroup:
+		m := make(map[string]any)
+		for _, a := range v.Group() {
+			m[a.Key] = valueAny(a.Value)
+		}
+		return m
+	default:
+		return v.Any()
+	}
+}
+
+// sortedIndexes returns the indexes of names in the order of the names.
+func sortedIndexes(names []string) []int {
+	idx := make([]int, len(names))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortFunc(idx, func(a, b int) int { return cmp.Compare(names[a], names[b]) })
+	return idx
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+type configDB struct {
+	URL      string
+	Password string
+	Timeouts []time.Duration
+}
+
+// ConfigBase is exported, as fields of unexported embedded structs are left
+// out.
+type ConfigBase struct {
+	Service string
+	Region  string
+}
+
+type configNode struct {
+	Name string
+	Next *configNode
+}
+
+type testConfig struct {
+	ConfigBase
+	Port     int
+	Timeout  time.Duration
+	Started  time.Time
+	Addr     net.IP
+	DB       configDB
+	Cache    *configDB
+	Pepper   string `log:"mask"`
+	Internal string `log:"-"`
+	internal string
+	Limits   map[string]any
+	Chain    *configNode
+}
+
+func TestDumpConfig(t *testing.T) {
+	chain := &configNode{Name: "a"}
+	chain.Next = chain
+	cfg := testConfig{
+		ConfigBase: ConfigBase{Service: "api", Region: "eu"},
+		Port:       8080,
+		Timeout:    30 * time.Second,
+		Started:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Addr:       net.IPv4(10, 0, 0, 1),
+		DB: configDB{
+			URL:      "postgres://app:hunter2@db/app",
+			Password: "hunter2",
+			Timeouts: []time.Duration{time.Second, time.Minute},
+		},
+		Pepper:   "salt",
+		Internal: "hidden",
+		internal: "hidden",
+		Limits:   map[string]any{"rps": 100, "api_token": "t0k3n", "burst": []string{"a"}},
+		Chain:    chain,
+	}
+	var buf bytes.Buffer
+	ctx := IntoContext(context.Background(), slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true})))
+	DumpConfig(ctx, &cfg)
+
+	var record struct {
+		Level, Msg string
+		Config     json.RawMessage
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	want := `{"Service":"api","Region":"eu","Port":8080,"Timeout":30000000000,"Started":"2024-01-15T10:00:00Z","Addr":"10.0.0.1",` +
+		`"DB":{"URL":"postgres://app:xxxxx@db/app","Password":"[REDACTED]","Timeouts":[1000000000,60000000000]},"Cache":null,"Pepper":"[REDACTED]",` +
+		`"Limits":{"api_token":"[REDACTED]","burst":["a"],"rps":100},` +
+		`"Chain":{"Name":"a","Next":{"Name":"a","Next":{"Name":"a","Next":{"Name":"a","Next":{"Name":"a","Next":{"Name":"a","Next":{"Name":"a","Next":{"Name":"...","Next":"..."}}}}}}}}}`
+	if record.Level != "INFO" || record.Msg != "configuration loaded" || string(record.Config) != want {
+		t.Errorf("got %s %s %s\nwant config %s", record.Level, record.Msg, record.Config, want)
+	}
+}
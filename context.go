@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type (
+	loggerKey     struct{}
+	requestIDKey  struct{}
+	forceDebugKey struct{}
+	tenantKey     struct{}
+)
+
+// IntoContext returns a copy of ctx carrying l.
+func IntoContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by IntoContext,
+// or slog.Default() when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" when there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant, which handlers
+// with HandlerOptions.Tenant add to records.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx, or "" when there is none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// ForceDebug returns a copy of ctx for which handlers from NewHandler log
+// records at Debug level and above, regardless of their level, unless
+// HandlerOptions.DebugWhen is set.
+func ForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceDebugKey{}, true)
+}
+
+// DebugForced reports whether ctx comes from ForceDebug, for DebugWhen funcs
+// that add their own conditions.
+func DebugForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceDebugKey{}).(bool)
+	return forced
+}
+
+// Detach returns a context carrying the values of ctx, such as the logger and
+// request ID, that is not cancelled when ctx is. Use it for work that outlives
+// the request that started it.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// Go runs fn in a new goroutine with Detach(ctx). A panic in fn is recovered and
+// logged at Error level with its stack to the logger of ctx.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = Detach(ctx)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				FromContext(ctx).ErrorContext(ctx, "panic recovered in goroutine",
+					slog.Any("panic", rec),
+					slog.String("stack", PanicStack()),
+				)
+			}
+		}()
+		fn(ctx)
+	}()
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextLogger(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != slog.Default() {
+		t.Error("FromContext without a logger isn't slog.Default()")
+	}
+	l := slog.New(slog.DiscardHandler)
+	if FromContext(IntoContext(ctx, l)) != l {
+		t.Error("FromContext didn't return the logger of IntoContext")
+	}
+}
+
+// lineWriter sends every write to a channel.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func requestIDAttr(ctx context.Context) []slog.Attr {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return []slog.Attr{slog.String("request_id", id)}
+	}
+	return nil
+}
+
+func TestDetach(t *testing.T) {
+	l := slog.New(slog.DiscardHandler)
+	parent, cancel := context.WithCancel(IntoContext(ContextWithRequestID(context.Background(), "req-1"), l))
+	ctx := Detach(parent)
+	cancel()
+
+	if ctx.Err() != nil {
+		t.Errorf("detached context cancelled with its parent: %v", ctx.Err())
+	}
+	if FromContext(ctx) != l || RequestIDFromContext(ctx) != "req-1" {
+		t.Error("detached context lost the logger or request ID")
+	}
+}
+
+func TestGo(t *testing.T) {
+	lines := make(lineWriter, 2)
+	l := slog.New(NewHandler(&HandlerOptions{Writer: lines, ContextExtractors: []ContextExtractor{requestIDAttr}}))
+	parent, cancel := context.WithCancel(IntoContext(ContextWithRequestID(context.Background(), "req-1"), l))
+
+	started := make(chan struct{})
+	Go(parent, func(ctx context.Context) {
+		<-started
+		if ctx.Err() != nil {
+			t.Errorf("goroutine context cancelled: %v", ctx.Err())
+		}
+		FromContext(ctx).InfoContext(ctx, "background work")
+		panic("boom")
+	})
+	cancel()
+	close(started)
+
+	for _, want := range []string{"INFO: background work", "ERROR: panic recovered in goroutine"} {
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, want) || !strings.Contains(line, `"request_id":"req-1"`) {
+				t.Errorf("line = %q, want %q with the request ID", line, want)
+			}
+			if strings.HasPrefix(want, "ERROR") && (!strings.Contains(line, `"panic":"boom"`) || !strings.Contains(line, "stack")) {
+				t.Errorf("panic line = %q, want the panic value and stack", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %q line", want)
+		}
+	}
+}
+
+func TestForceDebug(t *testing.T) {
+	type tenantKey struct{}
+	debugTenant := func(ctx context.Context, r slog.Record) bool {
+		return ctx.Value(tenantKey{}) == "acme" && r.Level >= LevelTrace
+	}
+	plain := context.Background()
+	forced := ForceDebug(plain)
+	acme := context.WithValue(plain, tenantKey{}, "acme")
+	for _, tc := range []struct {
+		name      string
+		debugWhen func(ctx context.Context, r slog.Record) bool
+		ctx       context.Context
+		want      []string
+	}{
+		{"plain", nil, plain, []string{"info"}},
+		{"forced", nil, forced, []string{"debug", "info"}},
+		{"DebugWhen", debugTenant, acme, []string{"trace", "debug", "info"}},
+		{"DebugWhen other tenant", debugTenant, plain, []string{"info"}},
+		{"DebugWhen replaces ForceDebug", debugTenant, forced, []string{"info"}},
+		{"DebugWhen with DebugForced", func(ctx context.Context, r slog.Record) bool {
+			return DebugForced(ctx) && r.Message != "debug"
+		}, forced, []string{"trace", "info"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, DebugWhen: tc.debugWhen})).With("k", 1)
+			l.Log(tc.ctx, LevelTrace, "trace")
+			l.DebugContext(tc.ctx, "debug")
+			l.InfoContext(tc.ctx, "info")
+
+			var got []string
+			for _, r := range parseLines(t, buf.Bytes()) {
+				got = append(got, r[slog.MessageKey].(string))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// maxDiffEntries is the number of differences a Diff lists.
+	maxDiffEntries = 50
+	// maxDiffElements is the number of slice elements a Diff compares.
+	maxDiffElements = 100
+)
+
+// Diff returns an attr with the differences between before and after, such as
+// the state of an entity before and after an update. Both are compared in their
+// JSON encoding, so struct fields are named as in their json tags. The attr is a
+// group like
+//
+//	{"changed":{"status":["active","suspended"]},"added":{"tags[2]":"vip"},"removed":{"address.zip":"10115"}}
+//
+// keyed by the paths of the differences, with nested fields separated by dots
+// and slice elements indexed in brackets. Slices holding the same elements in
+// another order are listed as changed as a whole. A difference of before and
+// after themselves, when they aren't objects or arrays, is keyed by "value".
+// Values of keys that look like secrets are redacted.
+//
+// Diffs list at most 50 differences, compare up to 100 elements of slices and
+// compare values deeper than 8 levels as a whole; "truncated":true marks diffs
+// cut by these limits. The diff is computed when the record is handled.
+//
+// NewHandler with Colorize writes diffs as colored lines of - and + below the
+// record instead.
+func Diff(key string, before, after any) slog.Attr {
+	return slog.Any(key, &diffValue{before: before, after: after})
+}
+
+type diffValue struct {
+	before, after any
+
+	once      sync.Once
+	entries   []diffEntry
+	truncated bool
+	err       error
+}
+
+// diffEntry is a value removed, added, or both when it changed.
+type diffEntry struct {
+	path           string
+	old, new       any
+	removed, added bool
+}
+
+func (d *diffValue) LogValue() slog.Value {
+	d.compute()
+	if d.err != nil {
+		return slog.GroupValue(slog.String("error", d.err.Error()))
+	}
+	var changed, added, removed []slog.Attr
+	for _, e := range d.entries {
+		if e.path == "" {
+			e.path = "value"
+		}
+		switch {
+		case e.removed && e.added:
+			changed = append(changed, slog.Any(e.path, []any{e.old, e.new}))
+		case e.added:
+			added = append(added, slog.Any(e.path, e.new))
+		default:
+			removed = append(removed, slog.Any(e.path, e.old))
+		}
+	}
+	var attrs []slog.Attr
+	for _, g := range []struct {
+		key   string
+		attrs []slog.Attr
+	}{{"changed", changed}, {"added", added}, {"removed", removed}} {
+		if len(g.attrs) > 0 {
+			attrs = append(attrs, slog.Attr{Key: g.key, Value: slog.GroupValue(g.attrs...)})
+		}
+	}
+	if d.truncated {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (d *diffValue) compute() {
+	d.once.Do(func() {
+		before, err := jsonTree(d.before)
+		if err != nil {
+			d.err = err
+			return
+		}
+		after, err := jsonTree(d.after)
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.walk("", before, after, false, 0)
+	})
+}
+
+// jsonTree returns v decoded from its JSON encoding, with numbers kept exact.
+func jsonTree(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	err = dec.Decode(&tree)
+	return tree, err
+}
+
+// walk adds the differences between a and b at path. Secret values are
+// compared, but listed as redacted.
+func (d *diffValue) walk(path string, a, b any, secret bool, depth int) {
+	if depth < maxFallbackDepth && !secret {
+		switch a := a.(type) {
+		case map[string]any:
+			if b, ok := b.(map[string]any); ok {
+				d.walkMaps(path, a, b, depth)
+				return
+			}
+		case []any:
+			if b, ok := b.([]any); ok {
+				d.walkSlices(path, a, b, depth)
+				return
+			}
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		if secret {
+			a, b = redacted, redacted
+		}
+		d.add(diffEntry{path: path, old: a, new: b, removed: true, added: true})
+	}
+}
+
+func (d *diffValue) walkMaps(path string, a, b map[string]any, depth int) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		av, inA := a[k]
+		bv, inB := b[k]
+		secret := isSensitive(k)
+		if secret {
+			av, bv = redacted, redacted
+		}
+		switch {
+		case !inB:
+			d.add(diffEntry{path: p, old: av, removed: true})
+		case !inA:
+			d.add(diffEntry{path: p, new: bv, added: true})
+		default:
+			d.walk(p, a[k], b[k], secret, depth+1)
+		}
+	}
+}
+
+func (d *diffValue) walkSlices(path string, a, b []any, depth int) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	if len(a) > maxDiffElements || len(b) > maxDiffElements {
+		d.truncated = true
+		a, b = a[:min(len(a), maxDiffElements)], b[:min(len(b), maxDiffElements)]
+		if reflect.DeepEqual(a, b) {
+			return
+		}
+	}
+	if sameElements(a, b) {
+		d.add(diffEntry{path: path, old: a, new: b, removed: true, added: true})
+		return
+	}
+	for i := range max(len(a), len(b)) {
+		p := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(b):
+			d.add(diffEntry{path: p, old: a[i], removed: true})
+		case i >= len(a):
+			d.add(diffEntry{path: p, new: b[i], added: true})
+		default:
+			d.walk(p, a[i], b[i], false, depth+1)
+		}
+	}
+}
+
+func (d *diffValue) add(e diffEntry) {
+	if len(d.entries) == maxDiffEntries {
+		d.truncated = true
+		return
+	}
+	d.entries = append(d.entries, e)
+}
+
+// sameElements reports whether a and b hold the same elements in another order.
+func sameElements(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, v := range a {
+		data, _ := json.Marshal(v)
+		counts[string(data)]++
+	}
+	for _, v := range b {
+		data, _ := json.Marshal(v)
+		k := string(data)
+		if counts[k] == 0 {
+			return false
+		}
+		counts[k]--
+	}
+	return true
+}
+
+// diffListing is a Diff written as lines below a record.
+type diffListing struct {
+	key string
+	d   *diffValue
+}
+
+// appendDiffs appends the diffs as lines of - and + below the record, each
+// ending with the line ending.
+func (h *handler) appendDiffs(b []byte, diffs []diffListing) []byte {
+	p := &h.palette
+	line := func(b []byte, style, sign, path string, v any) []byte {
+		b = append(b, "  "...)
+		b = append(b, style...)
+		b = append(b, sign...)
+		b = append(b, ' ')
+		b = appendMessage(b, path, h.controlChars)
+		b = append(b, ": "...)
+		b, _ = appendAny(b, v)
+		b = appendReset(b, style)
+		return append(b, h.lineEnding...)
+	}
+	for _, l := range diffs {
+		l.d.compute()
+		if l.d.err != nil {
+			b = line(b, p.removed, "!", l.key, l.d.err.Error())
+			continue
+		}
+		for _, e := range l.d.entries {
+			path := l.key
+			if e.path != "" && e.path[0] != '[' {
+				path += "."
+			}
+			path += e.path
+			if e.removed {
+				b = line(b, p.removed, "-", path, e.old)
+			}
+			if e.added {
+				b = line(b, p.added, "+", path, e.new)
+			}
+		}
+		if l.d.truncated {
+			b = append(b, "  … "...)
+			b = appendMessage(b, l.key, h.controlChars)
+			b = append(b, ": more differences left out"...)
+			b = append(b, h.lineEnding...)
+		}
+	}
+	return b
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type diffUser struct {
+	Name     string            `json:"name"`
+	Status   string            `json:"status"`
+	Tags     []string          `json:"tags"`
+	Address  map[string]string `json:"address,omitempty"`
+	Password string            `json:"password"`
+	Balance  int64             `json:"balance"`
+}
+
+func TestDiff(t *testing.T) {
+	before := diffUser{Name: "ann", Status: "active", Tags: []string{"a", "b"}, Address: map[string]string{"zip": "10115"}, Password: "old", Balance: 1 << 60}
+	after := diffUser{Name: "ann", Status: "suspended", Tags: []string{"a", "b", "vip"}, Password: "new", Balance: 1<<60 + 1}
+	many := make([]int, 200)
+
+	for _, tc := range []struct {
+		name          string
+		before, after any
+		want          string
+	}{
+		{"structs", before, after,
+			`{"d":{"changed":{"balance":[1152921504606846976,1152921504606846977],"password":["[REDACTED]","[REDACTED]"],"status":["active","suspended"]},` +
+				`"added":{"tags[2]":"vip"},"removed":{"address":{"zip":"10115"}}}}`},
+		{"equal", before, before, `{}`},
+		{"values", 1, 2, `{"d":{"changed":{"value":[1,2]}}}`},
+		{"reordered", []int{1, 2}, []int{2, 1}, `{"d":{"changed":{"value":[[1,2],[2,1]]}}}`},
+		{"type change", map[string]any{"a": 1}, map[string]any{"a": []int{1}}, `{"d":{"changed":{"a":[1,[1]]}}}`},
+		{"long slices", many, append(many[:len(many):len(many)], 1), `{"d":{"truncated":true}}`},
+		{"error", func() {}, 1, `{"d":{"error":"json: unsupported type: func()"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{Writer: &buf})).Info("msg", Diff("d", tc.before, tc.after))
+			if got := buf.String(); !strings.HasSuffix(got, " "+tc.want+"\n") {
+				t.Errorf("got %s\nwant attrs %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiffLimits(t *testing.T) {
+	before, after := make(map[string]int), make(map[string]int)
+	for i := range 60 {
+		before[fmt.Sprintf("k%02d", i)] = i
+		after[fmt.Sprintf("k%02d", i)] = i + 1
+	}
+	v := Diff("d", before, after).Value.Resolve()
+	var changed int
+	var truncated bool
+	for _, a := range v.Group() {
+		switch a.Key {
+		case "changed":
+			changed = len(a.Value.Group())
+		case "truncated":
+			truncated = a.Value.Bool()
+		}
+	}
+	if changed != maxDiffEntries || !truncated {
+		t.Errorf("got %d changes, truncated %v, want %d, true", changed, truncated, maxDiffEntries)
+	}
+}
+
+func TestDiffColorized(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(NewHandler(&HandlerOptions{Writer: &buf, Colorize: true, Theme: &Theme{}})).Info("user updated",
+		"id", 7, Diff("user", map[string]any{"status": "active", "tags": []string{"a"}}, map[string]any{"status": "suspended", "tags": []string{"a", "b"}}))
+	want := ` {"id":7}` + "\n" +
+		"  - user.status: \"active\"\n" +
+		"  + user.status: \"suspended\"\n" +
+		"  + user.tags[1]: \"b\"\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("got %q\nwant suffix %q", got, want)
+	}
+}
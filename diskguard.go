@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// DiskGuard degrades the logging of a FileWriter when the disk runs out of
+// space, instead of filling it. Below the soft threshold, only every
+// SampleEvery-th record below Warn level is written; below the hard threshold,
+// only records at Error level and above are. Logging recovers once space is
+// freed. The changes are logged with slog.Default.
+//
+// A threshold is crossed when the available space is below its bytes or its
+// percentage of the file system, whichever is set. Zero thresholds are off.
+type DiskGuard struct {
+	SoftBytes   uint64
+	SoftPercent float64
+	HardBytes   uint64
+	HardPercent float64
+	// SampleEvery defaults to 10.
+	SampleEvery int
+	// Interval is the time between checks of the available space. It defaults
+	// to 10 seconds.
+	Interval time.Duration
+	// Statfs returns the available and total bytes of the file system holding
+	// path. It defaults to the statfs system call, which is supported on Linux,
+	// macOS and FreeBSD. While it fails, logging isn't degraded.
+	Statfs func(path string) (avail, total uint64, err error)
+}
+
+// diskState is the state of the disk a FileWriter writes to.
+type diskState int32
+
+const (
+	diskOK diskState = iota
+	// diskLow is below the soft threshold.
+	diskLow
+	// diskFull is below the hard threshold.
+	diskFull
+)
+
+// state returns the state of a disk with avail of total bytes available.
+func (g *DiskGuard) state(avail, total uint64) diskState {
+	below := func(bytes uint64, percent float64) bool {
+		return (bytes > 0 && avail < bytes) ||
+			(percent > 0 && total > 0 && float64(avail)/float64(total)*100 < percent)
+	}
+	switch {
+	case below(g.HardBytes, g.HardPercent):
+		return diskFull
+	case below(g.SoftBytes, g.SoftPercent):
+		return diskLow
+	}
+	return diskOK
+}
+
+// checkDisk updates the disk state of w and logs changes.
+func (w *FileWriter) checkDisk() {
+	g := w.opts.DiskGuard
+	dir := filepath.Dir(w.path)
+	avail, total, err := g.Statfs(dir)
+	if err != nil {
+		return
+	}
+	state := g.state(avail, total)
+	if diskState(w.disk.Swap(int32(state))) == state {
+		return
+	}
+	attrs := []any{slog.String("path", dir), slog.Uint64("available_bytes", avail)}
+	switch state {
+	case diskLow:
+		slog.Warn("disk space low, sampling logs below Warn", attrs...)
+	case diskFull:
+		slog.Error("disk space critical, dropping logs below Error", attrs...)
+	default:
+		slog.Info("disk space recovered, logging fully", attrs...)
+	}
+}
+
+// dropped reports whether a record at level is dropped by the DiskGuard.
+func (w *FileWriter) dropped(level slog.Level) bool {
+	switch diskState(w.disk.Load()) {
+	case diskLow:
+		return level < slog.LevelWarn && (w.sampled.Add(1)-1)%uint64(w.opts.DiskGuard.SampleEvery) != 0
+	case diskFull:
+		return level < slog.LevelError
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiskGuard(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var changes bytes.Buffer
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{Writer: &changes})))
+
+	var avail atomic.Uint64
+	avail.Store(1000)
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewFileWriter(path, &FileWriterOptions{DiskGuard: &DiskGuard{
+		SoftBytes:   100,
+		HardPercent: 1,
+		Statfs: func(string) (uint64, uint64, error) {
+			return avail.Load(), 1000, nil
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := slog.New(NewHandler(&HandlerOptions{Writer: w}))
+
+	for _, tc := range []struct {
+		avail                uint64
+		infos, warns, errors int
+		change               string
+	}{
+		{1000, 20, 1, 1, ""},
+		{50, 2, 1, 1, "WARN: disk space low, sampling logs below Warn"},
+		{5, 0, 0, 1, "ERROR: disk space critical, dropping logs below Error"},
+		{500, 20, 1, 1, "INFO: disk space recovered, logging fully"},
+	} {
+		avail.Store(tc.avail)
+		w.checkDisk()
+		if !strings.Contains(changes.String(), tc.change) {
+			t.Errorf("%d bytes available: got changes %q, want %q", tc.avail, changes.String(), tc.change)
+		}
+		changes.Reset()
+
+		before := fileContent(t, path)
+		for range 20 {
+			l.Info("info")
+		}
+		l.Warn("warn")
+		l.Error("error")
+		written := strings.TrimPrefix(fileContent(t, path), before)
+		infos, warns, errors := strings.Count(written, "INFO: "), strings.Count(written, "WARN: "), strings.Count(written, "ERROR: ")
+		if infos != tc.infos || warns != tc.warns || errors != tc.errors {
+			t.Errorf("%d bytes available: wrote %d infos, %d warns and %d errors, want %d, %d and %d",
+				tc.avail, infos, warns, errors, tc.infos, tc.warns, tc.errors)
+		}
+	}
+}
+
+func fileContent(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
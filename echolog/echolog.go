@@ -0,0 +1,83 @@
+// Package echolog adapts the go-log HTTP middleware to the Echo framework.
+package echolog
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+// Echo returns an Echo middleware logging requests with the same fields and
+// options as middleware.NewLoggerMiddleware. The completion record also carries
+// the matched route. Request IDs set by Echo's RequestID middleware are reused.
+func Echo(log *slog.Logger, opts ...middleware.Option) echo.MiddlewareFunc {
+	l := middleware.NewRequestLogger(log, opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			r := c.Request()
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" && logger.RequestIDFromContext(r.Context()) == "" {
+				r = r.WithContext(logger.ContextWithRequestID(r.Context(), id))
+			}
+
+			req := l.Begin(c.Response(), r, slog.String("route", c.Path()))
+			c.SetRequest(req.Request)
+
+			ended := false
+			defer func() {
+				if ended {
+					return
+				}
+				// A panic is unwinding through here. Log the request either way, so
+				// that the in-flight registry stays accurate, but only recover it
+				// with WithRecover.
+				var rec any
+				if l.Recovers() {
+					rec = recover()
+				}
+				res := c.Response()
+				status := res.Status
+				if !res.Committed {
+					status = http.StatusInternalServerError
+				}
+				var attrs []slog.Attr
+				if rec != nil {
+					attrs = append(attrs, slog.Any("panic", rec))
+					if req.Recovered(rec) && !res.Committed {
+						// The error handler writes the 500.
+						err = echo.ErrInternalServerError
+					}
+				}
+				req.End(status, int(res.Size), attrs...)
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+			}()
+
+			err = next(c)
+			ended = true
+
+			res := c.Response()
+			status := res.Status
+			var attrs []slog.Attr
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				// The error handler writes the response only after the middleware chain
+				// has returned, so derive the status it is going to write.
+				if !res.Committed {
+					status = http.StatusInternalServerError
+					var he *echo.HTTPError
+					if errors.As(err, &he) {
+						status = he.Code
+					}
+				}
+			}
+			req.End(status, int(res.Size), attrs...)
+			return err
+		}
+	}
+}
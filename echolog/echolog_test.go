@@ -0,0 +1,103 @@
+package echolog
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/middleware"
+	"github.com/corray333/go-log/testutil"
+	"github.com/labstack/echo/v4"
+)
+
+func newEcho(capture *testutil.CaptureHandler, opts ...middleware.Option) *echo.Echo {
+	e := echo.New()
+	e.Use(Echo(slog.New(capture), opts...))
+	e.GET("/users/:id", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+	e.GET("/missing", func(c echo.Context) error { return echo.NewHTTPError(http.StatusNotFound, "no such user") })
+	e.GET("/panic", func(c echo.Context) error { panic("boom") })
+	e.GET("/written", func(c echo.Context) error {
+		c.String(http.StatusAccepted, "partial")
+		panic("boom")
+	})
+	e.GET("/abort", func(c echo.Context) error { panic(http.ErrAbortHandler) })
+	return e
+}
+
+// serve serves a GET of path and returns the recorder and the value of a
+// panic escaping the router.
+func serve(e *echo.Echo, path string) (w *httptest.ResponseRecorder, rec any) {
+	w = httptest.NewRecorder()
+	defer func() { rec = recover() }()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w, nil
+}
+
+func completed(t *testing.T, capture *testutil.CaptureHandler) testutil.CapturedRecord {
+	t.Helper()
+	recs := capture.Find("request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d completion records, want 1", len(recs))
+	}
+	return recs[0]
+}
+
+func TestEcho(t *testing.T) {
+	for _, tc := range []struct {
+		path, route string
+		status      int64
+	}{
+		{"/users/42", "/users/:id", 200},
+		{"/missing", "/missing", 404},
+	} {
+		capture := testutil.NewCaptureHandler()
+		w, _ := serve(newEcho(capture), tc.path)
+		if int64(w.Code) != tc.status {
+			t.Errorf("%s: response status %d", tc.path, w.Code)
+		}
+		rec := completed(t, capture)
+		for key, want := range map[string]any{"status": tc.status, "route": tc.route, "path": tc.path} {
+			if v, ok := rec.Attr(key); !ok || v.Any() != want {
+				t.Errorf("%s: %s = %v, want %v", tc.path, key, v, want)
+			}
+		}
+	}
+}
+
+func TestEchoPanic(t *testing.T) {
+	for _, tc := range []struct {
+		name, path string
+		recover    bool
+		wantCode   int
+		wantStatus int64
+		escapes    bool
+	}{
+		{"recovered", "/panic", true, http.StatusInternalServerError, 500, false},
+		{"recovered after write", "/written", true, http.StatusAccepted, 202, false},
+		{"not recovered", "/panic", false, 0, 500, true},
+		{"abort", "/abort", true, 0, 500, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			capture := testutil.NewCaptureHandler()
+			var opts []middleware.Option
+			if tc.recover {
+				opts = append(opts, middleware.WithRecover())
+			}
+			w, escaped := serve(newEcho(capture, opts...), tc.path)
+			if (escaped != nil) != tc.escapes {
+				t.Fatalf("panic escaped: %v", escaped)
+			}
+			if !tc.escapes && w.Code != tc.wantCode {
+				t.Errorf("response status %d, want %d", w.Code, tc.wantCode)
+			}
+			if v, _ := completed(t, capture).Attr("status"); v.Int64() != tc.wantStatus {
+				t.Errorf("logged status %v, want %d", v, tc.wantStatus)
+			}
+			logged := len(capture.Find("panic recovered")) > 0
+			if logged != (tc.recover && !tc.escapes) {
+				t.Errorf("panic recovered logged: %v", logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// attrEncoder renders attrs as a JSON object, keeping the kinds of slog values:
+// integers are written exactly, durations in nanoseconds like slog.JSONHandler
+// writes them and times in RFC 3339 format.
+type attrEncoder struct {
+	replace func(groups []string, a slog.Attr) slog.Attr
+	groups  []string
+	// depth is the number of groups being written, including inlined ones.
+	depth int
+	// hexBytes writes []byte values in hex instead of base64.
+	hexBytes bool
+	// humanDurations writes durations like "1.5s" instead of in nanoseconds.
+	humanDurations bool
+	// humanLaps writes the durations of Stopwatch laps like "1.5s" instead of
+	// in nanoseconds.
+	humanLaps bool
+	// limit is the length b may grow to, or 0 for no limit.
+	limit int
+	// maxAttrs is the number of attrs that may be written, or 0 for no limit.
+	// Further attrs are counted in dropped.
+	maxAttrs int
+	written  int
+	dropped  int
+	// secrets scans string values when set. The keys of suspect values are
+	// collected in suspects unless they are masked.
+	secrets  *SecretScan
+	suspects []string
+	// listDiffs collects Diff attrs in diffs instead of writing them.
+	listDiffs bool
+	diffs     []diffListing
+}
+
+// errTruncated is returned when the rendered record reached the limit.
+var errTruncated = errors.New("record truncated")
+
+// truncate cuts b to the limit and returns errTruncated if it exceeds it.
+func (e *attrEncoder) truncate(b []byte) ([]byte, error) {
+	if e.limit > 0 && len(b) > e.limit {
+		return b[:e.limit], errTruncated
+	}
+	return b, nil
+}
+
+// appendAttrs appends the fields for attrs to the object being written to b.
+func (e *attrEncoder) appendAttrs(b []byte, attrs []slog.Attr) ([]byte, error) {
+	var err error
+	for _, a := range attrs {
+		if b, err = e.appendAttr(b, a); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+// bytesValue returns the []byte held by v. It checks the kind first, as Value.Any
+// allocates for values of the other kinds.
+func bytesValue(v slog.Value) ([]byte, bool) {
+	if v.Kind() != slog.KindAny {
+		return nil, false
+	}
+	bs, ok := v.Any().([]byte)
+	return bs, ok
+}
+
+func (e *attrEncoder) appendAttr(b []byte, a slog.Attr) ([]byte, error) {
+	if a.Value.Kind() == slog.KindLogValuer && e.listDiffs && a.Key != "" {
+		if d, ok := a.Value.Any().(*diffValue); ok {
+			e.diffs = append(e.diffs, diffListing{e.path(a.Key), d})
+			return b, nil
+		}
+	}
+	a.Value = a.Value.Resolve()
+	if e.replace != nil && a.Value.Kind() != slog.KindGroup {
+		a = e.replace(e.groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return b, nil
+	}
+
+	if a.Value.Kind() == slog.KindGroup && e.depth >= maxGroupDepth {
+		// Most likely a LogValuer returning a group that contains itself.
+		if a.Key == "" {
+			return b, nil
+		}
+		a.Value = slog.StringValue("...")
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		e.depth++
+		if a.Key == "" {
+			// Groups without a key are inlined.
+			b, err := e.appendAttrs(b, attrs)
+			e.depth--
+			return b, err
+		}
+		b, start := e.openGroup(b, a.Key)
+		b, err := e.appendAttrs(b, attrs)
+		e.depth--
+		if err != nil {
+			return b, err
+		}
+		return e.closeGroup(b, start), nil
+	}
+	if a.Key == "" {
+		return b, nil
+	}
+
+	if e.maxAttrs > 0 {
+		if e.written >= e.maxAttrs {
+			e.dropped++
+			return b, nil
+		}
+		e.written++
+	}
+
+	if e.secrets != nil && a.Value.Kind() == slog.KindString && e.secrets.suspect(a.Value.String()) {
+		if e.secrets.Mask {
+			a.Value = slog.StringValue(redacted)
+		} else {
+			e.suspects = append(e.suspects, e.path(a.Key))
+		}
+	}
+
+	b = appendKey(b, a.Key)
+	if e.limit > 0 && a.Value.Kind() == slog.KindString {
+		// Don't copy more of a huge string than fits.
+		if str := a.Value.String(); len(b)+len(str) > e.limit {
+			a.Value = slog.StringValue(str[:max(e.limit-len(b), 0)])
+		}
+	}
+	if bs, ok := bytesValue(a.Value); ok {
+		b = e.appendBytes(b, bs)
+	} else {
+		b = e.appendValue(b, a.Value)
+	}
+	return e.truncate(b)
+}
+
+// path returns key preceded by the open groups, separated by dots.
+func (e *attrEncoder) path(key string) string {
+	if len(e.groups) == 0 {
+		return key
+	}
+	return strings.Join(append(slices.Clip(e.groups), key), ".")
+}
+
+// openGroup appends the start of a group field and returns the position to
+// pass to closeGroup.
+func (e *attrEncoder) openGroup(b []byte, name string) ([]byte, int) {
+	start := len(b)
+	b = appendKey(b, name)
+	e.groups = append(e.groups, name)
+	return append(b, '{'), start
+}
+
+// closeGroup ends the group opened at start, removing it again when no field
+// was written to it.
+func (e *attrEncoder) closeGroup(b []byte, start int) []byte {
+	e.groups = e.groups[:len(e.groups)-1]
+	if b[len(b)-1] == '{' {
+		return b[:start]
+	}
+	return append(b, '}')
+}
+
+// appendFields appends fields rendered before, like the attrs of WithAttrs.
+func appendFields(b, fields []byte) []byte {
+	if len(fields) == 0 {
+		return b
+	}
+	if b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	return append(b, fields...)
+}
+
+// appendKey appends the key of a field, preceded by a comma unless it is the
+// first field of its object.
+func appendKey(b []byte, key string) []byte {
+	if len(b) > 0 && b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	b = appendString(b, key)
+	return append(b, ':')
+}
+
+// appendBytes appends bs as an object with its length and its base64 or hex
+// encoding.
+func (e *attrEncoder) appendBytes(b, bs []byte) []byte {
+	b = append(b, `{"len":`...)
+	b = strconv.AppendInt(b, int64(len(bs)), 10)
+	if e.limit > 0 {
+		// Don't encode more of a huge slice than fits. The encoding is at least
+		// as long as the bytes, so the record is still cut and marked.
+		bs = bs[:min(len(bs), max(e.limit-len(b), 0))]
+	}
+	if e.hexBytes {
+		b = append(b, `,"hex":"`...)
+		b = hex.AppendEncode(b, bs)
+	} else {
+		b = append(b, `,"base64":"`...)
+		b = base64.StdEncoding.AppendEncode(b, bs)
+	}
+	return append(b, '"', '}')
+}
+
+// appendValue appends v. Values that can't be marshaled are replaced with a
+// string describing the error, so that one bad attr doesn't lose the record.
+func (e *attrEncoder) appendValue(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendString(b, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return appendFloat(b, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		if e.humanDurations {
+			return appendString(b, v.Duration().String())
+		}
+		return strconv.AppendInt(b, int64(v.Duration()), 10)
+	case slog.KindTime:
+		return appendString(b, v.Time().Format(time.RFC3339Nano))
+	default:
+		if d, ok := v.Any().(lapDuration); ok {
+			if e.humanLaps {
+				return appendString(b, d.String())
+			}
+			return strconv.AppendInt(b, int64(d), 10)
+		}
+		b2, err := appendAny(b, v.Any())
+		if err != nil {
+			return appendString(b, "!ERROR marshaling value: "+err.Error())
+		}
+		return b2
+	}
+}
+
+// appendFloat appends f the way encoding/json does. NaN and infinities, which
+// JSON can't represent, are written as the strings "NaN", "+Inf" and "-Inf".
+func appendFloat(b []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return appendString(b, "NaN")
+	case math.IsInf(f, 1):
+		return appendString(b, "+Inf")
+	case math.IsInf(f, -1):
+		return appendString(b, "-Inf")
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// appendAny appends v encoded with encoding/json. Errors that don't implement
+// json.Marshaler are written as their message, and json.Number values verbatim,
+// or as a string when they aren't valid numbers. Values JSON can't represent,
+// like funcs, channels and cycles, are written as strings by fallbackString. A
+// failing or panicking MarshalJSON is reported as an error.
+func appendAny(b []byte, v any) (_ []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	if n, ok := v.(json.Number); ok {
+		if isNumber(string(n)) {
+			return append(b, n...), nil
+		}
+		return appendString(b, string(n)), nil
+	}
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			return appendString(b, err.Error()), nil
+		}
+	}
+
+	if unsupportedType(reflect.TypeOf(v)) {
+		return appendString(b, fallbackString(v)), nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		var typeErr *json.UnsupportedTypeError
+		var valueErr *json.UnsupportedValueError
+		if errors.As(err, &typeErr) || errors.As(err, &valueErr) {
+			return appendString(b, fallbackString(v)), nil
+		}
+		return b, err
+	}
+	return append(b, bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...), nil
+}
+
+// isNumber reports whether s is a valid JSON number.
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	var n json.Number
+	return json.Unmarshal([]byte(s), &n) == nil && string(n) == s
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends s as a JSON string. Control characters, including DEL
+// and the C1 controls, are escaped. Bytes that aren't valid UTF-8 are written as
+// visible escapes like \xff, which decode to a backslash and hex digits.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != 0x7f {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', '\\', 'x', hexDigits[c>>4], hexDigits[c&0xf])
+			i += size
+			start = i
+			continue
+		}
+		if r >= 0x80 && r <= 0x9f {
+			// C1 control characters, like CSI, which terminals may act on.
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// ControlChars selects how control characters in messages are written. Attrs
+// are always written as JSON, which escapes them.
+type ControlChars int
+
+const (
+	// EscapeControlChars writes control characters as escapes like \n and \x1b,
+	// so that messages can't forge records or send sequences to terminals.
+	EscapeControlChars ControlChars = iota
+	// StripControlChars removes control characters.
+	StripControlChars
+	// KeepControlChars writes messages unchanged.
+	KeepControlChars
+)
+
+// appendMessage appends msg with its C0 and C1 control characters other than
+// tabs, and bytes that aren't valid UTF-8, handled as mode says.
+func appendMessage(b []byte, msg string, mode ControlChars) []byte {
+	if mode == KeepControlChars {
+		return append(b, msg...)
+	}
+	start := 0
+	for i := 0; i < len(msg); {
+		c := msg[i]
+		size := 1
+		if c >= utf8.RuneSelf {
+			var r rune
+			r, size = utf8.DecodeRuneInString(msg[i:])
+			if (r < 0x80 || r > 0x9f) && (r != utf8.RuneError || size > 1) {
+				i += size
+				continue
+			}
+		} else if (c >= 0x20 || c == '\t') && c != 0x7f {
+			i++
+			continue
+		}
+
+		b = append(b, msg[start:i]...)
+		if mode == EscapeControlChars {
+			switch c {
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			default:
+				// Control characters are below U+0100, and invalid UTF-8 is
+				// written byte by byte.
+				r := rune(c)
+				if size > 1 {
+					r, _ = utf8.DecodeRuneInString(msg[i:])
+				}
+				b = append(b, '\\', 'x', hexDigits[r>>4], hexDigits[r&0xf])
+			}
+		}
+		i += size
+		start = i
+	}
+	return append(b, msg[start:]...)
+}
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalJSON() ([]byte, error) { panic("boom") }
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("unsupported") }
+
+func TestAppendAny(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"chan", make(chan int), `^"chan int 0x[0-9a-f]+"$`},
+		{"nil chan", (chan int)(nil), `^"chan int <nil>"$`},
+		{"func", func() {}, `^"func\(\) 0x[0-9a-f]+"$`},
+		{"NaN", math.NaN(), `^"NaN"$`},
+		{"+Inf", math.Inf(1), `^"\+Inf"$`},
+		{"-Inf", math.Inf(-1), `^"-Inf"$`},
+		{"nested Inf", []float64{1, math.Inf(-1)}, `^"\[\]float64 \[1 -Inf\]"$`},
+		{"nested func", map[string]any{"f": func() {}}, `^"map\[string\]interface \{\} map\[f:0x[0-9a-f]+\]"$`},
+		{"panicking MarshalJSON", panickingMarshaler{}, `^"!ERROR marshaling value: panic: boom"$`},
+		{"failing MarshalJSON", failingMarshaler{}, `^"!ERROR marshaling value: .*unsupported"$`},
+		{"error", errors.New("failed"), `^"failed"$`},
+		{"big json.Number", json.Number("12345678901234567890"), `^12345678901234567890$`},
+		{"invalid json.Number", json.Number("1e"), `^"1e"$`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true})).Info("msg", "v", tc.value, "after", 1)
+
+			var record struct {
+				V     json.RawMessage
+				After int
+			}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("%v: %s", err, buf.Bytes())
+			}
+			if !regexp.MustCompile(tc.want).Match(record.V) || record.After != 1 {
+				t.Errorf("got %s, want v matching %s", buf.Bytes(), tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxAttrs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		maxAttrs int
+		log      func(l *slog.Logger)
+		want     string
+	}{
+		{"no limit", 0, func(l *slog.Logger) { l.Info("msg", "a", 1, "b", 2, "c", 3) }, `{"a":1,"b":2,"c":3}`},
+		{"exact", 3, func(l *slog.Logger) { l.Info("msg", "a", 1, "b", 2, "c", 3) }, `{"a":1,"b":2,"c":3}`},
+		{"over", 2, func(l *slog.Logger) { l.Info("msg", "a", 1, "b", 2, "c", 3) }, `{"a":1,"b":2,"_truncated_attrs":1}`},
+		{"in groups", 2, func(l *slog.Logger) { l.Info("msg", slog.Group("g", "a", 1, "b", 2), "c", 3) }, `{"g":{"a":1,"b":2},"_truncated_attrs":1}`},
+		{"emptied group", 1, func(l *slog.Logger) { l.Info("msg", "a", 1, slog.Group("g", "b", 2)) }, `{"a":1,"_truncated_attrs":1}`},
+		{"With not counted", 1, func(l *slog.Logger) { l.With("w", 0).Info("msg", "a", 1, "b", 2) }, `{"w":0,"a":1,"_truncated_attrs":1}`},
+		{"WithGroup", 1, func(l *slog.Logger) { l.WithGroup("g").Info("msg", "a", 1, "b", 2) }, `{"g":{"a":1},"_truncated_attrs":1}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.log(slog.New(NewHandler(&HandlerOptions{Writer: &buf, MaxAttrs: tc.maxAttrs})))
+			if got := buf.String(); !strings.HasSuffix(got, " "+tc.want+"\n") {
+				t.Errorf("got %q, want attrs %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHexBytes(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		hex   bool
+		value []byte
+		want  string
+	}{
+		{"base64", false, []byte{1, 2, 3}, `{"len":3,"base64":"AQID"}`},
+		{"hex", true, []byte{1, 2, 0xff}, `{"len":3,"hex":"0102ff"}`},
+		{"empty", true, []byte{}, `{"len":0,"hex":""}`},
+		{"nil", false, nil, `{"len":0,"base64":""}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{Writer: &buf, HexBytes: tc.hex})).Info("msg", "b", tc.value)
+			if got, want := buf.String(), ` {"b":`+tc.want+"}\n"; !strings.HasSuffix(got, want) {
+				t.Errorf("got %q, want suffix %q", got, want)
+			}
+		})
+	}
+}
+
+func TestControlChars(t *testing.T) {
+	const msg = "line 1\nline 2\r\t\x1b[31mred\x7f \u0085 \xff ü"
+	for _, tc := range []struct {
+		name string
+		mode ControlChars
+		want string
+	}{
+		{"escape", EscapeControlChars, `line 1\nline 2\r` + "\t" + `\x1b[31mred\x7f \x85 \xff ü`},
+		{"strip", StripControlChars, "line 1line 2\t[31mred   ü"},
+		{"keep", KeepControlChars, msg},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{Writer: &buf, ControlChars: tc.mode})).Info(msg, "v", msg)
+			want := "INFO: " + tc.want + ` {"v":"line 1\nline 2\r\t\u001b[31mred\u007f \u0085 \\xff ü"}` + "\n"
+			if got := buf.String(); !strings.HasSuffix(got, want) {
+				t.Errorf("got  %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+func TestValueKinds(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC) }
+	at := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.FixedZone("", 2*60*60))
+	for _, tc := range []struct {
+		name string
+		json bool
+		want string
+	}{
+		{"text", false, `[2024-01-15 10:30:45.123] INFO: msg {"id":9007199254740993,"at":"2024-03-01T12:30:45.123456789+02:00"}` + "\n"},
+		{"json", true, `{"time":"2024-01-15T10:30:45.123Z","level":"INFO","msg":"msg","id":9007199254740993,"at":"2024-03-01T12:30:45.123456789+02:00"}` + "\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewHandler(&HandlerOptions{Writer: &buf, JSON: tc.json, Now: now, ForceNow: true})
+			slog.New(h).Info("msg", "id", int64(9007199254740993), "at", at)
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got  %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBoundaryIntegers(t *testing.T) {
+	for _, n := range []int64{math.MaxInt64, math.MinInt64, 1<<53 + 1} {
+		s := strconv.FormatInt(n, 10)
+		t.Run(s, func(t *testing.T) {
+			for _, isJSON := range []bool{false, true} {
+				var buf bytes.Buffer
+				slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: isJSON})).Info("msg",
+					"v", n,
+					slog.Group("g", "v", n, slog.Group("inner", "v", n)),
+					"s", []int64{n, n},
+					"num", json.Number(s),
+					"nums", map[string]any{"a": []any{json.Number(s)}},
+				)
+				want := `"v":` + s + `,"g":{"v":` + s + `,"inner":{"v":` + s + `}},"s":[` + s + `,` + s + `],"num":` + s + `,"nums":{"a":[` + s + `]}}` + "\n"
+				if got := buf.String(); !strings.HasSuffix(got, want) {
+					t.Errorf("JSON %v: got %q, want suffix %q", isJSON, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxFallbackDepth bounds how deep fallbackString descends into a value, which
+// ends cycles through maps, slices and interfaces.
+const maxFallbackDepth = 8
+
+// maxGroupDepth bounds the nesting of groups in attrs, which ends cycles of
+// LogValuers returning groups that contain themselves. Deeper groups are written
+// as "...".
+const maxGroupDepth = 32
+
+// unsupportedType reports whether values of t can never be encoded as JSON.
+func unsupportedType(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// fallbackString formats v, which JSON can't represent, like fmt's %+v prefixed
+// with its type. As with fmt, only a pointer at the top is followed, and nested
+// ones are written as addresses. Values nested deeper than maxFallbackDepth are
+// written as "...". Methods such as String aren't called.
+func fallbackString(v any) string {
+	var b strings.Builder
+	rv := reflect.ValueOf(v)
+	b.WriteString(rv.Type().String())
+	b.WriteByte(' ')
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		b.WriteByte('&')
+		rv = rv.Elem()
+	}
+	writeFallback(&b, rv, 0)
+	return b.String()
+}
+
+func writeFallback(b *strings.Builder, v reflect.Value, depth int) {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if depth >= maxFallbackDepth {
+			b.WriteString("...")
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		b.WriteString("<nil>")
+	case reflect.Pointer, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			b.WriteString("<nil>")
+		} else {
+			fmt.Fprintf(b, "%#x", v.Pointer())
+		}
+	case reflect.Interface:
+		writeFallback(b, v.Elem(), depth)
+	case reflect.Struct:
+		b.WriteByte('{')
+		for i := range v.NumField() {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(v.Type().Field(i).Name)
+			b.WriteByte(':')
+			writeFallback(b, v.Field(i), depth+1)
+		}
+		b.WriteByte('}')
+	case reflect.Map:
+		b.WriteString("map[")
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			var kb strings.Builder
+			writeFallback(&kb, k, depth+1)
+			names[i] = kb.String()
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortFunc(order, func(i, j int) int { return strings.Compare(names[i], names[j]) })
+		for n, i := range order {
+			if n > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(names[i])
+			b.WriteByte(':')
+			writeFallback(b, v.MapIndex(keys[i]), depth+1)
+		}
+		b.WriteByte(']')
+	case reflect.Slice, reflect.Array:
+		b.WriteByte('[')
+		for i := range v.Len() {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeFallback(b, v.Index(i), depth+1)
+		}
+		b.WriteByte(']')
+	default:
+		fmt.Fprint(b, v)
+	}
+}
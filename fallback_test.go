@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// selfValuer returns itself from LogValue.
+type selfValuer struct{}
+
+func (v selfValuer) LogValue() slog.Value { return slog.AnyValue(v) }
+
+// groupValuer returns a group that contains itself, with key as its key.
+type groupValuer struct{ key string }
+
+func (v groupValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("n", 1), slog.Any(v.key, v))
+}
+
+// node is a struct with a cycle through a pointer.
+type node struct {
+	Name string
+	Next *node
+	Fn   func()
+}
+
+func TestFallbackValues(t *testing.T) {
+	cyclicMap := map[string]any{"a": 1}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []any{1}
+	cyclicSlice[0] = cyclicSlice
+	cyclicNode := &node{Name: "n", Fn: func() {}}
+	cyclicNode.Next = cyclicNode
+
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"func", func(int) error { return nil }, `"v":"func(int) error 0x`},
+		{"struct with func", node{Name: "n", Fn: func() {}}, `"v":"logger.node {Name:n Next:<nil> Fn:0x`},
+		{"cyclic map", cyclicMap, `"v":"map[string]interface {} map[a:1 self:map[a:1 self:map[`},
+		{"cyclic slice", cyclicSlice, `"v":"[]interface {} [[[[[[[[...]]]]]]]]"`},
+		{"cyclic pointer", cyclicNode, `"v":"*logger.node &{Name:n Next:0x`},
+		{"self LogValuer", selfValuer{}, `"v":"LogValue called too many times`},
+		{"cyclic LogValuer group", groupValuer{key: "self"}, `"v":{"n":1,"self":{"n":1,"self":{`},
+		{"cyclic inline LogValuer group", groupValuer{}, `"v":{"n":1,"n":1,`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true})).Info("msg", "v", tc.value, "after", 1)
+
+			if !json.Valid(buf.Bytes()) || !strings.Contains(buf.String(), tc.want) || !strings.HasSuffix(buf.String(), `"after":1}`+"\n") {
+				t.Errorf("got %s, want %s", buf.Bytes(), tc.want)
+			}
+			if buf.Len() > 2<<10 {
+				t.Errorf("got %d bytes", buf.Len())
+			}
+		})
+	}
+}
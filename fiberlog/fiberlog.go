@@ -0,0 +1,134 @@
+// Package fiberlog adapts the go-log HTTP middleware to the Fiber framework.
+package fiberlog
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Fiber returns a Fiber middleware logging requests with the same fields and
+// options as middleware.NewLoggerMiddleware. The completion record also carries
+// the matched route and the client IP as resolved by Fiber's proxy settings.
+// Request IDs set by Fiber's requestid middleware are reused.
+//
+// The request-scoped logger is available through logger.FromContext(c.UserContext()).
+func Fiber(log *slog.Logger, opts ...middleware.Option) fiber.Handler {
+	l := middleware.NewRequestLogger(log, opts...)
+
+	return func(c *fiber.Ctx) (err error) {
+		r, err := convertRequest(c)
+		if err != nil {
+			return c.Next()
+		}
+
+		header := http.Header{}
+		req := l.Begin(responseHeader(header), r,
+			slog.String("client_ip", strings.Clone(c.IP())),
+		)
+		for k, vs := range header {
+			for _, v := range vs {
+				c.Set(k, v)
+			}
+		}
+		c.SetUserContext(req.Request.Context())
+
+		ended := false
+		defer func() {
+			if ended {
+				return
+			}
+			// A panic is unwinding through here. Log the request either way, so that
+			// the in-flight registry stays accurate, but only recover it with
+			// WithRecover.
+			var rec any
+			if l.Recovers() {
+				rec = recover()
+			}
+			status := c.Response().StatusCode()
+			if !written(c) {
+				status = fiber.StatusInternalServerError
+			}
+			var attrs []slog.Attr
+			if rec != nil {
+				attrs = append(attrs, slog.Any("panic", rec))
+				if req.Recovered(rec) && !written(c) {
+					// The error handler writes the 500.
+					err = fiber.ErrInternalServerError
+				}
+			}
+			req.End(status, len(c.Response().Body()), attrs...)
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+		}()
+
+		err = c.Next()
+		ended = true
+
+		// The route is only known once the router has matched it further down the chain.
+		status := c.Response().StatusCode()
+		attrs := []slog.Attr{slog.String("route", strings.Clone(c.Route().Path))}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			// The error handler writes the response only after the middleware chain
+			// has returned, so derive the status it is going to write.
+			status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			}
+		}
+		req.End(status, len(c.Response().Body()), attrs...)
+		return err
+	}
+}
+
+// written reports whether the handler set the status or body of the response.
+// fasthttp buffers the response until the handler returns, so it can still be
+// replaced either way.
+func written(c *fiber.Ctx) bool {
+	resp := c.Response()
+	return resp.StatusCode() != fiber.StatusOK || len(resp.Body()) > 0 || resp.IsBodyStream()
+}
+
+// convertRequest builds an *http.Request from the Fiber request. fasthttp reuses
+// its buffers once the handler returns, so every value is copied.
+func convertRequest(c *fiber.Ctx) (*http.Request, error) {
+	fr := c.Request()
+
+	r, err := http.NewRequestWithContext(c.UserContext(), strings.Clone(c.Method()), string(fr.URI().FullURI()), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.RequestURI = string(fr.RequestURI())
+	r.RemoteAddr = c.Context().RemoteAddr().String()
+	r.ContentLength = int64(fr.Header.ContentLength())
+	if r.ContentLength < 0 {
+		r.ContentLength = -1
+	}
+	r.Proto = string(fr.Header.Protocol())
+	r.ProtoMajor, r.ProtoMinor, _ = http.ParseHTTPVersion(r.Proto)
+	r.TLS = c.Context().TLSConnectionState()
+
+	fr.Header.VisitAll(func(k, v []byte) {
+		r.Header.Add(string(k), string(v))
+	})
+
+	if id := c.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+		r = r.WithContext(logger.ContextWithRequestID(r.Context(), strings.Clone(id)))
+	}
+	return r, nil
+}
+
+// responseHeader collects the headers set by the request logger.
+type responseHeader http.Header
+
+func (h responseHeader) Header() http.Header         { return http.Header(h) }
+func (h responseHeader) Write(b []byte) (int, error) { return len(b), nil }
+func (h responseHeader) WriteHeader(int)             {}
@@ -0,0 +1,117 @@
+package fiberlog
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/middleware"
+	"github.com/corray333/go-log/testutil"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newApp(capture *testutil.CaptureHandler, opts ...middleware.Option) *fiber.App {
+	app := fiber.New()
+	app.Use(Fiber(slog.New(capture), opts...))
+	app.Get("/users/:id", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+	app.Get("/panic", func(c *fiber.Ctx) error { panic("boom") })
+	app.Get("/written", func(c *fiber.Ctx) error {
+		c.Status(fiber.StatusAccepted).SendString("partial")
+		panic("boom")
+	})
+	return app
+}
+
+func completed(t *testing.T, capture *testutil.CaptureHandler) testutil.CapturedRecord {
+	t.Helper()
+	recs := capture.Find("request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d completion records, want 1", len(recs))
+	}
+	return recs[0]
+}
+
+func TestFiber(t *testing.T) {
+	for _, tc := range []struct {
+		path, route string
+		status      int64
+	}{
+		{"/users/42", "/users/:id", 200},
+		{"/missing", "/missing", 404},
+	} {
+		capture := testutil.NewCaptureHandler()
+		resp, err := newApp(capture).Test(httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(resp.StatusCode) != tc.status {
+			t.Errorf("%s: response status %d", tc.path, resp.StatusCode)
+		}
+		rec := completed(t, capture)
+		for key, want := range map[string]any{"status": tc.status, "route": tc.route, "path": tc.path} {
+			if v, ok := rec.Attr(key); !ok || v.Any() != want {
+				t.Errorf("%s: %s = %v, want %v", tc.path, key, v, want)
+			}
+		}
+	}
+}
+
+func TestFiberPanicRecovered(t *testing.T) {
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/panic", http.StatusInternalServerError, "Internal Server Error"},
+		{"/written", http.StatusAccepted, "partial"},
+	} {
+		capture := testutil.NewCaptureHandler()
+		resp, err := newApp(capture, middleware.WithRecover()).Test(httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.status || string(body) != tc.body {
+			t.Errorf("%s: response %d %q, want %d %q", tc.path, resp.StatusCode, body, tc.status, tc.body)
+		}
+		if v, _ := completed(t, capture).Attr("status"); v.Int64() != int64(tc.status) {
+			t.Errorf("%s: logged status %v", tc.path, v)
+		}
+		if len(capture.Find("panic recovered")) != 1 {
+			t.Errorf("%s: panic not logged", tc.path)
+		}
+	}
+}
+
+func TestFiberPanicNotRecovered(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	// An outer recover middleware handles the panic passing through.
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fiber.ErrServiceUnavailable
+			}
+		}()
+		return c.Next()
+	})
+	app.Use(Fiber(slog.New(capture)))
+	app.Get("/panic", func(c *fiber.Ctx) error { panic("boom") })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("response status %d, want the outer recover's 503", resp.StatusCode)
+	}
+	if v, _ := completed(t, capture).Attr("status"); v.Int64() != 500 {
+		t.Errorf("logged status %v", v)
+	}
+	if len(capture.Find("panic recovered")) != 0 {
+		t.Error("panic logged without WithRecover")
+	}
+}
@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyncPolicy selects when a FileWriter syncs the file to disk.
+type SyncPolicy struct {
+	everyRecord bool
+	interval    time.Duration
+}
+
+var (
+	// SyncNone leaves syncing to the operating system.
+	SyncNone = SyncPolicy{}
+	// SyncEveryRecord syncs after every record.
+	SyncEveryRecord = SyncPolicy{everyRecord: true}
+)
+
+// SyncInterval syncs every d in the background.
+func SyncInterval(d time.Duration) SyncPolicy {
+	return SyncPolicy{interval: d}
+}
+
+// FileWriterOptions configures a FileWriter.
+type FileWriterOptions struct {
+	// Sync selects when the file is synced. It defaults to SyncNone.
+	Sync SyncPolicy
+	// SyncOnError syncs after records at Error level and above regardless of
+	// Sync, when the writer is used by a handler from NewHandler.
+	SyncOnError bool
+	// Prune removes old rotated backups of the file from its directory when it
+	// is opened and every PruneInterval. Its Active is set to the file. Errors
+	// are written to stderr.
+	Prune *PrunePolicy
+	// PruneInterval defaults to an hour.
+	PruneInterval time.Duration
+	// DiskGuard degrades logging when the disk of the file runs out of space.
+	DiskGuard *DiskGuard
+}
+
+// file is the part of *os.File used by FileWriter.
+type file interface {
+	io.Writer
+	Sync() error
+	Close() error
+}
+
+// FileWriter appends records to a file, syncing it to disk as configured. It
+// implements Flusher by syncing the file, so it can be passed to RegisterFlusher.
+type FileWriter struct {
+	m    sync.Mutex
+	f    file
+	path string
+	// disk is the diskState, and sampled counts the records sampled with it.
+	disk     atomic.Int32
+	sampled  atomic.Uint64
+	opts     FileWriterOptions
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileWriter opens the file at path for appending, creating it if needed.
+func NewFileWriter(path string, opts *FileWriterOptions) (*FileWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return newFileWriter(f, path, opts), nil
+}
+
+func newFileWriter(f file, path string, opts *FileWriterOptions) *FileWriter {
+	w := &FileWriter{f: f, path: path}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Prune != nil {
+		policy := *w.opts.Prune
+		policy.Active = path
+		w.opts.Prune = &policy
+		if w.opts.PruneInterval <= 0 {
+			w.opts.PruneInterval = time.Hour
+		}
+		w.prune()
+	}
+	if w.opts.DiskGuard != nil {
+		g := *w.opts.DiskGuard
+		if g.SampleEvery <= 0 {
+			g.SampleEvery = 10
+		}
+		if g.Interval <= 0 {
+			g.Interval = 10 * time.Second
+		}
+		if g.Statfs == nil {
+			g.Statfs = statfs
+		}
+		w.opts.DiskGuard = &g
+		w.checkDisk()
+	}
+	if w.opts.Sync.interval > 0 || w.opts.Prune != nil || w.opts.DiskGuard != nil {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.background()
+	}
+	return w
+}
+
+// background syncs, prunes and checks the disk as configured until the writer
+// is closed.
+func (w *FileWriter) background() {
+	defer close(w.done)
+	var syncC, pruneC, diskC <-chan time.Time
+	if d := w.opts.Sync.interval; d > 0 {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		syncC = ticker.C
+	}
+	if w.opts.Prune != nil {
+		ticker := time.NewTicker(w.opts.PruneInterval)
+		defer ticker.Stop()
+		pruneC = ticker.C
+	}
+	if g := w.opts.DiskGuard; g != nil {
+		ticker := time.NewTicker(g.Interval)
+		defer ticker.Stop()
+		diskC = ticker.C
+	}
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-syncC:
+			_ = w.Sync()
+		case <-pruneC:
+			w.prune()
+		case <-diskC:
+			w.checkDisk()
+		}
+	}
+}
+
+func (w *FileWriter) prune() {
+	if err := Prune(filepath.Dir(w.path), *w.opts.Prune); err != nil {
+		// The logger may be writing to this file, so don't log through it.
+		fmt.Fprintf(os.Stderr, "failed to prune logs: %v\n", err)
+	}
+}
+
+// Write appends p to the file.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	return w.writeLevel(slog.LevelInfo, p)
+}
+
+// writeLevel appends p, a record at level, to the file.
+func (w *FileWriter) writeLevel(level slog.Level, p []byte) (int, error) {
+	if w.opts.DiskGuard != nil && w.dropped(level) {
+		return len(p), nil
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	n, err := w.f.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.opts.Sync.everyRecord || (w.opts.SyncOnError && level >= slog.LevelError) {
+		err = w.f.Sync()
+	}
+	return n, err
+}
+
+// Sync commits the written records to disk.
+func (w *FileWriter) Sync() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.f.Sync()
+}
+
+// Flush syncs the file.
+func (w *FileWriter) Flush(context.Context) error {
+	return w.Sync()
+}
+
+// Close stops background syncing, syncs the file and closes it.
+func (w *FileWriter) Close() error {
+	if w.stop != nil {
+		w.stopOnce.Do(func() { close(w.stop) })
+		<-w.done
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFile counts the calls made to it and fails them with its errors.
+type fakeFile struct {
+	mu                          sync.Mutex
+	writes, syncs, closes       int
+	written                     strings.Builder
+	writeErr, syncErr, closeErr error
+}
+
+func (f *fakeFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes++
+	if f.writeErr != nil {
+		return 0, f.writeErr
+	}
+	return f.written.Write(p)
+}
+
+func (f *fakeFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.syncs++
+	return f.syncErr
+}
+
+func (f *fakeFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closes++
+	return f.closeErr
+}
+
+func (f *fakeFile) counts() (writes, syncs, closes int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes, f.syncs, f.closes
+}
+
+func TestFileWriterSync(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		opts  FileWriterOptions
+		syncs int
+	}{
+		{"SyncNone", FileWriterOptions{}, 0},
+		{"SyncEveryRecord", FileWriterOptions{Sync: SyncEveryRecord}, 4},
+		{"SyncOnError", FileWriterOptions{SyncOnError: true}, 1},
+		{"SyncEveryRecord and SyncOnError", FileWriterOptions{Sync: SyncEveryRecord, SyncOnError: true}, 4},
+		{"SyncInterval", FileWriterOptions{Sync: SyncInterval(time.Hour)}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &fakeFile{}
+			w := newFileWriter(f, "app.log", &tc.opts)
+			l := slog.New(NewHandler(&HandlerOptions{Writer: w}))
+			l.Info("info")
+			l.Warn("warn")
+			l.Error("error")
+			// Writes not made by a handler are at Info level.
+			io.WriteString(w, "direct\n")
+
+			if writes, syncs, _ := f.counts(); writes != 4 || syncs != tc.syncs {
+				t.Errorf("%d writes and %d syncs, want 4 writes and %d syncs", writes, syncs, tc.syncs)
+			}
+			if err := w.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, syncs, closes := f.counts(); syncs != tc.syncs+2 || closes != 1 {
+				t.Errorf("after Flush and Close: %d syncs and %d closes, want %d and 1", syncs, closes, tc.syncs+2)
+			}
+		})
+	}
+}
+
+func TestFileWriterSyncInterval(t *testing.T) {
+	f := &fakeFile{}
+	w := newFileWriter(f, "app.log", &FileWriterOptions{Sync: SyncInterval(time.Millisecond)})
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, syncs, _ := f.counts(); syncs >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the file wasn't synced in the background")
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Background syncing stops with Close.
+	_, synced, _ := f.counts()
+	time.Sleep(10 * time.Millisecond)
+	if _, syncs, _ := f.counts(); syncs != synced {
+		t.Errorf("%d syncs after Close, want %d", syncs, synced)
+	}
+}
+
+func TestFileWriterErrors(t *testing.T) {
+	errWrite, errSync, errClose := errors.New("write failed"), errors.New("sync failed"), errors.New("close failed")
+
+	// A failed write isn't synced.
+	f := &fakeFile{writeErr: errWrite}
+	w := newFileWriter(f, "app.log", &FileWriterOptions{Sync: SyncEveryRecord})
+	if _, err := w.Write([]byte("record\n")); !errors.Is(err, errWrite) {
+		t.Errorf("Write: got %v, want %v", err, errWrite)
+	}
+	if _, syncs, _ := f.counts(); syncs != 0 {
+		t.Errorf("%d syncs after a failed write", syncs)
+	}
+
+	// Failed syncs are returned by Write, Sync and Flush, and Close still closes
+	// the file.
+	f = &fakeFile{syncErr: errSync}
+	w = newFileWriter(f, "app.log", &FileWriterOptions{SyncOnError: true})
+	h := NewHandler(&HandlerOptions{Writer: w})
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelError} {
+		err := h.Handle(context.Background(), slog.NewRecord(time.Now(), level, "record", 0))
+		if want := level == slog.LevelError; errors.Is(err, errSync) != want {
+			t.Errorf("Handle at %v: got %v", level, err)
+		}
+	}
+	if err := w.Sync(); !errors.Is(err, errSync) {
+		t.Errorf("Sync: got %v, want %v", err, errSync)
+	}
+	if err := w.Flush(context.Background()); !errors.Is(err, errSync) {
+		t.Errorf("Flush: got %v, want %v", err, errSync)
+	}
+	if err := w.Close(); !errors.Is(err, errSync) {
+		t.Errorf("Close: got %v, want %v", err, errSync)
+	}
+	if _, _, closes := f.counts(); closes != 1 {
+		t.Errorf("%d closes after a failed sync, want 1", closes)
+	}
+
+	f = &fakeFile{closeErr: errClose}
+	if err := newFileWriter(f, "app.log", nil).Close(); !errors.Is(err, errClose) {
+		t.Errorf("Close: got %v, want %v", err, errClose)
+	}
+}
+
+func TestFileWriterPruneInterval(t *testing.T) {
+	dir := pruneDir(t, map[string]time.Duration{"app.log.1": time.Hour, "app.log.2": 2 * time.Hour}, 1)
+	w := newFileWriter(&fakeFile{}, filepath.Join(dir, "app.log"), &FileWriterOptions{
+		Prune:         &PrunePolicy{MaxFiles: 1},
+		PruneInterval: time.Millisecond,
+	})
+	defer w.Close()
+
+	// Backups are pruned when the file is opened, then every PruneInterval.
+	if got, want := remaining(t, dir), []string{"app.log.0", "app.log.1"}; !slices.Equal(got, want) {
+		t.Errorf("remaining files = %v, want %v", got, want)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.log.3"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-3 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "app.log.3"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if got := remaining(t, dir); slices.Equal(got, []string{"app.log.0", "app.log.1"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("remaining files = %v, want the new backup pruned", remaining(t, dir))
+		}
+	}
+}
+
+func TestFileWriterDiskGuard(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{Writer: io.Discard})))
+
+	avail := uint64(5)
+	f := &fakeFile{}
+	w := newFileWriter(f, "app.log", &FileWriterOptions{
+		Sync: SyncEveryRecord,
+		DiskGuard: &DiskGuard{
+			HardBytes: 10,
+			Interval:  time.Hour,
+			Statfs:    func(string) (uint64, uint64, error) { return avail, 1000, nil },
+		},
+	})
+	defer w.Close()
+	l := slog.New(NewHandler(&HandlerOptions{Writer: w}))
+
+	// Records dropped on a full disk reach neither the file nor Sync.
+	l.Info("dropped")
+	l.Error("kept")
+	if writes, syncs, _ := f.counts(); writes != 1 || syncs != 1 || !strings.Contains(f.written.String(), "kept") {
+		t.Errorf("%d writes and %d syncs of %q, want the Error record only", writes, syncs, f.written.String())
+	}
+
+	avail = 500
+	w.checkDisk()
+	l.Info("recovered")
+	if writes, syncs, _ := f.counts(); writes != 2 || syncs != 2 {
+		t.Errorf("%d writes and %d syncs after recovery, want 2 each", writes, syncs)
+	}
+}
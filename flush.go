@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Flusher is implemented by writers and handlers that buffer records, so that
+// they can be written out before the process exits.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+var flushers struct {
+	sync.Mutex
+	list []Flusher
+}
+
+// RegisterFlusher adds f to the flushers called by Flush.
+func RegisterFlusher(f Flusher) {
+	flushers.Lock()
+	defer flushers.Unlock()
+	flushers.list = append(flushers.list, f)
+}
+
+// Flush flushes all registered flushers in the order they were registered and
+// returns their errors joined. Flushers should give up when ctx is done.
+func Flush(ctx context.Context) error {
+	flushers.Lock()
+	list := flushers.list
+	flushers.Unlock()
+
+	var errs []error
+	for _, f := range list {
+		if err := f.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RegisterShutdown flushes the registered flushers when the process receives
+// SIGINT or SIGTERM, waiting at most timeout, and then raises the signal again
+// so that the process exits as it would have. It stops watching for the signals
+// when ctx is done. Applications shutting down gracefully on the signals should
+// use FlushOnSignal instead.
+func RegisterShutdown(ctx context.Context, timeout time.Duration) {
+	watchSignals(ctx, timeout, true)
+}
+
+// FlushOnSignal is like RegisterShutdown but doesn't raise the signal again,
+// so that the application can shut down gracefully, logging as it does. As
+// the process no longer exits on the signals, the application must watch for
+// them itself, like with signal.NotifyContext.
+func FlushOnSignal(ctx context.Context, timeout time.Duration) {
+	watchSignals(ctx, timeout, false)
+}
+
+func watchSignals(ctx context.Context, timeout time.Duration, reraise bool) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-ctx.Done():
+		case sig := <-signals:
+			flushWithTimeout(timeout)
+			if !reraise {
+				return
+			}
+			signal.Reset(sig)
+			if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+				return
+			}
+			os.Exit(1)
+		}
+	}()
+}
+
+// fatalFlushTimeout bounds how long Fatal waits for the flushers.
+const fatalFlushTimeout = 5 * time.Second
+
+// Fatal logs msg with args at Error level on the default logger, flushes the
+// registered flushers and exits with status 1.
+func Fatal(msg string, args ...any) {
+	logDefault(context.Background(), slog.LevelError, msg, args)
+	flushWithTimeout(fatalFlushTimeout)
+	os.Exit(1)
+}
+
+func flushWithTimeout(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := Flush(ctx); err != nil {
+		// The logger may be what failed, so don't log through it.
+		fmt.Fprintf(os.Stderr, "failed to flush logs: %v\n", err)
+	}
+}
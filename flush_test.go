@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type flusherFunc func(ctx context.Context) error
+
+func (f flusherFunc) Flush(ctx context.Context) error { return f(ctx) }
+
+func TestFlush(t *testing.T) {
+	var calls []int
+	errFlush := errors.New("flush failed")
+	for i := range 3 {
+		RegisterFlusher(flusherFunc(func(context.Context) error {
+			calls = append(calls, i)
+			if i == 1 {
+				return errFlush
+			}
+			return nil
+		}))
+	}
+	t.Cleanup(func() {
+		flushers.Lock()
+		flushers.list = nil
+		flushers.Unlock()
+	})
+
+	if err := Flush(context.Background()); !errors.Is(err, errFlush) {
+		t.Errorf("Flush() = %v, want %v", err, errFlush)
+	}
+	if fmt.Sprint(calls) != "[0 1 2]" {
+		t.Errorf("flushers called in order %v, want [0 1 2]", calls)
+	}
+}
+
+// TestRegisterShutdown runs the test binary as an application that doesn't
+// handle SIGTERM and checks that it flushed the logs and was then killed by the
+// signal.
+func TestRegisterShutdown(t *testing.T) {
+	if os.Getenv("GOLOG_SHUTDOWN_CHILD") == "1" {
+		shutdownChild()
+		return
+	}
+
+	out, err := runChild("TestRegisterShutdown", "GOLOG_SHUTDOWN_CHILD")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("child wasn't killed: %v\n%s", err, out)
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+		t.Errorf("child exited with %v, want to be killed by SIGTERM", err)
+	}
+	if string(out) != "flushed\n" {
+		t.Errorf("child output:\n%s\nwant the logs flushed before the signal took effect", out)
+	}
+}
+
+func shutdownChild() {
+	RegisterFlusher(flusherFunc(func(context.Context) error {
+		fmt.Println("flushed")
+		return nil
+	}))
+	RegisterShutdown(context.Background(), time.Second)
+
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	time.Sleep(5 * time.Second)
+	fmt.Println("not killed")
+	os.Exit(0)
+}
+
+// TestFlushOnSignal runs the test binary as an application shutting down
+// gracefully on SIGTERM and checks that it flushed the logs and wasn't killed
+// by the signal.
+func TestFlushOnSignal(t *testing.T) {
+	if os.Getenv("GOLOG_GRACEFUL_CHILD") == "1" {
+		gracefulChild()
+		return
+	}
+
+	out, err := runChild("TestFlushOnSignal", "GOLOG_GRACEFUL_CHILD")
+	if err != nil {
+		t.Fatalf("child failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "flushed\nshut down\n") {
+		t.Errorf("child output:\n%s\nwant the logs flushed before the application shut down", out)
+	}
+}
+
+func gracefulChild() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	flushed := make(chan struct{})
+	RegisterFlusher(flusherFunc(func(context.Context) error {
+		fmt.Println("flushed")
+		close(flushed)
+		return nil
+	}))
+	FlushOnSignal(context.Background(), time.Second)
+
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	<-ctx.Done()
+	select {
+	case <-flushed:
+	case <-time.After(5 * time.Second):
+		fmt.Println("not flushed")
+		os.Exit(1)
+	}
+	// The graceful shutdown takes a while, and must not be cut short.
+	time.Sleep(100 * time.Millisecond)
+	fmt.Println("shut down")
+	os.Exit(0)
+}
+
+// runChild runs the test binary with only the test named test, and env set to 1.
+func runChild(test, env string) ([]byte, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^"+test+"$")
+	cmd.Env = append(os.Environ(), env+"=1")
+	return cmd.CombinedOutput()
+}
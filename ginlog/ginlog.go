@@ -0,0 +1,63 @@
+// Package ginlog adapts the go-log HTTP middleware to the Gin framework.
+package ginlog
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/corray333/go-log/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin returns a Gin middleware logging requests with the same fields and
+// options as middleware.NewLoggerMiddleware. The completion record also carries
+// the matched route and client IP, and the errors accumulated in c.Errors.
+func Gin(log *slog.Logger, opts ...middleware.Option) gin.HandlerFunc {
+	l := middleware.NewRequestLogger(log, opts...)
+
+	return func(c *gin.Context) {
+		req := l.Begin(c.Writer, c.Request,
+			slog.String("route", c.FullPath()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+		c.Request = req.Request
+
+		ended := false
+		defer func() {
+			if ended {
+				return
+			}
+			// A panic is unwinding through here. Log the request either way, so that
+			// the in-flight registry stays accurate, but only recover it with
+			// WithRecover.
+			var rec any
+			if l.Recovers() {
+				rec = recover()
+			}
+			status := c.Writer.Status()
+			if !c.Writer.Written() {
+				status = http.StatusInternalServerError
+			}
+			var attrs []slog.Attr
+			if rec != nil {
+				attrs = append(attrs, slog.Any("panic", rec))
+				if req.Recovered(rec) && !c.Writer.Written() {
+					c.AbortWithStatus(http.StatusInternalServerError)
+				}
+			}
+			req.End(status, max(c.Writer.Size(), 0), attrs...)
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+		}()
+
+		c.Next()
+		ended = true
+
+		var attrs []slog.Attr
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+		req.End(c.Writer.Status(), max(c.Writer.Size(), 0), attrs...)
+	}
+}
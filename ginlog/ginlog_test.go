@@ -0,0 +1,105 @@
+package ginlog
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/middleware"
+	"github.com/corray333/go-log/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newRouter(capture *testutil.CaptureHandler, opts ...middleware.Option) *gin.Engine {
+	r := gin.New()
+	r.Use(Gin(slog.New(capture), opts...))
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Error(errors.New("cache miss"))
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/written", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "partial")
+		panic("boom")
+	})
+	r.GET("/abort", func(c *gin.Context) { panic(http.ErrAbortHandler) })
+	return r
+}
+
+// serve serves a GET of path and returns the recorder and the value of a
+// panic escaping the router.
+func serve(r *gin.Engine, path string) (w *httptest.ResponseRecorder, rec any) {
+	w = httptest.NewRecorder()
+	defer func() { rec = recover() }()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w, nil
+}
+
+func completed(t *testing.T, capture *testutil.CaptureHandler) testutil.CapturedRecord {
+	t.Helper()
+	recs := capture.Find("request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d completion records, want 1", len(recs))
+	}
+	return recs[0]
+}
+
+func TestGin(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	w, _ := serve(newRouter(capture), "/users/42")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	rec := completed(t, capture)
+	for key, want := range map[string]any{"status": int64(200), "route": "/users/:id", "path": "/users/42", "size": int64(2)} {
+		if v, ok := rec.Attr(key); !ok || v.Any() != want {
+			t.Errorf("%s = %v, want %v", key, v, want)
+		}
+	}
+	if _, ok := rec.Attr("errors"); !ok {
+		t.Error("no errors attr")
+	}
+}
+
+func TestGinPanic(t *testing.T) {
+	for _, tc := range []struct {
+		name, path string
+		recover    bool
+		wantCode   int
+		wantStatus int64
+		escapes    bool
+	}{
+		{"recovered", "/panic", true, http.StatusInternalServerError, 500, false},
+		{"recovered after write", "/written", true, http.StatusAccepted, 202, false},
+		{"not recovered", "/panic", false, 0, 500, true},
+		{"abort", "/abort", true, 0, 500, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			capture := testutil.NewCaptureHandler()
+			var opts []middleware.Option
+			if tc.recover {
+				opts = append(opts, middleware.WithRecover())
+			}
+			w, escaped := serve(newRouter(capture, opts...), tc.path)
+			if (escaped != nil) != tc.escapes {
+				t.Fatalf("panic escaped: %v", escaped)
+			}
+			if !tc.escapes && w.Code != tc.wantCode {
+				t.Errorf("response status %d, want %d", w.Code, tc.wantCode)
+			}
+			if v, _ := completed(t, capture).Attr("status"); v.Int64() != tc.wantStatus {
+				t.Errorf("logged status %v, want %d", v, tc.wantStatus)
+			}
+			logged := len(capture.Find("panic recovered")) > 0
+			if logged != (tc.recover && !tc.escapes) {
+				t.Errorf("panic recovered logged: %v", logged)
+			}
+		})
+	}
+}
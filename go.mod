@@ -1,5 +1,3 @@
 module github.com/corray333/go-log
 
 go 1.25.2
-
-require github.com/go-chi/chi/v5 v5.2.3
@@ -0,0 +1,198 @@
+// Package grpclog provides gRPC server interceptors logging calls with go-log.
+package grpclog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	logger "github.com/corray333/go-log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor returns an interceptor logging every unary call with its
+// method, peer address, request ID, status code and duration.
+func UnaryServerInterceptor(log *slog.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	log = log.With(slog.String("component", "grpc/logger"))
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if _, ok := o.skipFullMethods[info.FullMethod]; ok {
+			return handler(ctx, req)
+		}
+
+		ctx, entry := o.begin(ctx, log, info.FullMethod)
+		o.logPayload(ctx, entry, "request", req)
+
+		start := time.Now()
+		defer func() {
+			rec := recover()
+			if rec != nil {
+				err = o.panicked(ctx, entry, rec)
+			} else if err == nil {
+				o.logPayload(ctx, entry, "response", resp)
+			}
+			o.end(ctx, entry, start, err, rec != nil)
+			if rec != nil && !o.recover {
+				panic(rec)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor logging every streaming call
+// with its method, peer address, request ID, status code and duration.
+func StreamServerInterceptor(log *slog.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	log = log.With(slog.String("component", "grpc/logger"))
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if _, ok := o.skipFullMethods[info.FullMethod]; ok {
+			return handler(srv, ss)
+		}
+
+		ctx, entry := o.begin(ss.Context(), log, info.FullMethod)
+		ws := &serverStream{ServerStream: ss, ctx: ctx, entry: entry, o: o}
+
+		start := time.Now()
+		defer func() {
+			rec := recover()
+			if rec != nil {
+				err = o.panicked(ctx, entry, rec)
+			}
+			o.end(ctx, entry, start, err, rec != nil,
+				slog.Int("messages_received", ws.received),
+				slog.Int("messages_sent", ws.sent),
+			)
+			if rec != nil && !o.recover {
+				panic(rec)
+			}
+		}()
+		return handler(srv, ws)
+	}
+}
+
+func (o *options) begin(ctx context.Context, log *slog.Logger, fullMethod string) (context.Context, *slog.Logger) {
+	reqID := logger.RequestIDFromContext(ctx)
+	if reqID == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vs := md.Get(o.requestIDKey); len(vs) > 0 {
+				reqID = vs[0]
+			}
+		}
+		if reqID == "" && o.genRequestID != nil {
+			reqID = o.genRequestID()
+		}
+		if reqID != "" {
+			ctx = logger.ContextWithRequestID(ctx, reqID)
+		}
+	}
+
+	attrs := []any{slog.String("method", fullMethod)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if reqID != "" {
+		attrs = append(attrs, slog.String("request_id", reqID))
+	}
+
+	entry := log.With(attrs...)
+	return logger.IntoContext(ctx, entry), entry
+}
+
+// panicked logs the panic rec of a handler with a stack trace and returns the
+// error of the call. Without WithRecover, the interceptors panic again with rec
+// once the call is logged.
+func (o *options) panicked(ctx context.Context, entry *slog.Logger, rec any) error {
+	msg := "panic"
+	if o.recover {
+		msg = "panic recovered"
+	}
+	entry.ErrorContext(ctx, msg,
+		slog.Any("panic", rec),
+		slog.String("stack", logger.PanicStack()),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// end logs the completion of a call. Calls whose handler panicked are logged at
+// Error level at least.
+func (o *options) end(ctx context.Context, entry *slog.Logger, start time.Time, err error, panicked bool, attrs ...slog.Attr) {
+	code := status.Code(err)
+	attrs = append([]slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}, attrs...)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	level := o.codeToLevel(code)
+	if panicked {
+		level = max(level, slog.LevelError)
+	}
+	entry.LogAttrs(ctx, level, "call completed", attrs...)
+}
+
+func (o *options) logPayload(ctx context.Context, entry *slog.Logger, kind string, msg any) {
+	if o.payloadLimit <= 0 || msg == nil || !entry.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return
+	}
+	payload := string(b)
+
+	truncated := len(payload) > o.payloadLimit
+	if truncated {
+		payload = payload[:o.payloadLimit]
+	}
+	entry.DebugContext(ctx, kind+" payload",
+		slog.String("payload", payload),
+		slog.Bool("truncated", truncated),
+	)
+}
+
+// serverStream carries the request-scoped context and logs streamed payloads.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	entry    *slog.Logger
+	o        *options
+	received int
+	sent     int
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+		s.o.logPayload(s.ctx, s.entry, "response", m)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		s.o.logPayload(s.ctx, s.entry, "request", m)
+	}
+	return err
+}
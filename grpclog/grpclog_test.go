@@ -0,0 +1,137 @@
+package grpclog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func completed(t *testing.T, h *testutil.CaptureHandler) testutil.CapturedRecord {
+	t.Helper()
+	recs := h.Find("call completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d completion records, want 1", len(recs))
+	}
+	return recs[0]
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	h := testutil.NewCaptureHandler()
+	intercept := UnaryServerInterceptor(slog.New(h))
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Get"}
+
+	_, err := intercept(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("err = %v, want NotFound", err)
+	}
+	rec := completed(t, h)
+	if rec.Level != slog.LevelInfo {
+		t.Errorf("level = %v, want INFO", rec.Level)
+	}
+	for key, want := range map[string]string{"method": "/svc/Get", "code": "NotFound", "error": "rpc error: code = NotFound desc = missing"} {
+		if v, ok := rec.Attr(key); !ok || v.String() != want {
+			t.Errorf("%s = %v, want %q", key, v, want)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorPanic(t *testing.T) {
+	for _, recov := range []bool{false, true} {
+		h := testutil.NewCaptureHandler()
+		var opts []Option
+		if recov {
+			opts = append(opts, WithRecover())
+		}
+		intercept := UnaryServerInterceptor(slog.New(h), opts...)
+		info := &grpc.UnaryServerInfo{FullMethod: "/svc/Get"}
+
+		var err error
+		rec := func() (rec any) {
+			defer func() { rec = recover() }()
+			_, err = intercept(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+				panic("boom")
+			})
+			return nil
+		}()
+		if recov {
+			if rec != nil || status.Code(err) != codes.Internal {
+				t.Errorf("WithRecover: panic %v, err %v, want Internal status", rec, err)
+			}
+		} else if rec != "boom" {
+			t.Errorf("panic = %v, want it re-raised", rec)
+		}
+		checkPanicLogged(t, h)
+	}
+}
+
+func TestStreamServerInterceptorPanic(t *testing.T) {
+	for _, recov := range []bool{false, true} {
+		h := testutil.NewCaptureHandler()
+		var opts []Option
+		if recov {
+			opts = append(opts, WithRecover())
+		}
+		intercept := StreamServerInterceptor(slog.New(h), opts...)
+		info := &grpc.StreamServerInfo{FullMethod: "/svc/Watch"}
+
+		var err error
+		rec := func() (rec any) {
+			defer func() { rec = recover() }()
+			err = intercept(nil, &fakeStream{ctx: context.Background()}, info, func(srv any, ss grpc.ServerStream) error {
+				panic("boom")
+			})
+			return nil
+		}()
+		if recov {
+			if rec != nil || status.Code(err) != codes.Internal {
+				t.Errorf("WithRecover: panic %v, err %v, want Internal status", rec, err)
+			}
+		} else if rec != "boom" {
+			t.Errorf("panic = %v, want it re-raised", rec)
+		}
+		checkPanicLogged(t, h)
+	}
+}
+
+func checkPanicLogged(t *testing.T, h *testutil.CaptureHandler) {
+	t.Helper()
+	panics := h.Filter(slog.LevelError)
+	if len(panics) != 2 {
+		t.Fatalf("got %d Error records, want the panic and the completion", len(panics))
+	}
+	if v, ok := panics[0].Attr("stack"); !ok || v.String() == "" {
+		t.Error("panic logged without a stack")
+	}
+	rec := completed(t, h)
+	if v, _ := rec.Attr("code"); v.String() != "Internal" || rec.Level != slog.LevelError {
+		t.Errorf("completion logged with code %v at %v, want Internal at ERROR", v, rec.Level)
+	}
+}
+
+func TestDefaultCodeToLevel(t *testing.T) {
+	for code, want := range map[codes.Code]slog.Level{
+		codes.OK:               slog.LevelInfo,
+		codes.NotFound:         slog.LevelInfo,
+		codes.DeadlineExceeded: slog.LevelWarn,
+		codes.Internal:         slog.LevelError,
+		codes.Unavailable:      slog.LevelError,
+	} {
+		if got := DefaultCodeToLevel(code); got != want {
+			t.Errorf("DefaultCodeToLevel(%v) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package grpclog
+
+import (
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Option configures the interceptors.
+type Option func(*options)
+
+type options struct {
+	recover         bool
+	payloadLimit    int
+	requestIDKey    string
+	codeToLevel     func(codes.Code) slog.Level
+	genRequestID    func() string
+	skipFullMethods map[string]struct{}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		requestIDKey: "x-request-id",
+		codeToLevel:  DefaultCodeToLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRecover makes the interceptors recover panics from handlers and return an
+// Internal status instead. Panics are logged at Error level with a stack trace
+// either way, and the call as completed with the Internal code; without
+// WithRecover, the panic then continues.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recover = true
+	}
+}
+
+// WithPayloads logs request and response messages at Debug level, truncated to
+// limit bytes each. Payloads aren't logged by default.
+func WithPayloads(limit int) Option {
+	return func(o *options) {
+		o.payloadLimit = limit
+	}
+}
+
+// WithRequestIDMetadata sets the incoming metadata key the request ID is read
+// from. It defaults to "x-request-id".
+func WithRequestIDMetadata(key string) Option {
+	return func(o *options) {
+		o.requestIDKey = key
+	}
+}
+
+// WithRequestIDGenerator generates request IDs with gen for calls whose metadata
+// doesn't carry one. By default such calls are logged without a request ID.
+func WithRequestIDGenerator(gen func() string) Option {
+	return func(o *options) {
+		o.genRequestID = gen
+	}
+}
+
+// WithCodeToLevel replaces the mapping from status codes to completion record levels.
+func WithCodeToLevel(fn func(codes.Code) slog.Level) Option {
+	return func(o *options) {
+		o.codeToLevel = fn
+	}
+}
+
+// WithSkipMethods disables logging for the given full method names,
+// such as "/grpc.health.v1.Health/Check".
+func WithSkipMethods(fullMethods ...string) Option {
+	return func(o *options) {
+		if o.skipFullMethods == nil {
+			o.skipFullMethods = make(map[string]struct{}, len(fullMethods))
+		}
+		for _, m := range fullMethods {
+			o.skipFullMethods[m] = struct{}{}
+		}
+	}
+}
+
+// DefaultCodeToLevel logs client-caused codes at Info, codes worth attention at
+// Warn and server failures at Error.
+func DefaultCodeToLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return slog.LevelInfo
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// lineRE splits a rendered record into its time, level, message and attrs.
+var lineRE = regexp.MustCompile(`^(?:\[([^\]]*)\] )?([A-Z]+(?:[+-]\d+)?): (.*?) (\{.*\})$`)
+
+// parseLines parses the records rendered by a plain handler into maps as
+// slogtest expects them.
+func parseLines(t *testing.T, out []byte) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		m := lineRE.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("malformed line %q", line)
+		}
+		record := make(map[string]any)
+		if err := json.Unmarshal([]byte(m[4]), &record); err != nil {
+			t.Fatalf("attrs of line %q: %v", line, err)
+		}
+		if m[1] != "" {
+			record[slog.TimeKey] = m[1]
+		}
+		record[slog.LevelKey] = m[2]
+		record[slog.MessageKey] = m[3]
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerOptions{Writer: &buf})
+	if err := slogtest.TestHandler(h, func() []map[string]any { return parseLines(t, buf.Bytes()) }); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogtestRun(t *testing.T) {
+	var buf bytes.Buffer
+	slogtest.Run(t, func(*testing.T) slog.Handler {
+		buf.Reset()
+		return NewHandler(&HandlerOptions{Writer: &buf})
+	}, func(t *testing.T) map[string]any {
+		records := parseLines(t, buf.Bytes())
+		if len(records) != 1 {
+			t.Fatalf("got %d records, want 1", len(records))
+		}
+		return records[0]
+	})
+}
+
+func TestDerivedLoggersConcurrently(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerOptions{
+		Writer:     &buf,
+		Sequence:   true,
+		SortKeys:   true,
+		MaxAttrs:   8,
+		SecretScan: &SecretScan{},
+		HandlerOptions: &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+	})
+	root := slog.New(h).With("service", "api")
+
+	const goroutines, perGoroutine = 32, 200
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := root
+			for i := range perGoroutine {
+				switch i % 4 {
+				case 0:
+					l = root.With("g", g)
+				case 1:
+					l = l.WithGroup(fmt.Sprintf("level%d", i%3))
+				case 2:
+					l = l.With("i", i)
+				}
+				l.Debug("record", "n", i, slog.Group("nested", "ok", true))
+			}
+		}()
+	}
+	wg.Wait()
+
+	records := parseLines(t, buf.Bytes())
+	if len(records) != goroutines*perGoroutine {
+		t.Fatalf("got %d records, want %d", len(records), goroutines*perGoroutine)
+	}
+	seen := make(map[float64]bool)
+	for _, r := range records {
+		seq, _ := r["seq"].(float64)
+		if seen[seq] {
+			t.Fatalf("duplicate seq %v", seq)
+		}
+		seen[seq] = true
+	}
+}
+
+// goldenTime is the time of every record in golden files.
+var goldenTime = time.Date(2024, 1, 15, 10, 30, 45, 123e6, time.UTC)
+
+// callerRE matches the caller of error records, which depends on the checkout.
+var callerRE = regexp.MustCompile(`"file":"[^"]*","line":\d+`)
+
+func logGoldenRecords(l *slog.Logger) {
+	l.Debug("cache warmed", "entries", 1024)
+	l.Info("user logged in", "user_id", 42, slog.Group("session", "id", "s-1", "ttl", 30*time.Minute))
+	l.With("request_id", "r-7").WithGroup("http").Warn("slow request", "status", 200, "duration", 1500*time.Millisecond)
+	l.Error("query failed", "err", fmt.Errorf("connection refused"))
+	l.Info("message with\nnewline and \x1b[31mcolor", "bytes", []byte("raw"))
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+	}{
+		{"plain", HandlerOptions{}},
+		{"colorized", HandlerOptions{Colorize: true, Theme: ThemeDefault}},
+		{"solarized", HandlerOptions{Colorize: true, Theme: ThemeSolarizedDark}},
+		{"monochrome", HandlerOptions{Colorize: true, Theme: ThemeMonochrome}},
+		{"custom", HandlerOptions{Colorize: true, Theme: &Theme{
+			Message: "1",
+			Levels:  map[slog.Level]string{slog.LevelError: "4;31"},
+			AttrKey: "35",
+		}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := tc.opts
+			opts.Writer = &buf
+			opts.HandlerOptions = &slog.HandlerOptions{Level: slog.LevelDebug}
+			opts.Now = func() time.Time { return goldenTime }
+			opts.ForceNow = true
+			logGoldenRecords(slog.New(NewHandler(&opts)))
+			got := callerRE.ReplaceAll(buf.Bytes(), []byte(`"file":"FILE","line":0`))
+			checkGolden(t, filepath.Join("testdata", tc.name+".golden"), got)
+		})
+	}
+}
+
+func TestThemeWithoutColors(t *testing.T) {
+	for _, theme := range []*Theme{ThemeDefault, ThemeSolarizedDark, ThemeMonochrome} {
+		for _, opts := range []HandlerOptions{
+			// A buffer isn't a terminal.
+			{Theme: theme},
+			{Theme: theme, Colorize: true, JSON: true},
+		} {
+			var buf bytes.Buffer
+			opts.Writer = &buf
+			opts.HandlerOptions = &slog.HandlerOptions{Level: slog.LevelDebug}
+			logGoldenRecords(slog.New(NewHandler(&opts)))
+			if bytes.IndexByte(buf.Bytes(), 0x1b) >= 0 {
+				t.Errorf("JSON %v: got escape sequences:\n%q", opts.JSON, buf.Bytes())
+			}
+		}
+	}
+}
+
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the tests with -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
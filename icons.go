@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// Icons adds an icon before the level name of each record, for scanning the
+// output of a terminal by eye.
+type Icons struct {
+	// Levels overrides icons of DefaultIcons. Levels without an icon use the
+	// icon of the closest level below.
+	Levels map[slog.Level]string
+	// Compact writes the icon in place of the level name.
+	Compact bool
+}
+
+// DefaultIcons are the icons used for the levels Icons.Levels leaves out.
+var DefaultIcons = map[slog.Level]string{
+	LevelTrace:      "🔍",
+	slog.LevelDebug: "🐛",
+	slog.LevelInfo:  "ℹ️",
+	LevelNotice:     "📣",
+	slog.LevelWarn:  "⚠️",
+	slog.LevelError: "❌",
+	LevelCritical:   "🔥",
+}
+
+// iconWidth is the number of columns icons are padded to, so that the level
+// names after them line up.
+const iconWidth = 2
+
+type levelIcon struct {
+	level slog.Level
+	icon  string
+}
+
+// iconSet holds the icons of a handler, padded and sorted by level.
+type iconSet struct {
+	icons   []levelIcon
+	compact bool
+}
+
+func newIconSet(icons *Icons) *iconSet {
+	merged := make(map[slog.Level]string, len(DefaultIcons))
+	for level, icon := range DefaultIcons {
+		merged[level] = icon
+	}
+	for level, icon := range icons.Levels {
+		merged[level] = icon
+	}
+
+	s := &iconSet{compact: icons.Compact}
+	for level, icon := range merged {
+		for w := displayWidth(icon); w < iconWidth; w++ {
+			icon += " "
+		}
+		s.icons = append(s.icons, levelIcon{level, icon})
+	}
+	slices.SortFunc(s.icons, func(a, b levelIcon) int { return int(a.level - b.level) })
+	return s
+}
+
+// icon returns the padded icon of level, or blanks when no level at or below
+// it has one.
+func (s *iconSet) icon(level slog.Level) string {
+	for i := len(s.icons) - 1; i >= 0; i-- {
+		if level >= s.icons[i].level {
+			return s.icons[i].icon
+		}
+	}
+	return "  "
+}
+
+// displayWidth returns the number of terminal columns s takes. Emoji and East
+// Asian wide characters take two columns, and a variation selector asking for
+// emoji presentation widens the symbol before it. Runes joined to the one before
+// by a zero width joiner add nothing.
+func displayWidth(s string) int {
+	w, last := 0, 0
+	joined := false
+	for _, r := range s {
+		switch {
+		case r == 0x200d:
+			joined = true
+			continue
+		case r == 0xfe0f:
+			if last == 1 {
+				w++
+				last = 2
+			}
+			continue
+		case r < 0x20, r >= 0x300 && r <= 0x36f, r >= 0xfe00 && r <= 0xfe0e, r == 0x20e3:
+			continue
+		}
+		if joined {
+			joined = false
+			continue
+		}
+		last = 1
+		if isWide(r) {
+			last = 2
+		}
+		w += last
+	}
+	return w
+}
+
+// wideRanges holds the wide characters displayWidth knows of: emoji with emoji
+// presentation by default and East Asian wide characters.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x23e9, 0x23ec}, {0x23f0, 0x23f0},
+	{0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615}, {0x2648, 0x2653},
+	{0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1}, {0x26aa, 0x26ab},
+	{0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce}, {0x26d4, 0x26d4},
+	{0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5}, {0x26fa, 0x26fa},
+	{0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b}, {0x2728, 0x2728},
+	{0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755}, {0x2757, 0x2757},
+	{0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf}, {0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0xa4cf}, {0xac00, 0xd7a3},
+	{0xf900, 0xfaff}, {0xfe30, 0xfe4f}, {0xff00, 0xff60}, {0xffe0, 0xffe6},
+	{0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf}, {0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251}, {0x1f300, 0x1f64f}, {0x1f680, 0x1f6ff}, {0x1f7e0, 0x1f7eb},
+	{0x1f900, 0x1faff}, {0x20000, 0x3fffd},
+}
+
+func isWide(r rune) bool {
+	for _, wr := range wideRanges {
+		if r < wr[0] {
+			return false
+		}
+		if r <= wr[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestIcons(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		// A buffer isn't a terminal.
+		{"not a terminal", HandlerOptions{Icons: &Icons{}}, "WARN: disk almost full"},
+		{"json", HandlerOptions{Icons: &Icons{}, Colorize: true, JSON: true}, `"level":"WARN","msg":"disk almost full"`},
+		{"colorized", HandlerOptions{Icons: &Icons{}, Colorize: true, Theme: &Theme{}}, "⚠️ WARN: disk almost full"},
+		{"compact", HandlerOptions{Icons: &Icons{Compact: true}, Colorize: true, Theme: &Theme{}}, "⚠️ disk almost full"},
+		{"override", HandlerOptions{Icons: &Icons{Levels: map[slog.Level]string{slog.LevelWarn: "!"}}, Colorize: true, Theme: &Theme{}}, "!  WARN: disk almost full"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := tc.opts
+			opts.Writer = &buf
+			slog.New(NewHandler(&opts)).Warn("disk almost full")
+
+			got := buf.String()
+			if !strings.Contains(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if !strings.Contains(tc.want, "⚠️") && strings.Contains(got, "⚠️") {
+				t.Errorf("got icon in %q", got)
+			}
+			if (!opts.Colorize || opts.JSON) && strings.IndexByte(got, 0x1b) >= 0 {
+				t.Errorf("got escape sequences in %q", got)
+			}
+		})
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	for s, want := range map[string]int{
+		"!":   1,
+		"ℹ️":  2,
+		"⚠️":  2,
+		"❌":   2,
+		"🔥":   2,
+		"👩‍💻": 2,
+		"日本":  4,
+		"é":  1,
+	} {
+		if got := displayWidth(s); got != want {
+			t.Errorf("displayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/corray333/go-log/lambdalog
+
+go 1.25.2
+
+require (
+	github.com/aws/aws-lambda-go v1.54.0
+	github.com/corray333/go-log v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/corray333/go-log => ../
@@ -0,0 +1,116 @@
+// Package lambdalog adds AWS Lambda invocation details to records.
+package lambdalog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	logger "github.com/corray333/go-log"
+)
+
+// ContextAttrs returns the aws_request_id of the invocation in ctx together
+// with function_name, function_version and a remaining_time bucket. It can be
+// used as a logger.ContextExtractor.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("aws_request_id", lc.AwsRequestID))
+	}
+	if lambdacontext.FunctionName != "" {
+		attrs = append(attrs, slog.String("function_name", lambdacontext.FunctionName))
+	}
+	if lambdacontext.FunctionVersion != "" {
+		attrs = append(attrs, slog.String("function_version", lambdacontext.FunctionVersion))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		attrs = append(attrs, slog.String("remaining_time", remainingBucket(time.Until(deadline))))
+	}
+	return attrs
+}
+
+// remainingBucket returns a label for the time left before the invocation times out.
+func remainingBucket(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "lt_1s"
+	case d < 5*time.Second:
+		return "lt_5s"
+	case d < 30*time.Second:
+		return "lt_30s"
+	case d < 5*time.Minute:
+		return "lt_5m"
+	default:
+		return "ge_5m"
+	}
+}
+
+// Option configures Wrap.
+type Option func(*options)
+
+type options struct {
+	log *slog.Logger
+}
+
+// WithLogger logs invocations to l instead of the default JSON logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.log = l
+	}
+}
+
+var warm atomic.Bool
+
+// Wrap returns a handler for lambda.Start that stores a logger carrying
+// ContextAttrs in the invocation context for logger.FromContext, and logs the
+// start of every invocation at Debug level and its end with the duration and
+// cold_start. Failed invocations are logged at Error level.
+//
+// Unless WithLogger is given, records are written to stdout as JSON lines for
+// CloudWatch, and that logger is installed as slog.Default.
+func Wrap[TIn, TOut any](h func(ctx context.Context, in TIn) (TOut, error), opts ...Option) func(ctx context.Context, in TIn) (TOut, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.log == nil {
+		o.log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+		slog.SetDefault(o.log)
+	}
+
+	return func(ctx context.Context, in TIn) (TOut, error) {
+		coldStart := !warm.Swap(true)
+		l := o.log.With(attrsToAny(ContextAttrs(ctx))...)
+		ctx = logger.IntoContext(ctx, l)
+		if lc, ok := lambdacontext.FromContext(ctx); ok {
+			ctx = logger.ContextWithRequestID(ctx, lc.AwsRequestID)
+		}
+
+		l.DebugContext(ctx, "invocation started")
+		start := time.Now()
+		out, err := h(ctx, in)
+
+		attrs := []slog.Attr{
+			slog.Duration("duration", time.Since(start)),
+			slog.Bool("cold_start", coldStart),
+		}
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		l.LogAttrs(ctx, level, "invocation completed", attrs...)
+		return out, err
+	}
+}
+
+func attrsToAny(attrs []slog.Attr) []any {
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return args
+}
@@ -0,0 +1,96 @@
+package lambdalog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/testutil"
+)
+
+func invocationContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+	return context.WithTimeout(ctx, timeout)
+}
+
+func TestContextAttrs(t *testing.T) {
+	if attrs := ContextAttrs(context.Background()); len(attrs) != 0 {
+		t.Errorf("got %v outside an invocation", attrs)
+	}
+
+	lambdacontext.FunctionName, lambdacontext.FunctionVersion = "orders", "$LATEST"
+	defer func() { lambdacontext.FunctionName, lambdacontext.FunctionVersion = "", "" }()
+
+	ctx, cancel := invocationContext(3 * time.Second)
+	defer cancel()
+	got := map[string]string{}
+	for _, a := range ContextAttrs(ctx) {
+		got[a.Key] = a.Value.String()
+	}
+	want := map[string]string{"aws_request_id": "req-1", "function_name": "orders", "function_version": "$LATEST", "remaining_time": "lt_5s"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRemainingBucket(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		500 * time.Millisecond: "lt_1s",
+		2 * time.Second:        "lt_5s",
+		10 * time.Second:       "lt_30s",
+		time.Minute:            "lt_5m",
+		15 * time.Minute:       "ge_5m",
+	} {
+		if got := remainingBucket(d); got != want {
+			t.Errorf("remainingBucket(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	h := Wrap(func(ctx context.Context, in string) (string, error) {
+		if logger.RequestIDFromContext(ctx) != "req-1" {
+			t.Error("no request ID in the invocation context")
+		}
+		logger.FromContext(ctx).Info("handling", "in", in)
+		if in == "fail" {
+			return "", errors.New("boom")
+		}
+		return "ok:" + in, nil
+	}, WithLogger(slog.New(capture)))
+
+	ctx, cancel := invocationContext(time.Minute)
+	defer cancel()
+	if out, err := h(ctx, "a"); out != "ok:a" || err != nil {
+		t.Fatalf("got %q, %v", out, err)
+	}
+	if _, err := h(ctx, "fail"); err == nil {
+		t.Fatal("error not returned")
+	}
+
+	if recs := capture.Find("handling"); len(recs) != 2 {
+		t.Fatalf("got %d records from the handler", len(recs))
+	} else if v, _ := recs[0].Attr("aws_request_id"); v.String() != "req-1" {
+		t.Errorf("handler record aws_request_id = %v", v)
+	}
+	completed := capture.Find("invocation completed")
+	if len(completed) != 2 {
+		t.Fatalf("got %d completion records", len(completed))
+	}
+	if completed[0].Level != slog.LevelInfo || completed[1].Level != slog.LevelError {
+		t.Errorf("levels %v and %v", completed[0].Level, completed[1].Level)
+	}
+	if v, _ := completed[1].Attr("error"); v.String() != "boom" {
+		t.Errorf("error = %v", v)
+	}
+	if v, _ := completed[1].Attr("cold_start"); v.Bool() {
+		t.Error("second invocation marked as a cold start")
+	}
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Levels in addition to those of slog.
+const (
+	// LevelTrace is below Debug, for records like wire-level dumps that are too
+	// verbose even for development unless asked for.
+	LevelTrace = slog.LevelDebug - 4
+	// LevelNotice is between Info and Warn, for normal but significant events.
+	LevelNotice = slog.LevelInfo + 2
+	// LevelCritical is above Error, for failures that need immediate action.
+	LevelCritical = slog.LevelError + 4
+)
+
+// namedLevels are the levels with names, in increasing order.
+var namedLevels = []struct {
+	level slog.Level
+	name  string
+}{
+	{LevelTrace, "TRACE"},
+	{slog.LevelDebug, "DEBUG"},
+	{slog.LevelInfo, "INFO"},
+	{LevelNotice, "NOTICE"},
+	{slog.LevelWarn, "WARN"},
+	{slog.LevelError, "ERROR"},
+	{LevelCritical, "CRITICAL"},
+}
+
+// levelName returns the name of level like slog.Level.String, including the
+// levels of this package: levels between named ones are named relative to the
+// one below, like "NOTICE+1", and levels below LevelTrace relative to it.
+func levelName(level slog.Level) string {
+	for i := len(namedLevels) - 1; i >= 0; i-- {
+		l := namedLevels[i]
+		if level == l.level {
+			return l.name
+		}
+		if level > l.level || i == 0 {
+			return fmt.Sprintf("%s%+d", l.name, level-l.level)
+		}
+	}
+	panic("unreachable")
+}
+
+// ParseLevel parses a level name as written by the handler, like "info",
+// "notice", "warn+2" or "TRACE-1", ignoring case.
+func ParseLevel(s string) (slog.Level, error) {
+	name, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name, offset = s[:i], s[i:]
+	}
+	for _, l := range namedLevels {
+		if !strings.EqualFold(name, l.name) {
+			continue
+		}
+		if offset == "" {
+			return l.level, nil
+		}
+		n, err := strconv.Atoi(offset)
+		if err != nil {
+			return 0, fmt.Errorf("invalid level %q", s)
+		}
+		return l.level + slog.Level(n), nil
+	}
+	return 0, fmt.Errorf("invalid level %q", s)
+}
+
+// SyslogSeverity returns the syslog severity of level as defined by RFC 5424,
+// which GELF uses as well: 7 for Debug and below, 6 for Info, 5 for Notice, 4
+// for Warn, 3 for Error and 2 for Critical and above.
+func SyslogSeverity(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return 7
+	case level < LevelNotice:
+		return 6
+	case level < slog.LevelWarn:
+		return 5
+	case level < slog.LevelError:
+		return 4
+	case level < LevelCritical:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// levelNames returns a ReplaceAttr function calling next and then writing
+// levels with levelName, for handlers other than the one of NewHandler.
+func levelNames(next func(groups []string, a slog.Attr) slog.Attr) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if next != nil {
+			a = next(groups, a)
+		}
+		if len(groups) > 0 || a.Key != slog.LevelKey || a.Value.Kind() != slog.KindAny {
+			return a
+		}
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(levelName(level))
+		}
+		return a
+	}
+}
+
+// Trace logs at LevelTrace on the default logger.
+func Trace(msg string, args ...any) {
+	logDefault(context.Background(), LevelTrace, msg, args)
+}
+
+// TraceContext logs at LevelTrace on the default logger with ctx.
+func TraceContext(ctx context.Context, msg string, args ...any) {
+	logDefault(ctx, LevelTrace, msg, args)
+}
+
+// Notice logs at LevelNotice on the default logger.
+func Notice(msg string, args ...any) {
+	logDefault(context.Background(), LevelNotice, msg, args)
+}
+
+// NoticeContext logs at LevelNotice on the default logger with ctx.
+func NoticeContext(ctx context.Context, msg string, args ...any) {
+	logDefault(ctx, LevelNotice, msg, args)
+}
+
+// Critical logs at LevelCritical on the default logger.
+func Critical(msg string, args ...any) {
+	logDefault(context.Background(), LevelCritical, msg, args)
+}
+
+// CriticalContext logs at LevelCritical on the default logger with ctx.
+func CriticalContext(ctx context.Context, msg string, args ...any) {
+	logDefault(ctx, LevelCritical, msg, args)
+}
+
+// logDefault logs on the default logger with the caller of its caller as the
+// source of the record.
+func logDefault(ctx context.Context, level slog.Level, msg string, args []any) {
+	l := slog.Default()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip Callers, logDefault and its caller.
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
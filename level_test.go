@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHandlerLevel(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		opts *HandlerOptions
+		want slog.Level
+	}{
+		{"nil options", nil, slog.LevelInfo},
+		{"nil slog options", &HandlerOptions{}, slog.LevelInfo},
+		{"nil level", &HandlerOptions{HandlerOptions: &slog.HandlerOptions{}}, slog.LevelInfo},
+		{"plain level", &HandlerOptions{HandlerOptions: &slog.HandlerOptions{Level: slog.LevelWarn}}, slog.LevelWarn},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(tc.opts)
+			if h.Enabled(ctx, tc.want-1) || !h.Enabled(ctx, tc.want) {
+				t.Errorf("handler enabled from %v, want %v", firstEnabled(h), tc.want)
+			}
+		})
+	}
+}
+
+// firstEnabled returns the lowest level h is enabled for between Debug-4 and Error+4.
+func firstEnabled(h slog.Handler) slog.Level {
+	for l := slog.LevelDebug - 4; l < slog.LevelError+4; l++ {
+		if h.Enabled(context.Background(), l) {
+			return l
+		}
+	}
+	return slog.LevelError + 4
+}
+
+func TestHandlerLevelVar(t *testing.T) {
+	var level slog.LevelVar
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, HandlerOptions: &slog.HandlerOptions{Level: &level}}))
+	derived := l.With("k", 1).WithGroup("g")
+
+	l.Debug("dropped")
+	level.Set(slog.LevelDebug)
+	l.Debug("kept")
+	derived.Debug("kept derived")
+	level.Set(slog.LevelError)
+	derived.Warn("dropped derived")
+
+	var msgs []string
+	for _, r := range parseLines(t, buf.Bytes()) {
+		msgs = append(msgs, r[slog.MessageKey].(string))
+	}
+	if len(msgs) != 2 || msgs[0] != "kept" || msgs[1] != "kept derived" {
+		t.Errorf("records = %q, want the two logged while Debug was enabled", msgs)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want slog.Level
+	}{
+		{"trace", LevelTrace},
+		{"DEBUG", slog.LevelDebug},
+		{"Info", slog.LevelInfo},
+		{"warn+2", slog.LevelWarn + 2},
+		{"TRACE-1", LevelTrace - 1},
+		{"notice", LevelNotice},
+		{"critical+1", LevelCritical + 1},
+	} {
+		if got, err := ParseLevel(tc.s); err != nil || got != tc.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tc.s, got, err, tc.want)
+		}
+	}
+	for _, s := range []string{"", "verbose", "warning", "info+", "info+x", "info+1+1", "+1", " info", "info 1"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) succeeded", s)
+		}
+	}
+	// Every level round-trips through the names the handler writes.
+	for level := LevelTrace - 4; level <= LevelCritical+4; level++ {
+		if got, err := ParseLevel(levelName(level)); err != nil || got != level {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", levelName(level), got, err, level)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{
+		Writer:         &buf,
+		HandlerOptions: &slog.HandlerOptions{Level: LevelTrace},
+	})))
+	Trace("frame sent", "len", 3)
+	TraceContext(context.Background(), "frame received")
+	slog.Log(context.Background(), LevelTrace-1, "dropped")
+
+	records := parseLines(t, buf.Bytes())
+	if len(records) != 2 || records[0][slog.LevelKey] != "TRACE" || records[1][slog.MessageKey] != "frame received" {
+		t.Errorf("got %s", buf.Bytes())
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		level slog.Level
+		want  int
+	}{
+		{LevelTrace, 7},
+		{slog.LevelDebug, 7},
+		{slog.LevelInfo, 6},
+		{slog.LevelInfo + 1, 6},
+		{LevelNotice, 5},
+		{slog.LevelWarn, 4},
+		{slog.LevelError, 3},
+		{LevelCritical - 1, 3},
+		{LevelCritical, 2},
+		{LevelCritical + 8, 2},
+	} {
+		if got := SyslogSeverity(tc.level); got != tc.want {
+			t.Errorf("SyslogSeverity(%v) = %d, want %d", levelName(tc.level), got, tc.want)
+		}
+	}
+}
+
+func TestNoticeCritical(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true})))
+	Notice("config reloaded")
+	NoticeContext(context.Background(), "config reloaded")
+	Critical("disk failed")
+	CriticalContext(context.Background(), "disk failed")
+	slog.Log(context.Background(), LevelNotice+1, "between")
+
+	var levels []string
+	for line := range strings.Lines(buf.String()) {
+		var record struct{ Level string }
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, record.Level)
+	}
+	if want := []string{"NOTICE", "NOTICE", "CRITICAL", "CRITICAL", "NOTICE+1"}; !slices.Equal(levels, want) {
+		t.Errorf("got levels %q, want %q", levels, want)
+	}
+}
@@ -0,0 +1,116 @@
+package logger
+
+import "slices"
+
+// truncatedField ends objects cut by cutObject.
+const truncatedField = `"_truncated":true}`
+
+// cutObject cuts the JSON object obj, as written by attrEncoder, to at most limit
+// bytes. It keeps the fields that fit, closes the objects left open and adds
+// "_truncated":true at the top level, so that the result is still valid JSON.
+// Arrays are kept or dropped as a whole. The result may exceed limit when not
+// even the marker fits.
+func cutObject(obj []byte, limit int) []byte {
+	if len(obj) <= limit {
+		return obj
+	}
+
+	// The best position to cut at so far and the number of objects open there.
+	cut, open := 1, 1
+	depth := 0
+	for i := 0; i < len(obj); {
+		// Fields end before commas. Cutting right after the opening brace of a
+		// nested object would leave an empty group.
+		p := i
+		switch obj[i] {
+		case '"', '[':
+			i = skipValue(obj, i)
+			continue
+		case ',':
+			i++
+		default:
+			switch obj[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			i++
+			continue
+		}
+
+		size := p + depth - 1 + len(truncatedField)
+		if obj[p-1] != '{' {
+			size++
+		}
+		if size > limit {
+			break
+		}
+		cut, open = p, depth
+	}
+
+	b := obj[:cut]
+	for range open - 1 {
+		b = append(b, '}')
+	}
+	if b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	return append(b, truncatedField...)
+}
+
+// visibleLen returns the length of b without the color escape sequences added by
+// the handler.
+func visibleLen(b []byte) int {
+	n := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\033' && i+1 < len(b) && b[i+1] == '[' {
+			for i < len(b) && b[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// closeTruncated turns obj, a JSON object written by attrEncoder and cut
+// anywhere by MaxRecordBytes, into valid JSON: it keeps the fields that were
+// written completely, closes the objects left open and adds "_truncated":true
+// at the top level.
+func closeTruncated(obj []byte) []byte {
+	// The position after the last complete field and the objects open there.
+	cut, open := 1, 1
+	// stack holds the open objects and arrays.
+	stack := make([]byte, 0, 8)
+	inString := false
+	for i := 0; i < len(obj); i++ {
+		c := obj[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+		case c == '}' || c == ']':
+			stack = stack[:len(stack)-1]
+		case c == ',' && !slices.Contains(stack, '['):
+			cut, open = i, len(stack)
+		}
+	}
+
+	b := obj[:cut]
+	for range open - 1 {
+		b = append(b, '}')
+	}
+	if b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	return append(b, truncatedField...)
+}
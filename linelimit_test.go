@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// colorRE matches the color escape sequences of the handler.
+var colorRE = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestMaxLineBytes(t *testing.T) {
+	args := []any{"id", 7, "name", "日本語のテキスト", "tags", []string{"a", "ö"}, slog.Group("req", "path", "/ünïcode", "n", 1)}
+	render := func(opts HandlerOptions) string {
+		var buf bytes.Buffer
+		opts.Writer = &buf
+		opts.Now = func() time.Time { return goldenTime }
+		opts.ForceNow = true
+		slog.New(NewHandler(&opts)).Info("msg", args...)
+		return buf.String()
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+	}{
+		{"text", HandlerOptions{}},
+		{"colorized", HandlerOptions{Colorize: true, Theme: ThemeDefault}},
+		{"json", HandlerOptions{JSON: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			full := render(tc.opts)
+			fullAttrs := lineAttrs(t, tc.opts.JSON, full)
+			// The shortest line is the one with just the marker.
+			opts := tc.opts
+			opts.MaxLineBytes = 1
+			short := visibleLen([]byte(render(opts)))
+			// Every limit up to the exact length of the full line, so that limits
+			// also fall within the multibyte runes of the values.
+			for limit := short; limit <= visibleLen([]byte(full))+1; limit++ {
+				opts := tc.opts
+				opts.MaxLineBytes = limit
+				line := render(opts)
+				if n := visibleLen([]byte(line)); n > limit {
+					t.Fatalf("limit %d: got %d bytes: %s", limit, n, line)
+				}
+				if !utf8.ValidString(line) {
+					t.Fatalf("limit %d: invalid UTF-8: %q", limit, line)
+				}
+				if limit >= visibleLen([]byte(full)) {
+					if line != full {
+						t.Fatalf("limit %d: got %s, want %s", limit, line, full)
+					}
+					continue
+				}
+
+				// The fields kept are a prefix of the fields of the full line.
+				attrs := lineAttrs(t, tc.opts.JSON, line)
+				var got map[string]any
+				if err := json.Unmarshal([]byte(attrs), &got); err != nil {
+					t.Fatalf("limit %d: %v: %s", limit, err, line)
+				}
+				if got["_truncated"] != true {
+					t.Fatalf("limit %d: not marked truncated: %s", limit, line)
+				}
+				kept := strings.TrimSuffix(strings.TrimSuffix(attrs, `"_truncated":true}`), ",")
+				if !strings.HasPrefix(fullAttrs, strings.TrimRight(kept, "}")) {
+					t.Fatalf("limit %d: kept %s, not a prefix of %s", limit, kept, fullAttrs)
+				}
+			}
+		})
+	}
+}
+
+// lineAttrs returns the attrs object of a line without colors, or the whole
+// object of a JSON line.
+func lineAttrs(t *testing.T, isJSON bool, line string) string {
+	t.Helper()
+	line = strings.TrimSuffix(colorRE.ReplaceAllString(line, ""), "\n")
+	if isJSON {
+		return line
+	}
+	i := strings.IndexByte(line, '{')
+	if i < 0 {
+		t.Fatalf("no attrs in %q", line)
+	}
+	return line[i:]
+}
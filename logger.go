@@ -4,147 +4,735 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"net/http"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/go-chi/chi/v5/middleware"
 )
 
-const (
-	reset = "\033[0m"
-
-	black        = 30
-	red          = 31
-	green        = 32
-	yellow       = 33
-	blue         = 34
-	magenta      = 35
-	cyan         = 36
-	lightGray    = 37
-	darkGray     = 90
-	lightRed     = 91
-	lightGreen   = 92
-	lightYellow  = 93
-	lightBlue    = 94
-	lightMagenta = 95
-	lightCyan    = 96
-	white        = 97
-)
-
-func colorize(colorCode int, v string) string {
-	return fmt.Sprintf("\033[%sm%s%s", strconv.Itoa(colorCode), v, reset)
-}
+const reset = "\033[0m"
 
 type handler struct {
-	h           slog.Handler
-	b           *bytes.Buffer
-	m           *sync.Mutex
-	colorize    bool
+	w           io.Writer
+	level       slog.Leveler
+	groups      []group
+	addSource   bool
+	replace     func(groups []string, a slog.Attr) slog.Attr
+	palette     palette
+	icons       *iconSet
 	prettyPrint bool
+	// json writes records as JSON objects, with jsonReplace applied to the
+	// time, level and message.
+	json        bool
+	jsonReplace func(groups []string, a slog.Attr) slog.Attr
+	extractors  []ContextExtractor
+	debugWhen   func(ctx context.Context, r slog.Record) bool
+	secrets     *SecretScan
+	transforms  []transformRule
+	timeLayout  string
+	formatTime  func(t time.Time) string
+	maxBytes    int
+	onError     func(err error)
+
+	compactCaller  bool
+	stackLevel     slog.Leveler
+	allGoroutines  bool
+	fallback       io.Writer
+	maxAttrs       int
+	controlChars   ControlChars
+	sortKeys       bool
+	lineEnding     string
+	maxLine        int
+	hexBytes       bool
+	humanDurations bool
+	humanLaps      bool
+	now            func() time.Time
+	forceNow       bool
+	// stats, seq, state and mu are shared with the handlers derived from this
+	// one.
+	stats *handlerStats
+	seq   *atomic.Uint64
+	// mu serializes the writes to w, which needn't be safe for concurrent use.
+	// It is nil for SingleWriter handlers and with WriteTimeout, whose writes
+	// are serialized by the timeoutWriter.
+	mu *writeLock
+	// state is the buffer of a SingleWriter handler.
+	state *renderState
+	// timeout writes to w when WriteTimeout is set.
+	timeout *timeoutWriter
+	// status is the status line state when w is a terminal.
+	status *statusState
+}
+
+// group holds the attrs added with WithAttrs after the group was opened with
+// WithGroup, rendered as JSON fields. The first group of a handler is the root
+// and has no name.
+type group struct {
+	name   string
+	fields []byte
+	// suspects are the keys of the fields SecretScan flagged.
+	suspects []string
+	// attrs are the attrs of WithAttrs, kept instead of fields with
+	// AttrTransforms.
+	attrs []slog.Attr
 }
 
 func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.h.Enabled(ctx, level)
+	if level >= h.level.Level() {
+		return true
+	}
+	if h.debugWhen != nil {
+		return h.debugWhen(ctx, slog.Record{Level: level})
+	}
+	return level >= slog.LevelDebug && ctx != nil && DebugForced(ctx)
 }
 
 func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &handler{h: h.h.WithAttrs(attrs), b: h.b, m: h.m, colorize: h.colorize, prettyPrint: h.prettyPrint}
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.groups = slices.Clone(h.groups)
+	last := &h2.groups[len(h2.groups)-1]
+	if h.transforms != nil {
+		last.attrs = append(slices.Clip(last.attrs), attrs...)
+		return &h2
+	}
+
+	e := &attrEncoder{replace: h.replace, hexBytes: h.hexBytes, humanDurations: h.humanDurations, humanLaps: h.humanLaps, secrets: h.secrets}
+	for _, g := range h.groups[1:] {
+		e.groups = append(e.groups, g.name)
+	}
+	b := make([]byte, 0, len(last.fields)+64)
+	b = appendFields(append(b, '{'), last.fields)
+	// Without a limit, rendering can't fail.
+	b, _ = e.appendAttrs(b, attrs)
+	last.fields = b[1:]
+	last.suspects = append(slices.Clip(last.suspects), e.suspects...)
+	return &h2
 }
 
 func (h *handler) WithGroup(name string) slog.Handler {
-	return &handler{h: h.h.WithGroup(name), b: h.b, m: h.m, colorize: h.colorize, prettyPrint: h.prettyPrint}
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clip(h.groups), group{name: name})
+	return &h2
 }
 
 const (
 	timeFormat = "[2006-01-02 15:04:05.000]"
+
+	// truncatedMarker ends the attrs of records cut at MaxRecordBytes.
+	truncatedMarker = "...[truncated]"
 )
 
+// renderState is the buffer a record is rendered into, reused across records.
+type renderState struct {
+	buf    []byte
+	enc    attrEncoder
+	starts []int
+	sorted []byte
+	styled []byte
+	// busy is set while a SingleWriter handler handles a record.
+	busy bool
+}
+
+// maxPooledBuffer is the capacity above which buffers aren't reused, so that a
+// single huge record doesn't keep its memory alive.
+const maxPooledBuffer = 64 << 10
+
+var renderPool = sync.Pool{
+	New: func() any {
+		s := &renderState{buf: make([]byte, 0, 1024)}
+		renderBuffers.Add(1)
+		runtime.AddCleanup(s, func(struct{}) { renderBuffers.Add(-1) }, struct{}{})
+		return s
+	},
+}
+
+// release ends the handling of a record by a SingleWriter handler, dropping
+// its buffer when a huge record has grown it beyond maxPooledBuffer.
+func (s *renderState) release() {
+	s.busy = false
+	if cap(s.buf) > maxPooledBuffer {
+		s.buf = nil
+	}
+}
+
+func freeRenderState(s *renderState) {
+	if cap(s.buf) > maxPooledBuffer {
+		return
+	}
+	renderPool.Put(s)
+}
+
 func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.debugWhen != nil && r.Level < h.level.Level() && !h.debugWhen(ctx, r) {
+		return nil
+	}
+	if h.now != nil && (h.forceNow || r.Time.IsZero()) {
+		r.Time = h.now()
+	}
 
-	level := r.Level.String() + ":"
+	s := h.state
+	if s == nil {
+		s = renderPool.Get().(*renderState)
+		defer freeRenderState(s)
+	} else {
+		if s.busy {
+			// A SingleWriter handler is only used by one goroutine, so this
+			// record was logged while that goroutine handled another one.
+			return h.suppress(r)
+		}
+		s.busy = true
+		defer s.release()
+	}
 
-	attrs, err := h.computeAttrs(ctx, r)
-	if err != nil {
-		return err
+	// The palette is empty without Colorize.
+	p := &h.palette
+
+	// Render the whole line first so that it reaches the writer in a single
+	// Write and can't interleave with records logged concurrently.
+	b := s.buf[:0]
+	if h.json {
+		b = h.appendJSONHeader(&s.enc, b, r)
+	} else if !r.Time.IsZero() {
+		b = append(b, p.time...)
+		if h.formatTime != nil {
+			b = append(b, '[')
+			b = append(b, h.formatTime(r.Time)...)
+			b = append(b, ']')
+		} else {
+			b = r.Time.AppendFormat(b, h.timeLayout)
+		}
+		b = appendReset(b, p.time)
+		b = append(b, ' ')
+	}
+	if h.icons != nil {
+		b = append(b, h.icons.icon(r.Level)...)
+		b = append(b, ' ')
+	}
+	if !h.json && (h.icons == nil || !h.icons.compact) {
+		levelStyle := p.levels[r.Level]
+		b = append(b, levelStyle...)
+		b = append(b, levelName(r.Level)...)
+		b = append(b, ':')
+		b = appendReset(b, levelStyle)
+		b = append(b, ' ')
+	}
+	messageStyle := p.message
+	if r.Level >= slog.LevelError {
+		messageStyle = p.errorMessage
+	}
+	if !h.json {
+		b = append(b, messageStyle...)
+		b = appendMessage(b, r.Message, h.controlChars)
+		b = appendReset(b, messageStyle)
+		b = append(b, ' ')
+		b = append(b, p.attrs...)
 	}
 
-	if r.Level == slog.LevelError {
-		// Skip three levels of slog functions calls
-		_, file, line, ok := runtime.Caller(3)
-		if !ok {
-			file = "unknown"
-			line = 0
+	attrsStart := len(b)
+	b, err := h.appendAttrs(ctx, s, b, r)
+	s.buf = b
+	truncated := errors.Is(err, errTruncated)
+	if truncated {
+		h.stats.truncated.Add(1)
+		if h.json {
+			b = append(b[:attrsStart], closeTruncated(b[attrsStart:])...)
+		} else {
+			b = append(b, truncatedMarker...)
+		}
+		if h.onError != nil {
+			h.onError(fmt.Errorf("record %q exceeded %d bytes and was truncated", r.Message, h.maxBytes))
+		}
+	} else if err != nil {
+		return fmt.Errorf("error when marshaling attrs: %w", err)
+	}
+	if h.sortKeys && !truncated {
+		s.sorted = appendSorted(s.sorted[:0], b[attrsStart:])
+		b = append(b[:attrsStart], s.sorted...)
+	}
+	if h.maxLine > 0 && !truncated {
+		limit := h.maxLine - visibleLen(b[:attrsStart]) - len(h.lineEnding)
+		b = append(b[:attrsStart], cutObject(b[attrsStart:], limit)...)
+	}
+	if h.prettyPrint && !h.json && !truncated {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, b[attrsStart:], "", "  "); err != nil {
+			return fmt.Errorf("error when indenting attrs: %w", err)
 		}
+		b = append(b[:attrsStart], indented.Bytes()...)
+	}
+
+	if (p.key != "" || p.value != "") && !truncated {
+		s.styled = p.appendStyledJSON(s.styled[:0], b[attrsStart:])
+		b = append(b[:attrsStart], s.styled...)
+	}
+	b = appendReset(b, p.attrs)
+	stack := h.stackLevel != nil && r.Level >= h.stackLevel.Level()
+	if h.json {
+		b = h.mergeJSON(b, attrsStart, stack, r.PC)
+	}
+	b = append(b, h.lineEnding...)
+	if diffs := s.enc.diffs; len(diffs) > 0 && !truncated {
+		b = h.appendDiffs(b, diffs)
+	}
+	if stack && !h.json {
+		b = h.appendStack(b, r.PC)
+	}
+	s.buf = b
+	if err := h.write(r.Level, r.Message, b); err != errRecursive {
+		return err
+	}
+	return h.suppress(r)
+}
 
-		attrs["file"] = file
-		attrs["line"] = line
+// appendJSONHeader opens the JSON object of a record with its time, level and
+// message, passed to jsonReplace like slog.JSONHandler passes them to
+// ReplaceAttr.
+func (h *handler) appendJSONHeader(e *attrEncoder, b []byte, r slog.Record) []byte {
+	*e = attrEncoder{groups: e.groups[:0], suspects: e.suspects[:0], diffs: e.diffs[:0]}
+	b = append(b, '{')
+	if !r.Time.IsZero() {
+		b = h.appendBuiltin(e, b, slog.Time(slog.TimeKey, r.Time))
 	}
+	b = h.appendBuiltin(e, b, slog.Any(slog.LevelKey, r.Level))
+	return h.appendBuiltin(e, b, slog.String(slog.MessageKey, r.Message))
+}
 
-	var attrsBytes []byte
-	if h.prettyPrint {
-		attrsBytes, err = json.MarshalIndent(attrs, "", "  ")
-	} else {
-		attrsBytes, err = json.Marshal(attrs)
+// appendBuiltin appends the built-in attr a passed to jsonReplace. Like
+// slog.JSONHandler, it passes the attrs of a group replacing a to jsonReplace
+// too, as with TimeEpochSplit.
+func (h *handler) appendBuiltin(e *attrEncoder, b []byte, a slog.Attr) []byte {
+	a = h.jsonReplace(nil, a)
+	if a.Value = a.Value.Resolve(); a.Value.Kind() == slog.KindGroup && a.Key != "" {
+		a.Value = slog.GroupValue(replaceAttrs(h.jsonReplace, []string{a.Key}, a.Value.Group())...)
 	}
+	b, _ = e.appendAttr(b, a)
+	return b
+}
+
+// mergeJSON merges the attrs object written at attrsStart into the object
+// opened by appendJSONHeader and closes it, adding the stack of the logging
+// call as a "stack" field if asked for.
+func (h *handler) mergeJSON(b []byte, attrsStart int, stack bool, pc uintptr) []byte {
+	// Drop the braces of the attrs object.
+	b = b[:len(b)-1]
+	switch {
+	case len(b) == attrsStart+1:
+		b = b[:attrsStart]
+	case b[attrsStart-1] == '{':
+		b = append(b[:attrsStart], b[attrsStart+1:]...)
+	default:
+		b[attrsStart] = ','
+	}
+	if stack {
+		b = appendKey(b, "stack")
+		stack := strings.TrimPrefix(string(h.appendStack(nil, pc)), "  stack:"+h.lineEnding)
+		b = appendString(b, strings.TrimSuffix(stack, h.lineEnding))
+	}
+	return append(b, '}')
+}
+
+// write writes the rendered record b, clearing and redrawing the status line
+// around it if one is shown. It returns errRecursive without writing b when
+// the calling goroutine is already writing a record, which happens when the
+// writer logs to the handler it writes for.
+func (h *handler) write(level slog.Level, msg string, b []byte) error {
+	if h.mu != nil {
+		if err := h.mu.lock(); err != nil {
+			return err
+		}
+		defer h.mu.unlock()
+	}
+	if st := h.status; st != nil {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		b = st.withStatus(b)
+	}
+	err := h.writeRecord(level, msg, b)
+	if err != errRecursive {
+		h.stats.records.Add(1)
+	}
+	return err
+}
+
+// writeRecord writes b, with WriteTimeout if it is set.
+func (h *handler) writeRecord(level slog.Level, msg string, b []byte) error {
+	if h.timeout == nil {
+		n, err := writeLevel(h.w, level, b)
+		h.countWrite(n, err)
+		return err
+	}
+	n, err := h.timeout.write(level, b)
+	if errors.Is(err, errWriteTimeout) {
+		h.stats.dropped.Add(1)
+		err = fmt.Errorf("record %q dropped: %w after %v", msg, err, h.timeout.timeout)
+		if h.onError != nil {
+			h.onError(err)
+		}
+		return err
+	}
+	h.countWrite(n, err)
+	return err
+}
+
+func (h *handler) countWrite(n int, err error) {
+	h.stats.bytes.Add(uint64(n))
 	if err != nil {
-		return fmt.Errorf("error when marshaling attrs: %w", err)
+		h.stats.writeErrors.Add(1)
 	}
+}
 
-	if h.colorize {
-		switch r.Level {
-		case slog.LevelDebug:
-			level = colorize(darkGray, level)
-		case slog.LevelInfo:
-			level = colorize(cyan, level)
-		case slog.LevelWarn:
-			level = colorize(lightYellow, level)
-		case slog.LevelError:
-			level = colorize(lightRed, level)
+// Flush waits for the records queued with WriteTimeout to be written, then
+// flushes the writer if it is a Flusher. It implements Flusher, so a handler
+// can be passed to RegisterFlusher.
+func (h *handler) Flush(ctx context.Context) error {
+	if h.timeout != nil {
+		if err := h.timeout.flush(ctx); err != nil {
+			return err
 		}
+	}
+	if f, ok := h.w.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Close writes the records queued with WriteTimeout and stops the goroutine
+// writing them; records logged afterwards fail. It doesn't close the writer,
+// and does nothing without WriteTimeout. Handlers derived with WithAttrs and
+// WithGroup share the goroutine, so closing any of them closes all.
+func (h *handler) Close() error {
+	if h.timeout != nil {
+		h.timeout.close()
+	}
+	return nil
+}
 
-		fmt.Println(
-			colorize(lightGray, r.Time.Format(timeFormat)),
-			level,
-			colorize(white, r.Message),
-			colorize(darkGray, string(attrsBytes)),
+// appendAttrs appends a JSON object with the attrs of the handler nested in
+// their groups and the record attrs in the innermost group. Attrs added by the
+// package, like context attrs and the caller of error records, are written at
+// the top level.
+func (h *handler) appendAttrs(ctx context.Context, s *renderState, b []byte, r slog.Record) ([]byte, error) {
+	e := &s.enc
+	e.replace = h.replace
+	e.hexBytes = h.hexBytes
+	e.humanDurations = h.humanDurations
+	e.humanLaps = h.humanLaps
+	e.secrets = h.secrets
+	e.suspects = e.suspects[:0]
+	// The palette has levels only with Colorize.
+	e.listDiffs = h.palette.levels != nil
+	e.diffs = e.diffs[:0]
+	e.limit = 0
+	if h.maxBytes > 0 {
+		// Colors don't count, and the time, level and message are never cut.
+		e.limit = max(h.maxBytes+len(b)-visibleLen(b), len(b)+1)
+	}
+	e.groups = e.groups[:0]
+	s.starts = s.starts[:0]
+	b = append(b, '{')
+
+	var err error
+	if h.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		source := slog.Group(slog.SourceKey,
+			slog.String("function", frame.Function),
+			slog.String("file", frame.File),
+			slog.Int("line", frame.Line),
 		)
+		if h.compactCaller {
+			source = slog.String(slog.SourceKey, compactCaller(frame))
+		}
+		if b, err = e.appendAttr(b, source); err != nil {
+			return b, err
+		}
+	}
+
+	if h.transforms != nil {
+		b, err = h.appendTransformed(e, b, r)
 	} else {
-		fmt.Println(
-			r.Time.Format(timeFormat),
-			level,
-			r.Message,
-			string(attrsBytes),
-		)
+		b, err = h.appendGroups(e, s, b, r)
+	}
+	if err != nil {
+		return b, err
 	}
 
-	return nil
+	for _, extract := range h.extractors {
+		if b, err = e.appendAttrs(b, extract(ctx)); err != nil {
+			return b, err
+		}
+	}
+
+	if h.seq != nil {
+		b = appendKey(b, "seq")
+		b = strconv.AppendUint(b, h.seq.Add(1), 10)
+	}
+
+	if e.dropped > 0 {
+		b = appendKey(b, "_truncated_attrs")
+		b = strconv.AppendInt(b, int64(e.dropped), 10)
+	}
+
+	if len(e.suspects) > 0 {
+		b = appendKey(b, "possible_secret_keys")
+		b = append(b, '[')
+		for i, key := range e.suspects {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendString(b, key)
+		}
+		b = append(b, ']')
+	}
+
+	if r.Level >= slog.LevelError {
+		frame := caller(r.PC)
+		if h.compactCaller {
+			b = appendKey(b, "caller")
+			b = appendString(b, compactCaller(frame))
+		} else {
+			b = appendKey(b, "file")
+			b = appendString(b, frame.File)
+			b = appendKey(b, "line")
+			b = strconv.AppendInt(b, int64(frame.Line), 10)
+			b = appendKey(b, "function")
+			b = appendString(b, frame.Function)
+		}
+	}
+	return append(b, '}'), nil
 }
 
-func suppressDefaults(
-	next func([]string, slog.Attr) slog.Attr,
-) func([]string, slog.Attr) slog.Attr {
-	return func(groups []string, a slog.Attr) slog.Attr {
-		if a.Key == slog.TimeKey ||
-			a.Key == slog.LevelKey ||
-			a.Key == slog.MessageKey {
-			return slog.Attr{}
+// appendGroups appends the groups of the handler with their fields, and the
+// record attrs in the innermost group.
+func (h *handler) appendGroups(e *attrEncoder, s *renderState, b []byte, r slog.Record) ([]byte, error) {
+	var err error
+	for i, g := range h.groups {
+		if i > 0 {
+			var start int
+			b, start = e.openGroup(b, g.name)
+			s.starts = append(s.starts, start)
 		}
-		if next == nil {
-			return a
+		b = appendFields(b, g.fields)
+		e.suspects = append(e.suspects, g.suspects...)
+		if b, err = e.truncate(b); err != nil {
+			return b, err
 		}
-		return next(groups, a)
 	}
+
+	e.maxAttrs, e.written, e.dropped = h.maxAttrs, 0, 0
+	r.Attrs(func(a slog.Attr) bool {
+		b, err = e.appendAttr(b, a)
+		return err == nil
+	})
+	e.maxAttrs = 0
+	if err != nil {
+		return b, err
+	}
+	for i := len(s.starts) - 1; i >= 0; i-- {
+		b = e.closeGroup(b, s.starts[i])
+	}
+	return b, nil
 }
 
+// appendTransformed appends the attrs of the handler and the record with
+// ReplaceAttr and then AttrTransforms applied. The attrs of the handler count
+// towards MaxAttrs here.
+func (h *handler) appendTransformed(e *attrEncoder, b []byte, r slog.Record) ([]byte, error) {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		attrs = append(slices.Clip(g.attrs), attrs...)
+		if i > 0 && len(attrs) > 0 {
+			attrs = []slog.Attr{{Key: g.name, Value: slog.GroupValue(attrs...)}}
+		}
+	}
+	attrs = transformAttrs(replaceAttrs(h.replace, nil, attrs), h.transforms)
+
+	// ReplaceAttr was applied already.
+	e.replace = nil
+	defer func() { e.replace = h.replace }()
+	e.maxAttrs, e.written, e.dropped = h.maxAttrs, 0, 0
+	defer func() { e.maxAttrs = 0 }()
+	return e.appendAttrs(b, attrs)
+}
+
+// caller returns the location of the logging call recorded in pc. Without a
+// recorded pc, it assumes Handle was called by slog.Error on the default logger.
+func caller(pc uintptr) runtime.Frame {
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		return frame
+	}
+
+	// Skip caller, Handle and the slog functions calls
+	pc, file, line, ok := runtime.Caller(4)
+	if !ok {
+		return runtime.Frame{File: "unknown"}
+	}
+	frame := runtime.Frame{File: file, Line: line}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		frame.Function = fn.Name()
+	}
+	return frame
+}
+
+// compactCaller formats frame as "store.go:42 (*Store).GetUser", with the base
+// name of the file and the function name without its package.
+func compactCaller(frame runtime.Frame) string {
+	fn := frame.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	if i := strings.IndexByte(fn, '.'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line) + " " + fn
+}
+
+// levelWriter is implemented by writers that treat records differently by
+// level, like FileWriter with SyncOnError.
+type levelWriter interface {
+	writeLevel(level slog.Level, p []byte) (int, error)
+}
+
+// ContextExtractor returns attributes derived from ctx, such as trace or tenant IDs.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
 type HandlerOptions struct {
 	*slog.HandlerOptions
-	Colorize    bool
+	Colorize bool
+	// Theme sets the colors used with Colorize. It defaults to the built-in theme
+	// named by the LOG_THEME environment variable, or ThemeDefault.
+	Theme *Theme
+	// Icons adds icons for the levels. They are only written with Colorize or
+	// when Writer is a terminal.
+	Icons       *Icons
 	PrettyPrint bool
+	// JSON writes each record as a single JSON object with "time", "level" and
+	// "msg" fields followed by the attrs, for log collectors. ReplaceAttr is
+	// called for the time, level and message like by slog.JSONHandler, and
+	// stacks are written as a "stack" field. Colorize, Icons, PrettyPrint,
+	// TimeFormat and FormatTime are ignored.
+	JSON bool
+	// Writer receives the rendered records, each in a single Write call. The
+	// calls are serialized, so Writer needn't be safe for concurrent use. It
+	// defaults to os.Stdout.
+	Writer io.Writer
+	// LineEnding ends every record. It defaults to "\n"; use "\r\n" for
+	// collectors expecting CRLF.
+	LineEnding string
+	// WriteTimeout bounds how long logging waits for Writer. Records are then
+	// written by a background goroutine, which the handler's Close stops, and
+	// records that aren't written in time are dropped and reported to OnError.
+	// Zero means waiting as long as Writer blocks.
+	WriteTimeout time.Duration
+	// FallbackWriter receives records logged while another record is handled on
+	// the same goroutine, such as a remote writer logging its own delivery errors
+	// through slog.Default(). It defaults to os.Stderr.
+	FallbackWriter io.Writer
+	// Metadata adds Kubernetes and Cloud Run metadata from the environment to all
+	// records, read once by NewHandler. See MetadataAttrs.
+	Metadata *MetadataEnv
+	// CompactCaller writes the caller of error records, and the source with
+	// AddSource, as a single string like "store.go:42 (*Store).GetUser" instead of
+	// separate file, line and function fields.
+	CompactCaller bool
+	// StackTraceLevel adds up to 32 frames of the logging call to records at or
+	// above this level, as an indented block below the record. Nil means no
+	// stacks.
+	StackTraceLevel slog.Leveler
+	// AllGoroutines adds the stacks of all goroutines below the stack of records
+	// that get one, e.g. to debug deadlocks from a fatal record.
+	AllGoroutines bool
+	// MaxRecordBytes limits the size of a rendered record, not counting colors.
+	// Longer records have their attrs cut and marked as truncated; the time, level
+	// and message are never cut. Zero means no limit.
+	MaxRecordBytes int
+	// Now is the clock used for records without a time. Records without a time
+	// are written without one if Now is nil.
+	Now func() time.Time
+	// ForceNow makes the handler use Now for all records instead of their time,
+	// e.g. for deterministic output in tests.
+	ForceNow bool
+	// SingleWriter makes the handler render all records into one buffer instead of
+	// taking buffers from a pool. The handler, and the handlers derived from it,
+	// must then not be used concurrently.
+	SingleWriter bool
+	// Sequence adds a "seq" number to all records, increasing by one with every
+	// record of the handler and the handlers derived from it. It breaks ties
+	// between records with the same time, and wraps around on overflow.
+	Sequence bool
+	// HexBytes writes []byte attrs in hex instead of base64.
+	HexBytes bool
+	// HumanDurations writes durations like "1.5s" instead of as integer
+	// nanoseconds, which slog.JSONHandler writes as well.
+	HumanDurations bool
+	// SortKeys writes the attrs sorted by key at every level of nesting instead of
+	// in the order they were added, e.g. for golden files.
+	SortKeys bool
+	// ControlChars selects how control characters in messages are written. By
+	// default they are escaped.
+	ControlChars ControlChars
+	// MaxAttrs limits the number of attrs of a record, counting the attrs within
+	// groups. Further attrs are dropped and counted in "_truncated_attrs". Attrs
+	// added with With aren't counted. Zero means no limit.
+	MaxAttrs int
+	// MaxLineBytes limits the length of a record's line, not counting colors, by
+	// dropping the attrs that don't fit and adding "_truncated":true, so that the
+	// attrs remain valid JSON. The time, level and message are never cut, and
+	// PrettyPrint is applied after the limit. Zero means no limit.
+	MaxLineBytes int
+	// OnError is called with problems that didn't prevent a record from being
+	// written, such as truncation.
+	OnError func(err error)
+	// ContextExtractors are called for every record with the context passed to the
+	// logging call, and the attributes they return are added to the record.
+	ContextExtractors []ContextExtractor
+	// DebugWhen lets records below Level through when it returns true, to debug
+	// a single tenant or endpoint. It is called by Enabled with a record that
+	// only has its Level set, so it should decide by the context, and again by
+	// Handle with the full record. It defaults to letting records at Debug level
+	// and above through for contexts from ForceDebug.
+	DebugWhen func(ctx context.Context, r slog.Record) bool
+	// SecretScan flags or masks string values that look like secrets. It is off
+	// when nil.
+	SecretScan *SecretScan
+	// Tenant adds the tenant of the context to records, after the attrs of the
+	// ContextExtractors.
+	Tenant *TenantOptions
+	// TimeFormat is the layout of the time of records, like "02 Jan 15:04:05".
+	// It defaults to "2006-01-02 15:04:05.000".
+	TimeFormat string
+	// FormatTime formats the time of records instead of TimeFormat, like
+	// ShortTime or a func writing localized month names.
+	FormatTime func(t time.Time) string
+	// TimeEncoding selects the encoding of the time of records with JSON. It
+	// defaults to RFC 3339 strings, and doesn't change the other output.
+	TimeEncoding TimeEncoding
+	// AttrTransforms rename, move and cast attrs in order, after ReplaceAttr,
+	// for migrating between logging schemas. They apply to the attrs of records
+	// and WithAttrs, which are rendered with every record then, but not to the
+	// attrs the handler adds. NewHandler panics if the rules conflict, as
+	// reported by ValidateAttrTransforms.
+	AttrTransforms []AttrTransform
 }
 
 func NewHandler(opts *HandlerOptions) *handler {
@@ -154,37 +742,104 @@ func NewHandler(opts *HandlerOptions) *handler {
 	if opts.HandlerOptions == nil {
 		opts.HandlerOptions = &slog.HandlerOptions{}
 	}
-	b := &bytes.Buffer{}
-
-	return &handler{
-		b: b,
-		h: slog.NewJSONHandler(b, &slog.HandlerOptions{
-			Level:       opts.Level,
-			AddSource:   opts.AddSource,
-			ReplaceAttr: suppressDefaults(opts.ReplaceAttr),
-		}),
-		m:           &sync.Mutex{},
-		colorize:    opts.Colorize,
-		prettyPrint: opts.PrettyPrint,
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
 	}
-}
-
-func (h *handler) computeAttrs(ctx context.Context, r slog.Record) (map[string]any, error) {
-	h.m.Lock()
-	defer func() {
-		h.b.Reset()
-		h.m.Unlock()
-	}()
-	if err := h.h.Handle(ctx, r); err != nil {
-		return nil, fmt.Errorf("error when calling inner handler's Handle: %w", err)
+	fallback := opts.FallbackWriter
+	if fallback == nil {
+		fallback = os.Stderr
+	}
+	lineEnding := opts.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	level := opts.Level
+	if level == nil {
+		level = slog.LevelInfo
 	}
 
-	var attrs map[string]any
-	err := json.Unmarshal(h.b.Bytes(), &attrs)
-	if err != nil {
-		return nil, fmt.Errorf("error when unmarshaling inner handler's Handle result: %w", err)
+	h := &handler{
+		w:           w,
+		level:       level,
+		groups:      []group{{}},
+		addSource:   opts.AddSource,
+		replace:     opts.ReplaceAttr,
+		prettyPrint: opts.PrettyPrint,
+		json:        opts.JSON,
+		extractors:  opts.ContextExtractors,
+		debugWhen:   opts.DebugWhen,
+		timeLayout:  timeFormat,
+		formatTime:  opts.FormatTime,
+		maxBytes:    opts.MaxRecordBytes,
+		onError:     opts.OnError,
+
+		compactCaller:  opts.CompactCaller,
+		stackLevel:     opts.StackTraceLevel,
+		allGoroutines:  opts.AllGoroutines,
+		fallback:       fallback,
+		maxAttrs:       opts.MaxAttrs,
+		controlChars:   opts.ControlChars,
+		sortKeys:       opts.SortKeys,
+		lineEnding:     lineEnding,
+		maxLine:        opts.MaxLineBytes,
+		hexBytes:       opts.HexBytes,
+		humanDurations: opts.HumanDurations,
+		humanLaps:      opts.HumanDurations || !opts.JSON,
+		now:            opts.Now,
+		forceNow:       opts.ForceNow,
+	}
+	if opts.AttrTransforms != nil {
+		transforms, err := compileTransforms(opts.AttrTransforms)
+		if err != nil {
+			panic(err)
+		}
+		h.transforms = transforms
+	}
+	if opts.JSON {
+		h.jsonReplace = levelNames(opts.TimeEncoding.ReplaceAttr(opts.ReplaceAttr))
+	} else if opts.Colorize {
+		theme := opts.Theme
+		if theme == nil {
+			theme, _ = LookupTheme(os.Getenv("LOG_THEME"))
+		}
+		if theme == nil {
+			theme = ThemeDefault
+		}
+		h.palette = newPalette(theme)
+	}
+	if opts.Icons != nil && !opts.JSON && (opts.Colorize || isTerminal(w)) {
+		h.icons = newIconSet(opts.Icons)
+	}
+	if opts.TimeFormat != "" {
+		h.timeLayout = "[" + opts.TimeFormat + "]"
+	}
+	h.stats = new(handlerStats)
+	if opts.Tenant != nil {
+		h.extractors = append(slices.Clip(h.extractors), newTenantGuard(opts.Tenant, h.stats).extract)
+	}
+	if opts.WriteTimeout > 0 {
+		h.timeout = newTimeoutWriter(w, opts.WriteTimeout, &h.stats.queueHighWater)
+	}
+	if isTerminal(w) {
+		h.status = new(statusState)
+	}
+	if opts.SecretScan != nil {
+		scan := *opts.SecretScan
+		h.secrets = &scan
+	}
+	if opts.SingleWriter {
+		h.state = &renderState{buf: make([]byte, 0, 1024)}
+	} else if h.timeout == nil {
+		h.mu = newWriteLock()
+	}
+	if opts.Sequence {
+		h.seq = new(atomic.Uint64)
+	}
+	if opts.Metadata != nil {
+		h = h.WithAttrs(MetadataAttrs(opts.Metadata)).(*handler)
 	}
-	return attrs, nil
+	return h
 }
 
 func SetupLoggerWith(opts *HandlerOptions) {
@@ -194,36 +849,3 @@ func SetupLoggerWith(opts *HandlerOptions) {
 
 	slog.SetDefault(logger)
 }
-
-func new(log *slog.Logger) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		log = log.With(
-			slog.String("component", "middleware/logger"),
-		)
-
-		log.Info("logger middleware enabled")
-
-		fn := func(w http.ResponseWriter, r *http.Request) {
-			entry := log.With(
-				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
-				slog.String("remote_addr", r.RemoteAddr),
-				slog.String("user_agent", r.UserAgent()),
-				slog.String("request_id", middleware.GetReqID(r.Context())),
-			)
-
-			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-
-			t1 := time.Now()
-			defer func() {
-				entry.Info("request completed",
-					slog.Int("status", ww.Status()),
-					slog.Int("size", ww.BytesWritten()),
-					slog.Duration("duration", time.Since(t1)),
-				)
-			}()
-			next.ServeHTTP(ww, r)
-		}
-		return http.HandlerFunc(fn)
-	}
-}
@@ -0,0 +1,311 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchRecord returns a record with n of the attrs of a typical access log.
+func benchRecord(n int) slog.Record {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request completed", 0)
+	attrs := []slog.Attr{
+		slog.String("method", "GET"),
+		slog.String("path", "/users/42"),
+		slog.Int("status", 200),
+		slog.Duration("duration", 1500*time.Microsecond),
+		slog.Bool("cached", true),
+	}
+	r.AddAttrs(attrs[:n]...)
+	return r
+}
+
+func TestHandleConcurrentWrites(t *testing.T) {
+	const goroutines, records = 50, 1000
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf}))
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := l.With("g", g).WithGroup("req")
+			for i := range records {
+				l.Info("record", "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	line := regexp.MustCompile(`^\[\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{3}\] INFO: record \{"g":\d+,"req":\{"i":\d+\}\}$`)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*records {
+		t.Fatalf("got %d lines, want %d", len(lines), goroutines*records)
+	}
+	for _, l := range lines {
+		if !line.MatchString(l) {
+			t.Fatalf("malformed line %q", l)
+		}
+	}
+}
+
+func TestHandleAllocs(t *testing.T) {
+	if testing.CoverMode() != "" {
+		t.Skip("coverage counters allocate")
+	}
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	for _, colorize := range []bool{false, true} {
+		h := NewHandler(&HandlerOptions{Writer: io.Discard, Colorize: colorize, Theme: ThemeDefault})
+		l := h.WithAttrs([]slog.Attr{slog.String("request_id", "abc")}).WithGroup("http")
+		r := benchRecord(5)
+		if n := testing.AllocsPerRun(100, func() { l.Handle(context.Background(), r) }); n > 0 {
+			t.Errorf("Colorize %v: %v allocs per record with 5 attrs, want 0", colorize, n)
+		}
+	}
+}
+
+func BenchmarkHandle(b *testing.B) {
+	for _, bc := range []struct {
+		name     string
+		attrs    int
+		colorize bool
+	}{
+		{"no attrs", 0, false},
+		{"5 attrs", 5, false},
+		{"no attrs colorized", 0, true},
+		{"5 attrs colorized", 5, true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			h := NewHandler(&HandlerOptions{Writer: io.Discard, Colorize: bc.colorize, Theme: ThemeDefault})
+			r := benchRecord(bc.attrs)
+			ctx := context.Background()
+			b.ReportAllocs()
+			for b.Loop() {
+				h.Handle(ctx, r)
+			}
+		})
+	}
+}
+
+func TestHugeRecordNotPooled(t *testing.T) {
+	huge := strings.Repeat("x", 10<<20)
+	for _, single := range []bool{false, true} {
+		h := NewHandler(&HandlerOptions{Writer: io.Discard, SingleWriter: single})
+		slog.New(h).Info("dump", "body", huge)
+
+		if single {
+			if c := cap(h.state.buf); c > maxPooledBuffer {
+				t.Errorf("SingleWriter handler kept a %d byte buffer", c)
+			}
+			continue
+		}
+		var states []*renderState
+		for range 8 {
+			s := renderPool.Get().(*renderState)
+			if c := cap(s.buf); c > maxPooledBuffer {
+				t.Errorf("pool returned a %d byte buffer", c)
+			}
+			states = append(states, s)
+		}
+		for _, s := range states {
+			freeRenderState(s)
+		}
+	}
+}
+
+func BenchmarkHandleParallel(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts HandlerOptions
+	}{
+		{"writer", HandlerOptions{Writer: io.Discard}},
+		{"sequence", HandlerOptions{Writer: io.Discard, Sequence: true}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			h := NewHandler(&bc.opts)
+			r := benchRecord(5)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				// Request loggers derived from the same handler share nothing but
+				// the writer.
+				l := h.WithAttrs([]slog.Attr{slog.String("request_id", "abc")})
+				ctx := context.Background()
+				for pb.Next() {
+					l.Handle(ctx, r)
+				}
+			})
+		})
+	}
+}
+
+// TestSetupLoggerWithIsSafe checks that the default logger isn't a SingleWriter
+// handler, which must only be used by one goroutine. Run it with -race.
+func TestSetupLoggerWithIsSafe(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	SetupLoggerWith(&HandlerOptions{Writer: &buf})
+
+	h, ok := slog.Default().Handler().(*handler)
+	if !ok {
+		t.Fatalf("default handler is %T", slog.Default().Handler())
+	}
+	if h.state != nil || h.mu == nil {
+		t.Fatal("SetupLoggerWith installed a SingleWriter handler")
+	}
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				slog.Info("record", "g", g, "i", i)
+			}
+		})
+	}
+	wg.Wait()
+	if n := strings.Count(buf.String(), "\n"); n != 800 {
+		t.Errorf("got %d lines, want 800", n)
+	}
+}
+
+func BenchmarkHandleSingleWriter(b *testing.B) {
+	for _, single := range []bool{false, true} {
+		name := "default"
+		if single {
+			name = "single writer"
+		}
+		b.Run(name, func(b *testing.B) {
+			h := NewHandler(&HandlerOptions{Writer: io.Discard, SingleWriter: single})
+			r := benchRecord(5)
+			ctx := context.Background()
+			b.ReportAllocs()
+			for b.Loop() {
+				h.Handle(ctx, r)
+			}
+		})
+	}
+}
+
+func TestDurations(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{"text", HandlerOptions{}, `{"d":1500000000}`},
+		{"json", HandlerOptions{JSON: true}, `"d":1500000000}`},
+		{"human", HandlerOptions{HumanDurations: true}, `{"d":"1.5s"}`},
+		{"human json", HandlerOptions{JSON: true, HumanDurations: true}, `"d":"1.5s"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := tc.opts
+			opts.Writer = &buf
+			slog.New(NewHandler(&opts)).Info("done", "d", 1500*time.Millisecond)
+			if got := strings.TrimSuffix(buf.String(), "\n"); !strings.HasSuffix(got, tc.want) {
+				t.Errorf("got %q, want suffix %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxRecordBytes(t *testing.T) {
+	const limit = 1 << 10
+	huge := strings.Repeat("x", 50<<20)
+	for _, tc := range []struct {
+		name      string
+		attr      slog.Attr
+		truncated bool
+	}{
+		{"small", slog.String("k", "v"), false},
+		{"huge string", slog.String("body", huge), true},
+		{"huge bytes", slog.Any("body", []byte(huge)), true},
+		{"huge slice", slog.Any("body", []string{huge}), true},
+		{"huge group", slog.Group("g", "a", 1, "body", huge), true},
+	} {
+		for _, isJSON := range []bool{false, true} {
+			var buf bytes.Buffer
+			var errs []error
+			slog.New(NewHandler(&HandlerOptions{
+				Writer:         &buf,
+				JSON:           isJSON,
+				MaxRecordBytes: limit,
+				OnError:        func(err error) { errs = append(errs, err) },
+			})).LogAttrs(context.Background(), slog.LevelInfo, "msg", slog.Int("first", 1), tc.attr)
+
+			line := strings.TrimSuffix(buf.String(), "\n")
+			if len(line) > limit+len(`,"_truncated":true}`) {
+				t.Errorf("%s, JSON %v: got %d bytes", tc.name, isJSON, len(line))
+			}
+			if (len(errs) > 0) != tc.truncated {
+				t.Errorf("%s, JSON %v: got errors %v", tc.name, isJSON, errs)
+			}
+			if !isJSON {
+				if strings.HasSuffix(line, truncatedMarker) != tc.truncated {
+					t.Errorf("%s: got %.100s...", tc.name, line)
+				}
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: %v: %.100s...", tc.name, err, line)
+			}
+			if (record["_truncated"] == true) != tc.truncated || record["msg"] != "msg" || record["first"] != float64(1) {
+				t.Errorf("%s: got %.100s...", tc.name, line)
+			}
+		}
+	}
+}
+
+// TestSequenceConcurrent checks that records logged concurrently through derived
+// handlers get unique sequence numbers without gaps, increasing in the order
+// each goroutine logged them. Run it with -race.
+func TestSequenceConcurrent(t *testing.T) {
+	const goroutines, records = 20, 500
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true, Sequence: true}))
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Go(func() {
+			l := l.With("g", g).WithGroup("req")
+			for i := range records {
+				l.Info("record", "i", i)
+			}
+		})
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	last := make(map[int]uint64)
+	for line := range strings.Lines(buf.String()) {
+		var record struct {
+			Seq uint64
+			G   int
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if seen[record.Seq] {
+			t.Fatalf("seq %d written twice", record.Seq)
+		}
+		seen[record.Seq] = true
+		if record.Seq <= last[record.G] {
+			t.Fatalf("goroutine %d: seq %d after %d", record.G, record.Seq, last[record.G])
+		}
+		last[record.G] = record.Seq
+	}
+	for seq := uint64(1); seq <= goroutines*records; seq++ {
+		if !seen[seq] {
+			t.Fatalf("seq %d missing", seq)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/corray333/go-log/logrlog
+
+go 1.25.2
+
+require github.com/go-logr/logr v1.4.4
@@ -0,0 +1,116 @@
+// Package logrlog provides a logr.Logger backed by a slog handler, for libraries
+// such as controller-runtime that require logr.
+package logrlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// NewLogr returns a logr.Logger writing through the handler of l.
+//
+// V-levels map to slog levels below Info: V(0) is Info, V(1) is Debug and every
+// further V-level is one level below Debug. Names set with WithName are joined
+// with "/" and logged in a "logger" attribute.
+func NewLogr(l *slog.Logger) logr.Logger {
+	return logr.New(&sink{h: l.Handler()})
+}
+
+type sink struct {
+	h     slog.Handler
+	name  string
+	depth int
+}
+
+var (
+	_ logr.LogSink          = (*sink)(nil)
+	_ logr.CallDepthLogSink = (*sink)(nil)
+)
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+func level(v int) slog.Level {
+	if v <= 0 {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug - slog.Level(v-1)
+}
+
+func (s *sink) Enabled(v int) bool {
+	return s.h.Enabled(context.Background(), level(v))
+}
+
+func (s *sink) Info(v int, msg string, kv ...any) {
+	s.log(level(v), msg, nil, kv)
+}
+
+func (s *sink) Error(err error, msg string, kv ...any) {
+	s.log(slog.LevelError, msg, err, kv)
+}
+
+func (s *sink) log(lvl slog.Level, msg string, err error, kv []any) {
+	ctx := context.Background()
+	if !s.h.Enabled(ctx, lvl) {
+		return
+	}
+
+	// Skip runtime.Callers, log, Info or Error and the logr frames.
+	var pcs [1]uintptr
+	runtime.Callers(3+s.depth, pcs[:])
+
+	r := slog.NewRecord(time.Now(), lvl, msg, pcs[0])
+	if s.name != "" {
+		r.AddAttrs(slog.String("logger", s.name))
+	}
+	if err != nil {
+		r.AddAttrs(slog.Any("error", err))
+	}
+	r.AddAttrs(attrs(kv)...)
+	_ = s.h.Handle(ctx, r)
+}
+
+func (s *sink) WithValues(kv ...any) logr.LogSink {
+	s2 := *s
+	s2.h = s.h.WithAttrs(attrs(kv))
+	return &s2
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	s2 := *s
+	if s.name == "" {
+		s2.name = name
+	} else {
+		s2.name = s.name + "/" + name
+	}
+	return &s2
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	s2 := *s
+	s2.depth += depth
+	return &s2
+}
+
+// attrs converts logr key/value pairs to attributes. Non-string keys are
+// formatted, and a trailing key without a value gets "<no-value>", as in funcr.
+func attrs(kv []any) []slog.Attr {
+	attrs := make([]slog.Attr, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = slog.AnyValue(kv[i]).String()
+		}
+		var v any = "<no-value>"
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+		attrs = append(attrs, slog.Any(key, v))
+	}
+	return attrs
+}
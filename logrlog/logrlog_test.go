@@ -0,0 +1,143 @@
+package logrlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// newTest returns a logr.Logger writing JSON lines at every level to the
+// returned buffer.
+func newTest(t *testing.T) (logr.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.Level(-10)})
+	return NewLogr(slog.New(h)), &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf, err)
+	}
+	buf.Reset()
+	return m
+}
+
+func TestLevels(t *testing.T) {
+	for _, tc := range []struct {
+		v    int
+		want string
+	}{
+		{0, "INFO"},
+		{1, "DEBUG"},
+		{2, "DEBUG-1"},
+		{4, "DEBUG-3"},
+	} {
+		log, buf := newTest(t)
+		log.V(tc.v).Info("hello")
+		if got := decode(t, buf)["level"]; got != tc.want {
+			t.Errorf("V(%d) level = %v, want %s", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogr(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if !log.V(0).Enabled() {
+		t.Error("V(0) disabled at Info")
+	}
+	if log.V(1).Enabled() {
+		t.Error("V(1) enabled at Info")
+	}
+	log.V(1).Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("disabled V-level wrote %q", buf)
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		kv   []any
+		want map[string]any
+	}{
+		{"pairs", []any{"a", 1, "b", "x"}, map[string]any{"a": 1.0, "b": "x"}},
+		{"odd", []any{"a", 1, "b"}, map[string]any{"a": 1.0, "b": "<no-value>"}},
+		{"non-string key", []any{42, "v"}, map[string]any{"42": "v"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			log, buf := newTest(t)
+			log.Info("kv", tc.kv...)
+			m := decode(t, buf)
+			for k, want := range tc.want {
+				if m[k] != want {
+					t.Errorf("%s = %v, want %v", k, m[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithValuesAndName(t *testing.T) {
+	log, buf := newTest(t)
+	log = log.WithName("controller").WithValues("kind", "Pod").WithName("reconcile")
+	log.Info("done", "n", 2)
+
+	m := decode(t, buf)
+	if m["logger"] != "controller/reconcile" {
+		t.Errorf("logger = %v, want controller/reconcile", m["logger"])
+	}
+	if m["kind"] != "Pod" || m["n"] != 2.0 {
+		t.Errorf("attrs = %v, want kind=Pod n=2", m)
+	}
+}
+
+func TestError(t *testing.T) {
+	log, buf := newTest(t)
+	log.Error(errors.New("boom"), "failed", "id", 7)
+
+	m := decode(t, buf)
+	if m["level"] != "ERROR" || m["error"] != "boom" || m["id"] != 7.0 {
+		t.Errorf("record = %v, want level=ERROR error=boom id=7", m)
+	}
+}
+
+func helper(log logr.Logger) {
+	log.WithCallDepth(1).Info("from helper")
+}
+
+func TestCaller(t *testing.T) {
+	log, buf := newTest(t)
+	log.Info("direct")
+	if file := sourceFile(t, decode(t, buf)); file != "logrlog_test.go" {
+		t.Errorf("source file = %s, want logrlog_test.go", file)
+	}
+
+	helper(log)
+	src := decode(t, buf)["source"].(map[string]any)
+	if src["function"] != "github.com/corray333/go-log/logrlog.TestCaller" {
+		t.Errorf("source function = %v, want TestCaller", src["function"])
+	}
+
+	log.Error(errors.New("boom"), "direct error")
+	if file := sourceFile(t, decode(t, buf)); file != "logrlog_test.go" {
+		t.Errorf("error source file = %s, want logrlog_test.go", file)
+	}
+}
+
+func sourceFile(t *testing.T, m map[string]any) string {
+	t.Helper()
+	src, ok := m["source"].(map[string]any)
+	if !ok {
+		t.Fatalf("no source in %v", m)
+	}
+	return filepath.Base(src["file"].(string))
+}
@@ -0,0 +1,7 @@
+module github.com/corray333/go-log/logruslog
+
+go 1.25.2
+
+require github.com/sirupsen/logrus v1.10.2
+
+require golang.org/x/sys v0.13.0 // indirect
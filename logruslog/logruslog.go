@@ -0,0 +1,75 @@
+// Package logruslog forwards logrus entries to a slog handler, so services still
+// on logrus write to the same stream as the rest of the code.
+package logruslog
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook passing every entry to a slog handler. Discard the
+// output of the logrus logger to avoid logging entries twice:
+//
+//	l.SetOutput(io.Discard)
+//	l.AddHook(logruslog.NewHook(handler))
+type Hook struct {
+	h slog.Handler
+}
+
+// NewHook returns a Hook writing to h.
+func NewHook(h slog.Handler) *Hook {
+	return &Hook{h: h}
+}
+
+// Levels returns all logrus levels.
+func (hook *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// level maps logrus levels to slog levels. Trace is below Debug, and Panic and
+// Fatal are logged at Error.
+func level(l logrus.Level) slog.Level {
+	switch l {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Fire converts e to a record and passes it to the handler.
+func (hook *Hook) Fire(e *logrus.Entry) error {
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lvl := level(e.Level)
+	if !hook.h.Enabled(ctx, lvl) {
+		return nil
+	}
+
+	var pc uintptr
+	if e.Caller != nil {
+		pc = e.Caller.PC
+	}
+	r := slog.NewRecord(e.Time, lvl, e.Message, pc)
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, e.Data[k]))
+	}
+	return hook.h.Handle(ctx, r)
+}
@@ -0,0 +1,109 @@
+package logruslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTest(t *testing.T, level slog.Level) (*logrus.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: level})
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.TraceLevel)
+	l.ExitFunc = func(int) {}
+	l.AddHook(NewHook(h))
+	return l, &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf, err)
+	}
+	buf.Reset()
+	return m
+}
+
+func TestLevels(t *testing.T) {
+	for _, tc := range []struct {
+		level logrus.Level
+		want  string
+	}{
+		{logrus.TraceLevel, "DEBUG-4"},
+		{logrus.DebugLevel, "DEBUG"},
+		{logrus.InfoLevel, "INFO"},
+		{logrus.WarnLevel, "WARN"},
+		{logrus.ErrorLevel, "ERROR"},
+		{logrus.FatalLevel, "ERROR"},
+		{logrus.PanicLevel, "ERROR"},
+	} {
+		t.Run(tc.level.String(), func(t *testing.T) {
+			l, buf := newTest(t, slog.Level(-10))
+			func() {
+				defer func() { recover() }() // PanicLevel panics after the hooks fire
+				l.WithField("n", 1).Log(tc.level, "hello")
+			}()
+
+			m := decode(t, buf)
+			if m["level"] != tc.want {
+				t.Errorf("level = %v, want %s", m["level"], tc.want)
+			}
+			if m["msg"] != "hello" || m["n"] != 1.0 {
+				t.Errorf("record = %v, want msg=hello n=1", m)
+			}
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l, buf := newTest(t, slog.LevelWarn)
+	l.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Info below Warn wrote %q", buf)
+	}
+	l.Warn("kept")
+	if buf.Len() == 0 {
+		t.Error("Warn not forwarded")
+	}
+}
+
+func TestData(t *testing.T) {
+	l, buf := newTest(t, slog.LevelDebug)
+	l.WithFields(logrus.Fields{"b": "x", "a": 2, "ok": true}).Info("fields")
+
+	// Fields are added in key order.
+	line := buf.String()
+	m := decode(t, buf)
+	if m["a"] != 2.0 || m["b"] != "x" || m["ok"] != true {
+		t.Errorf("record = %v, want a=2 b=x ok=true", m)
+	}
+	if strings.Index(line, `"a"`) > strings.Index(line, `"b"`) {
+		t.Errorf("fields not sorted: %s", line)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	l, buf := newTest(t, slog.LevelDebug)
+	l.Info("no caller")
+	if m := decode(t, buf); m["source"] != nil {
+		t.Errorf("source = %v without ReportCaller", m["source"])
+	}
+
+	l.SetReportCaller(true)
+	l.Info("caller")
+	src, _ := decode(t, buf)["source"].(map[string]any)
+	if file, _ := src["file"].(string); filepath.Base(file) != "logruslog_test.go" {
+		t.Errorf("source = %v, want logruslog_test.go", src)
+	}
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Measure starts timing an operation and returns a func that logs msg at Info
+// level to the logger of ctx with the args, a "duration" attr and the args
+// passed to the func:
+//
+//	defer logger.Measure(ctx, "import finished", "file", name)()
+//
+// When the args passed to the func contain a non-nil error, msg is logged at
+// Warn level. To report a named result error, pass it from a deferred closure:
+//
+//	done := logger.Measure(ctx, "import finished")
+//	defer func() { done("error", err) }()
+func Measure(ctx context.Context, msg string, args ...any) func(...any) {
+	return measure(ctx, slog.LevelInfo, 0, msg, args)
+}
+
+// MeasureDebug is like Measure but logs at Debug level.
+func MeasureDebug(ctx context.Context, msg string, args ...any) func(...any) {
+	return measure(ctx, slog.LevelDebug, 0, msg, args)
+}
+
+// MeasureOver is like Measure but only logs when the operation took longer
+// than d.
+func MeasureOver(ctx context.Context, d time.Duration, msg string, args ...any) func(...any) {
+	return measure(ctx, slog.LevelInfo, d, msg, args)
+}
+
+// now is the clock of Measure, which tests replace.
+var now = time.Now
+
+func measure(ctx context.Context, level slog.Level, threshold time.Duration, msg string, args []any) func(...any) {
+	start := now()
+	return func(end ...any) {
+		stop := now()
+		elapsed := stop.Sub(start)
+		if threshold > 0 && elapsed <= threshold {
+			return
+		}
+		lvl := level
+		if hasError(end) {
+			lvl = max(lvl, slog.LevelWarn)
+		}
+		l := FromContext(ctx)
+		if !l.Enabled(ctx, lvl) {
+			return
+		}
+		var pcs [1]uintptr
+		// Skip Callers and this func.
+		runtime.Callers(2, pcs[:])
+		r := slog.NewRecord(stop, lvl, msg, pcs[0])
+		r.Add(args...)
+		r.AddAttrs(slog.Duration("duration", elapsed))
+		r.Add(end...)
+		_ = l.Handler().Handle(ctx, r)
+	}
+}
+
+// hasError reports whether args contain a non-nil error, as a value or in an
+// attr.
+func hasError(args []any) bool {
+	for _, arg := range args {
+		if a, ok := arg.(slog.Attr); ok && a.Value.Kind() == slog.KindAny {
+			arg = a.Value.Any()
+		}
+		if err, ok := arg.(error); ok && err != nil {
+			return true
+		}
+	}
+	return false
+}
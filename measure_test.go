@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// writeLog records the writes made to it.
+type writeLog []string
+
+func (w *writeLog) Write(p []byte) (int, error) {
+	*w = append(*w, string(p))
+	return len(p), nil
+}
+
+// fakeClock replaces the clock of Measure for the test with one that only
+// moves when advanced.
+func fakeClock(t *testing.T) (advance func(time.Duration)) {
+	clock := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	saved := now
+	t.Cleanup(func() { now = saved })
+	now = func() time.Time { return clock }
+	return func(d time.Duration) { clock = clock.Add(d) }
+}
+
+func TestMeasure(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		log  func(ctx context.Context, advance func(time.Duration))
+		want string
+	}{
+		{"Measure", func(ctx context.Context, advance func(time.Duration)) {
+			done := Measure(ctx, "import finished", "file", "users.csv")
+			advance(1200 * time.Millisecond)
+			done("rows", 3)
+		}, `[2024-01-15 10:30:46.200] INFO: import finished {"file":"users.csv","duration":1200000000,"rows":3}`},
+		{"error", func(ctx context.Context, advance func(time.Duration)) {
+			done := Measure(ctx, "import finished")
+			advance(time.Millisecond)
+			done("error", errors.New("disk full"))
+		}, `[2024-01-15 10:30:45.001] WARN: import finished {"duration":1000000,"error":"disk full"}`},
+		{"nil error", func(ctx context.Context, advance func(time.Duration)) {
+			Measure(ctx, "import finished")("error", error(nil))
+		}, `[2024-01-15 10:30:45.000] INFO: import finished {"duration":0,"error":null}`},
+		{"MeasureDebug disabled", func(ctx context.Context, advance func(time.Duration)) {
+			MeasureDebug(ctx, "import finished")()
+		}, ""},
+		{"MeasureOver at threshold", func(ctx context.Context, advance func(time.Duration)) {
+			done := MeasureOver(ctx, time.Second, "import finished")
+			advance(time.Second)
+			done()
+		}, ""},
+		{"MeasureOver over threshold", func(ctx context.Context, advance func(time.Duration)) {
+			done := MeasureOver(ctx, time.Second, "import finished")
+			advance(time.Second + time.Nanosecond)
+			done()
+		}, `[2024-01-15 10:30:46.000] INFO: import finished {"duration":1000000001}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			advance := fakeClock(t)
+			var w writeLog
+			ctx := IntoContext(context.Background(), slog.New(NewHandler(&HandlerOptions{Writer: &w})))
+			tc.log(ctx, advance)
+
+			if tc.want == "" {
+				if len(w) != 0 {
+					t.Errorf("got %d writes: %q", len(w), w)
+				}
+				return
+			}
+			if len(w) != 1 || w[0] != tc.want+"\n" {
+				t.Errorf("got writes %q, want %q", w, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+)
+
+// MetadataEnv names the environment variables MetadataAttrs reads the
+// Kubernetes metadata from, typically set with the Downward API. Empty fields
+// default to POD_NAME, POD_NAMESPACE, NODE_NAME and CONTAINER_NAME.
+type MetadataEnv struct {
+	Pod       string
+	Namespace string
+	Node      string
+	Container string
+}
+
+// MetadataAttrs returns the pod, namespace, node and container from the
+// environment in a "k8s" group, and the Cloud Run service and revision from
+// K_SERVICE and K_REVISION in a "cloud" group. Unset variables are omitted, as
+// are groups without any of them.
+func MetadataAttrs(env *MetadataEnv) []slog.Attr {
+	if env == nil {
+		env = &MetadataEnv{}
+	}
+
+	var attrs []slog.Attr
+	if k8s := envAttrs(
+		"pod", or(env.Pod, "POD_NAME"),
+		"namespace", or(env.Namespace, "POD_NAMESPACE"),
+		"node", or(env.Node, "NODE_NAME"),
+		"container", or(env.Container, "CONTAINER_NAME"),
+	); len(k8s) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "k8s", Value: slog.GroupValue(k8s...)})
+	}
+	if cloud := envAttrs(
+		"service", "K_SERVICE",
+		"revision", "K_REVISION",
+	); len(cloud) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "cloud", Value: slog.GroupValue(cloud...)})
+	}
+	return attrs
+}
+
+// envAttrs returns an attribute for each key and environment variable pair
+// whose variable is set and not empty.
+func envAttrs(pairs ...string) []slog.Attr {
+	var attrs []slog.Attr
+	for i := 0; i < len(pairs); i += 2 {
+		if v := os.Getenv(pairs[i+1]); v != "" {
+			attrs = append(attrs, slog.String(pairs[i], v))
+		}
+	}
+	return attrs
+}
+
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
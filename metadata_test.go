@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// clearMetadataEnv unsets the variables MetadataAttrs reads by default.
+func clearMetadataEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"POD_NAME", "POD_NAMESPACE", "NODE_NAME", "CONTAINER_NAME", "K_SERVICE", "K_REVISION"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestMetadataAttrs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		opts *MetadataEnv
+		want string
+	}{
+		{"none", nil, nil, "[]"},
+		{"pod only", map[string]string{"POD_NAME": "api-7f9c"}, nil, "[k8s=[pod=api-7f9c]]"},
+		{
+			"all k8s",
+			map[string]string{"POD_NAME": "api-7f9c", "POD_NAMESPACE": "prod", "NODE_NAME": "node-1", "CONTAINER_NAME": "app"},
+			nil,
+			"[k8s=[pod=api-7f9c namespace=prod node=node-1 container=app]]",
+		},
+		{"cloud run", map[string]string{"K_SERVICE": "api", "K_REVISION": "api-00042"}, nil, "[cloud=[service=api revision=api-00042]]"},
+		{
+			"both",
+			map[string]string{"NODE_NAME": "node-1", "K_REVISION": "api-00042"},
+			&MetadataEnv{},
+			"[k8s=[node=node-1] cloud=[revision=api-00042]]",
+		},
+		{
+			"custom names",
+			map[string]string{"MY_POD": "worker-1", "POD_NAME": "ignored", "POD_NAMESPACE": "prod"},
+			&MetadataEnv{Pod: "MY_POD"},
+			"[k8s=[pod=worker-1 namespace=prod]]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearMetadataEnv(t)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if got := slog.GroupValue(MetadataAttrs(tc.opts)...).String(); got != tc.want {
+				t.Errorf("MetadataAttrs = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandlerMetadata(t *testing.T) {
+	clearMetadataEnv(t)
+	t.Setenv("POD_NAME", "api-7f9c")
+
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, Metadata: &MetadataEnv{}}))
+
+	// The environment is read once, when the handler is created.
+	t.Setenv("POD_NAME", "changed")
+	l.Info("hello")
+	if !strings.Contains(buf.String(), `{"k8s":{"pod":"api-7f9c"}}`) {
+		t.Errorf("output = %q, want the pod read at setup", buf.String())
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestAccessLogger(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		code       int
+		copyErrors bool
+		appCopies  int
+	}{
+		{"success", http.StatusOK, true, 0},
+		{"server error", http.StatusInternalServerError, false, 0},
+		{"server error copied", http.StatusInternalServerError, true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app, access := testutil.NewCaptureHandler(), testutil.NewCaptureHandler()
+			opts := []Option{
+				WithAccessLogger(slog.New(access)),
+				WithStatusLevel(500, slog.LevelError),
+				WithLogStart(),
+			}
+			if tc.copyErrors {
+				opts = append(opts, WithErrorCopy())
+			}
+			h := NewLoggerMiddleware(slog.New(app), opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logger.FromContext(r.Context()).Info("handling")
+				w.WriteHeader(tc.code)
+			}))
+			app.Reset()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if n := len(access.Find("request started")); n != 1 {
+				t.Errorf("access log got %d start records", n)
+			}
+			if n := len(access.Find("request completed")); n != 1 {
+				t.Errorf("access log got %d completion records", n)
+			}
+			if n := len(app.Find("handling")); n != 1 {
+				t.Errorf("app log got %d handler records", n)
+			}
+			if n := len(app.Find("request completed")); n != tc.appCopies {
+				t.Errorf("app log got %d completion records, want %d", n, tc.appCopies)
+			}
+			if len(access.Find("handling")) != 0 || len(app.Find("request started")) != 0 {
+				t.Error("records sent to the wrong logger")
+			}
+		})
+	}
+}
+
+func TestAccessLoggerDefault(t *testing.T) {
+	_, recs := serve(t, ok, httptest.NewRequest(http.MethodGet, "/", nil), WithLogStart())
+	if len(recs) != 2 {
+		t.Errorf("got %d records in the single logger, want 2", len(recs))
+	}
+}
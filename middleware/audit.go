@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	logger "github.com/corray333/go-log"
+)
+
+const auditBodyLimit = 64 << 10
+
+// WithAuditLog emits an additional "audit" record to auditLog for every POST,
+// PUT, PATCH and DELETE request, carrying the method, route, status and the
+// subject returned by subjectFn, e.g. the authenticated user. The middleware
+// must run after the authentication middleware for subjectFn to see its result.
+func WithAuditLog(auditLog *slog.Logger, subjectFn func(r *http.Request) string) Option {
+	return func(o *options) {
+		o.auditLog = auditLog
+		o.auditSubject = subjectFn
+	}
+}
+
+// WithAuditBodyFields adds the given top-level fields of JSON request bodies to
+// audit records. Only the first 64KB of a body are inspected.
+func WithAuditBodyFields(fields ...string) Option {
+	return func(o *options) {
+		o.auditFields = append(o.auditFields, fields...)
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditBody captures the beginning of the body of r for audit records.
+func (o *options) auditBody(r *http.Request) *limitedBuffer {
+	if o.auditLog == nil || len(o.auditFields) == 0 || !isMutating(r.Method) ||
+		r.Body == nil || r.Body == http.NoBody ||
+		!strings.Contains(r.Header.Get("Content-Type"), "json") {
+		return nil
+	}
+	buf := &limitedBuffer{limit: auditBodyLimit}
+	body := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(body, buf), body}
+	return buf
+}
+
+func (req *Request) audit(status int) {
+	o, r := req.l.o, req.Request
+	if o.auditLog == nil || !isMutating(r.Method) {
+		return
+	}
+
+	route := r.Pattern
+	if route == "" {
+		route = r.URL.Path
+	}
+	var subject string
+	if o.auditSubject != nil {
+		subject = o.auditSubject(r)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("route", route),
+		slog.String("subject", subject),
+		slog.Int("status", status),
+		slog.String("request_id", logger.RequestIDFromContext(r.Context())),
+	}
+	if req.auditBody != nil {
+		if fields := auditFields(req.auditBody.Bytes(), o.auditFields); len(fields) > 0 {
+			attrs = append(attrs, slog.Group("body", fields...))
+		}
+	}
+	o.auditLog.LogAttrs(r.Context(), slog.LevelInfo, "audit", attrs...)
+}
+
+func auditFields(body []byte, fields []string) []any {
+	var obj map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&obj); err != nil {
+		return nil
+	}
+
+	var attrs []any
+	for _, f := range fields {
+		raw, ok := obj[f]
+		if !ok {
+			continue
+		}
+		var v any
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&v); err == nil {
+			attrs = append(attrs, slog.Any(f, v))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestAuditLog(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"logged", nil},
+		{"skipped", []Option{WithSkipPaths("/orders")}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			access, audit := testutil.NewCaptureHandler(), testutil.NewCaptureHandler()
+			opts := append([]Option{
+				WithAuditLog(slog.New(audit), func(r *http.Request) string { return "alice" }),
+				WithAuditBodyFields("item"),
+			}, tc.opts...)
+			h := NewLoggerMiddleware(slog.New(access), opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var buf [64]byte
+				r.Body.Read(buf[:])
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item":"book","qty":2}`))
+			r.Header.Set("Content-Type", "application/json")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+			recs := audit.Records()
+			if len(recs) != 1 {
+				t.Fatalf("got %d audit records, want 1", len(recs))
+			}
+			for key, want := range map[string]any{"subject": "alice", "status": int64(http.StatusCreated), "method": "POST", "route": "/orders", "body.item": "book"} {
+				if v, ok := recs[0].Attr(key); !ok || v.Any() != want {
+					t.Errorf("%s = %v, want %v", key, v, want)
+				}
+			}
+			if completed := len(access.Find("request completed")); (tc.opts == nil) != (completed == 2) {
+				t.Errorf("got %d access records", completed)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// WithCountRequestBody counts the bytes read from request bodies of unknown
+// length, such as chunked uploads, so that request_bytes can be logged for them.
+func WithCountRequestBody() Option {
+	return func(o *options) {
+		o.countRequestBody = true
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// requestBody wraps the body of r for counting when its length is unknown.
+func (o *options) requestBody(r *http.Request) *countingBody {
+	if !o.countRequestBody || r.ContentLength >= 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body := &countingBody{ReadCloser: r.Body}
+	r.Body = body
+	return body
+}
+
+// requestAttrs returns the size and content type of the request body.
+func requestAttrs(r *http.Request, body *countingBody) []slog.Attr {
+	var attrs []slog.Attr
+	switch {
+	case body != nil:
+		attrs = append(attrs, slog.Int64("request_bytes", body.n.Load()))
+	case r.ContentLength >= 0:
+		attrs = append(attrs, slog.Int64("request_bytes", r.ContentLength))
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		attrs = append(attrs, slog.String("content_type", ct))
+	}
+	return attrs
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestBodyAttrs(t *testing.T) {
+	readAll := func(w http.ResponseWriter, r *http.Request) { io.Copy(io.Discard, r.Body) }
+	chunked := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/upload", io.NopCloser(strings.NewReader("chunked body")))
+		r.ContentLength = -1
+		r.Header.Set("Content-Type", "application/octet-stream")
+		return r
+	}
+	fixed := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item":"book"}`))
+	fixed.Header.Set("Content-Type", "application/json")
+
+	for _, tc := range []struct {
+		name        string
+		r           *http.Request
+		next        http.HandlerFunc
+		opts        []Option
+		bytes       int64
+		hasBytes    bool
+		contentType string
+	}{
+		{"fixed length", fixed, ok, nil, 15, true, "application/json"},
+		{"chunked counted", chunked(), readAll, []Option{WithCountRequestBody()}, 12, true, "application/octet-stream"},
+		{"chunked unread", chunked(), ok, []Option{WithCountRequestBody()}, 0, true, "application/octet-stream"},
+		{"chunked not counted", chunked(), readAll, nil, 0, false, "application/octet-stream"},
+		{"no body", httptest.NewRequest(http.MethodGet, "/", nil), ok, []Option{WithCountRequestBody()}, 0, true, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, recs := serve(t, tc.next, tc.r, tc.opts...)
+			v, ok := recs[0].Attr("request_bytes")
+			if ok != tc.hasBytes || ok && v.Int64() != tc.bytes {
+				t.Errorf("request_bytes = %v, want %d", v, tc.bytes)
+			}
+			ct, ok := recs[0].Attr("content_type")
+			if ok != (tc.contentType != "") || ok && ct.String() != tc.contentType {
+				t.Errorf("content_type = %q, want %q", ct, tc.contentType)
+			}
+		})
+	}
+}
+
+func TestRequestBodyGetBody(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ContentLength = -1
+	var first, second []byte
+	next := func(w http.ResponseWriter, r *http.Request) {
+		first, _ = io.ReadAll(r.Body)
+		body, err := r.GetBody()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		second, _ = io.ReadAll(body)
+	}
+	_, recs := serve(t, next, r, WithCountRequestBody())
+	if string(first) != "payload" || string(second) != "payload" {
+		t.Errorf("read %q and %q", first, second)
+	}
+	if v, _ := recs[0].Attr("request_bytes"); v.Int64() != 7 {
+		t.Errorf("request_bytes = %v, want 7", v)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultDurationBuckets are the bucket boundaries used by WithDurationBuckets
+// when called without any.
+var DefaultDurationBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// WithDurationBuckets adds a duration_bucket attribute to completion records
+// naming the smallest boundary the request duration doesn't exceed, like
+// "le_100ms", or "gt_5s" past the largest one. Boundaries default to
+// DefaultDurationBuckets.
+func WithDurationBuckets(boundaries ...time.Duration) Option {
+	if len(boundaries) == 0 {
+		boundaries = DefaultDurationBuckets
+	}
+	boundaries = slices.Clone(boundaries)
+	slices.Sort(boundaries)
+
+	labels := make([]string, len(boundaries)+1)
+	for i, b := range boundaries {
+		labels[i] = "le_" + bucketDuration(b)
+	}
+	labels[len(boundaries)] = "gt_" + bucketDuration(boundaries[len(boundaries)-1])
+
+	return func(o *options) {
+		o.buckets = boundaries
+		o.bucketLabels = labels
+	}
+}
+
+func (o *options) durationBucket(d time.Duration) slog.Attr {
+	i, _ := slices.BinarySearch(o.buckets, d)
+	return slog.String("duration_bucket", o.bucketLabels[i])
+}
+
+// bucketDuration formats d in the largest of s, ms and us that represents it exactly.
+func bucketDuration(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	default:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "us"
+	}
+}
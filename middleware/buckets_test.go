@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDurationBucket(t *testing.T) {
+	var o options
+	WithDurationBuckets()(&o)
+
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "le_10ms"},
+		{10 * time.Millisecond, "le_10ms"},
+		{10*time.Millisecond + 1, "le_50ms"},
+		{100 * time.Millisecond, "le_100ms"},
+		{250 * time.Millisecond, "le_250ms"},
+		{500 * time.Millisecond, "le_500ms"},
+		{time.Second, "le_1s"},
+		{time.Second + 1, "le_5s"},
+		{5 * time.Second, "le_5s"},
+		{5*time.Second + 1, "gt_5s"},
+		{time.Hour, "gt_5s"},
+	} {
+		if got := o.durationBucket(tc.d).Value.String(); got != tc.want {
+			t.Errorf("durationBucket(%v) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestDurationBucketCustom(t *testing.T) {
+	var o options
+	// Unsorted on purpose; the labels pick the largest exact unit.
+	WithDurationBuckets(2*time.Second, 1500*time.Microsecond, 250*time.Millisecond)(&o)
+
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Millisecond, "le_1500us"},
+		{1500 * time.Microsecond, "le_1500us"},
+		{2 * time.Millisecond, "le_250ms"},
+		{2 * time.Second, "le_2s"},
+		{3 * time.Second, "gt_2s"},
+	} {
+		if got := o.durationBucket(tc.d).Value.String(); got != tc.want {
+			t.Errorf("durationBucket(%v) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestWithDurationBuckets(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	_, recs := serve(t, ok, httptest.NewRequest("GET", "/", nil))
+	if _, ok := recs[0].Attr("duration_bucket"); ok {
+		t.Error("duration_bucket logged without WithDurationBuckets")
+	}
+
+	// Buckets and the slow threshold are independent of each other.
+	_, recs = serve(t, slow, httptest.NewRequest("GET", "/", nil),
+		WithDurationBuckets(time.Millisecond, time.Minute), WithSlowThreshold(time.Millisecond))
+	if v, _ := recs[0].Attr("duration_bucket"); v.String() != "le_60s" {
+		t.Errorf("duration_bucket = %v, want le_60s", v)
+	}
+	if _, ok := recs[0].Attr("slow"); !ok {
+		t.Error("slow attr missing alongside duration_bucket")
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// WithCancelledLevel sets the minimum level of completion records of requests
+// cancelled by the client; records whose status calls for a higher level, like
+// 5xx errors, keep it. It defaults to Info.
+func WithCancelledLevel(level slog.Level) Option {
+	return func(o *options) {
+		o.cancelledLevel = level
+	}
+}
+
+// contextAttrs returns attributes describing why the request context ended
+// and the level to log the completion record at.
+func (o *options) contextAttrs(ctx context.Context, level slog.Level) ([]slog.Attr, slog.Level) {
+	err := ctx.Err()
+	switch {
+	case err == nil:
+		return nil, level
+	case errors.Is(err, context.DeadlineExceeded):
+		return []slog.Attr{
+			slog.Bool("timeout", true),
+			slog.String("context_err", err.Error()),
+		}, max(level, slog.LevelWarn)
+	default:
+		return []slog.Attr{
+			slog.Bool("cancelled", true),
+			slog.String("context_err", err.Error()),
+		}, max(level, o.cancelledLevel)
+	}
+}
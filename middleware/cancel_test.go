@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestCancelledLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		opts   []Option
+		cancel bool
+		want   slog.Level
+		attr   string
+	}{
+		{"cancelled", http.StatusOK, nil, true, slog.LevelInfo, "cancelled"},
+		{"cancelled raised", http.StatusOK, []Option{WithCancelledLevel(slog.LevelWarn)}, true, slog.LevelWarn, "cancelled"},
+		{"cancelled server error", http.StatusInternalServerError, nil, true, slog.LevelError, "cancelled"},
+		{"cancelled debug server error", http.StatusBadGateway, []Option{WithCancelledLevel(slog.LevelDebug)}, true, slog.LevelError, "cancelled"},
+		{"timeout", http.StatusOK, nil, false, slog.LevelWarn, "timeout"},
+		{"timeout server error", http.StatusServiceUnavailable, nil, false, slog.LevelError, "timeout"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			capture := testutil.NewCaptureHandler()
+			opts := append([]Option{WithStatusLevel(500, slog.LevelError)}, tc.opts...)
+			h := NewLoggerMiddleware(slog.New(capture), opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+
+			var ctx context.Context
+			var cancel context.CancelFunc
+			if tc.cancel {
+				ctx, cancel = context.WithCancel(context.Background())
+				cancel()
+			} else {
+				ctx, cancel = context.WithTimeout(context.Background(), -time.Second)
+				defer cancel()
+			}
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+			recs := capture.Find("request completed")
+			if len(recs) != 1 {
+				t.Fatalf("got %d completion records", len(recs))
+			}
+			if recs[0].Level != tc.want {
+				t.Errorf("level %v, want %v", recs[0].Level, tc.want)
+			}
+			if v, ok := recs[0].Attr(tc.attr); !ok || !v.Bool() {
+				t.Errorf("%s = %v", tc.attr, v)
+			}
+		})
+	}
+}
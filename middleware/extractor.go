@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// AttrExtractor returns extra attributes for a request's log records.
+type AttrExtractor func(r *http.Request) []slog.Attr
+
+// WithAttrExtractor adds the attributes returned by extractors to the start and
+// completion records of every request. Extractors run once per request; one that
+// panics is reported with a warning and skipped.
+func WithAttrExtractor(extractors ...AttrExtractor) Option {
+	return func(o *options) {
+		o.attrExtractors = append(o.attrExtractors, extractors...)
+	}
+}
+
+func (o *options) extractAttrs(log *slog.Logger, r *http.Request) []any {
+	var args []any
+	for i, extract := range o.attrExtractors {
+		for _, a := range safeExtract(log, i, extract, r) {
+			args = append(args, a)
+		}
+	}
+	for _, extract := range o.extractors {
+		for _, a := range extract(r.Context()) {
+			args = append(args, a)
+		}
+	}
+	return args
+}
+
+func safeExtract(log *slog.Logger, i int, extract AttrExtractor, r *http.Request) (attrs []slog.Attr) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Warn("attr extractor panicked",
+				slog.Int("extractor", i),
+				slog.Any("panic", rec),
+			)
+			attrs = nil
+		}
+	}()
+	return extract(r)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/corray333/go-log"
+)
+
+func TestAttrExtractor(t *testing.T) {
+	calls := 0
+	apiKey := func(r *http.Request) []slog.Attr {
+		calls++
+		return []slog.Attr{slog.String("api_key_id", r.Header.Get("X-Api-Key-Id"))}
+	}
+	shop := func(r *http.Request) []slog.Attr {
+		return []slog.Attr{slog.String("shop", r.Host)}
+	}
+	broken := func(r *http.Request) []slog.Attr {
+		panic("broken extractor")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://acme.example.com/orders", nil)
+	r.Header.Set("X-Api-Key-Id", "key-7")
+	next := func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handling")
+	}
+	_, recs := serve(t, next, r, WithLogStart(), WithAttrExtractor(apiKey, broken, shop))
+
+	if calls != 1 {
+		t.Errorf("extractor called %d times, want once", calls)
+	}
+	var warned bool
+	for _, rec := range recs {
+		if rec.Message == "attr extractor panicked" {
+			warned = true
+			if rec.Level != slog.LevelWarn {
+				t.Errorf("panic reported at %v", rec.Level)
+			}
+			if v, _ := rec.Attr("extractor"); v.Int64() != 1 {
+				t.Errorf("extractor = %v, want 1", v)
+			}
+			continue
+		}
+		for key, want := range map[string]string{"api_key_id": "key-7", "shop": "acme.example.com"} {
+			if v, ok := rec.Attr(key); !ok || v.String() != want {
+				t.Errorf("%q: %s = %v, want %q", rec.Message, key, v, want)
+			}
+		}
+	}
+	if !warned || len(recs) != 4 {
+		t.Errorf("got %d records, warned %v", len(recs), warned)
+	}
+}
+
+type tenantKey struct{}
+
+func TestContextExtractor(t *testing.T) {
+	extract := func(ctx context.Context) []slog.Attr {
+		if id, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []slog.Attr{slog.String("tenant", id)}
+		}
+		return nil
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, "acme"))
+	_, recs := serve(t, ok, r, WithContextExtractors(extract))
+	if v, _ := recs[0].Attr("tenant"); v.String() != "acme" {
+		t.Errorf("tenant = %v", v)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Filter decides after a request has completed whether its completion record
+// should be skipped. It returns true to skip the record.
+type Filter func(r *http.Request, status int, elapsed time.Duration) bool
+
+// WithFilter skips completion records for requests matched by any of filters.
+// Filtered requests are still served and wrapped as usual; only the log is skipped.
+func WithFilter(filters ...Filter) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+// SkipProbes returns a Filter matching successful Kubernetes probe requests.
+func SkipProbes() Filter {
+	return func(r *http.Request, status int, _ time.Duration) bool {
+		return status < http.StatusBadRequest && strings.HasPrefix(r.UserAgent(), "kube-probe/")
+	}
+}
+
+// SkipPreflight returns a Filter matching successful CORS preflight requests.
+func SkipPreflight() Filter {
+	return func(r *http.Request, status int, _ time.Duration) bool {
+		return status < http.StatusBadRequest && isPreflight(r)
+	}
+}
+
+func (o *options) filtered(r *http.Request, status int, elapsed time.Duration) bool {
+	for _, f := range o.filters {
+		if f(r, status, elapsed) {
+			return true
+		}
+	}
+	return false
+}
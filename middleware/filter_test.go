@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	probe := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		r.Header.Set("User-Agent", "kube-probe/1.30")
+		return r
+	}
+	preflight := func() *http.Request {
+		r := httptest.NewRequest(http.MethodOptions, "/orders", nil)
+		r.Header.Set("Origin", "https://example.com")
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		return r
+	}
+	fast := func(r *http.Request, status int, elapsed time.Duration) bool { return elapsed < time.Hour }
+
+	for _, tc := range []struct {
+		name   string
+		r      *http.Request
+		code   int
+		filter Filter
+		logged bool
+	}{
+		{"probe ok", probe(), http.StatusOK, SkipProbes(), false},
+		{"probe failing", probe(), http.StatusInternalServerError, SkipProbes(), true},
+		{"not a probe", httptest.NewRequest(http.MethodGet, "/healthz", nil), http.StatusOK, SkipProbes(), true},
+		{"preflight ok", preflight(), http.StatusNoContent, SkipPreflight(), false},
+		{"preflight forbidden", preflight(), http.StatusForbidden, SkipPreflight(), true},
+		{"plain options", httptest.NewRequest(http.MethodOptions, "/orders", nil), http.StatusNoContent, SkipPreflight(), true},
+		{"elapsed", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, fast, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(tc.code) }
+			w, recs := serve(t, next, tc.r, WithFilter(tc.filter))
+			if logged := len(recs) == 1; logged != tc.logged {
+				t.Errorf("got %d records, want logged %v", len(recs), tc.logged)
+			}
+			if w.Code != tc.code {
+				t.Errorf("response status %d, want %d", w.Code, tc.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"container/list"
+	"log/slog"
+	"net"
+	"sync"
+)
+
+const geoCacheSize = 4096
+
+// Geo is the location of a client IP address.
+type Geo struct {
+	Country string
+	City    string
+}
+
+// GeoResolver resolves the location of IP addresses, e.g. backed by a MaxMind
+// database or an internal service.
+type GeoResolver interface {
+	Lookup(ip net.IP) (Geo, error)
+}
+
+// WithGeoIP adds a "geo" group with the country and city of the client to
+// completion records. The client is the remote address of the request, or the
+// address a trusted proxy forwarded the request for with WithTrustedProxies.
+// Lookups are cached per IP, private addresses are never looked up, and
+// resolver errors only cause the attributes to be omitted, and are retried.
+func WithGeoIP(resolver GeoResolver) Option {
+	return func(o *options) {
+		o.geo = &geoCache{
+			resolver: resolver,
+			entries:  make(map[string]*list.Element),
+			order:    list.New(),
+		}
+	}
+}
+
+type geoEntry struct {
+	ip  string
+	geo Geo
+}
+
+// geoCache is an LRU cache of resolved locations.
+type geoCache struct {
+	resolver GeoResolver
+	errOnce  sync.Once
+
+	m       sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func (c *geoCache) lookup(log *slog.Logger, host string) (Geo, bool) {
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return Geo{}, false
+	}
+
+	c.m.Lock()
+	if el, ok := c.entries[host]; ok {
+		c.order.MoveToFront(el)
+		e := el.Value.(*geoEntry)
+		c.m.Unlock()
+		return e.geo, true
+	}
+	c.m.Unlock()
+
+	geo, err := c.resolver.Lookup(ip)
+	if err != nil {
+		// Errors aren't cached, as they may be temporary.
+		c.errOnce.Do(func() {
+			log.Debug("geoip lookup failed", slog.String("ip", host), slog.String("error", err.Error()))
+		})
+		return Geo{}, false
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, ok := c.entries[host]; !ok {
+		c.entries[host] = c.order.PushFront(&geoEntry{ip: host, geo: geo})
+		if c.order.Len() > geoCacheSize {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*geoEntry).ip)
+		}
+	}
+	return geo, true
+}
+
+func (g Geo) attr() slog.Attr {
+	var attrs []any
+	if g.Country != "" {
+		attrs = append(attrs, slog.String("country", g.Country))
+	}
+	if g.City != "" {
+		attrs = append(attrs, slog.String("city", g.City))
+	}
+	return slog.Group("geo", attrs...)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+type fakeResolver struct {
+	mu      sync.Mutex
+	lookups map[string]int
+	fail    bool
+}
+
+func (r *fakeResolver) Lookup(ip net.IP) (Geo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups[ip.String()]++
+	if r.fail {
+		return Geo{}, errors.New("resolver unavailable")
+	}
+	return Geo{Country: "DE", City: "Berlin"}, nil
+}
+
+func serveGeo(t *testing.T, h http.Handler, remoteAddr, forwardedFor string) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestGeoIP(t *testing.T) {
+	resolver := &fakeResolver{lookups: make(map[string]int)}
+	log := testutil.NewCaptureHandler()
+	h := NewLoggerMiddleware(slog.New(log), WithGeoIP(resolver))(http.NotFoundHandler())
+
+	for _, addr := range []string{"203.0.113.7:1234", "203.0.113.7:5678", "198.51.100.1:80", "10.0.0.1:80", "127.0.0.1:80"} {
+		serveGeo(t, h, addr, "")
+	}
+
+	if len(resolver.lookups) != 2 || resolver.lookups["203.0.113.7"] != 1 || resolver.lookups["198.51.100.1"] != 1 {
+		t.Errorf("lookups = %v, want one per public IP", resolver.lookups)
+	}
+	if got := len(log.ByAttr("geo.country", "DE")); got != 3 {
+		t.Errorf("got %d records with a country, want 3", got)
+	}
+}
+
+func TestGeoIPErrorsNotCached(t *testing.T) {
+	resolver := &fakeResolver{lookups: make(map[string]int), fail: true}
+	log := testutil.NewCaptureHandler()
+	h := NewLoggerMiddleware(slog.New(log), WithGeoIP(resolver))(http.NotFoundHandler())
+
+	serveGeo(t, h, "203.0.113.7:1234", "")
+	serveGeo(t, h, "203.0.113.7:1234", "")
+	resolver.fail = false
+	serveGeo(t, h, "203.0.113.7:1234", "")
+	serveGeo(t, h, "203.0.113.7:1234", "")
+
+	if got := resolver.lookups["203.0.113.7"]; got != 3 {
+		t.Errorf("got %d lookups, want failures retried and the success cached", got)
+	}
+	if got := len(log.ByAttr("geo.country", "DE")); got != 2 {
+		t.Errorf("got %d records with a country, want 2", got)
+	}
+	if got := len(log.Find("geoip lookup failed")); got != 1 {
+		t.Errorf("failure logged %d times, want once", got)
+	}
+}
+
+func TestGeoIPTrustedProxies(t *testing.T) {
+	resolver := &fakeResolver{lookups: make(map[string]int)}
+	h := NewLoggerMiddleware(slog.New(testutil.NewCaptureHandler()),
+		WithGeoIP(resolver),
+		WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")),
+	)(http.NotFoundHandler())
+
+	// Through a trusted proxy chain, the first untrusted hop from the right.
+	serveGeo(t, h, "10.0.0.1:80", "1.2.3.4, 203.0.113.7, 10.0.0.2")
+	// Not from a trusted proxy, so the header is ignored.
+	serveGeo(t, h, "198.51.100.1:80", "192.0.2.1")
+
+	want := map[string]int{"203.0.113.7": 1, "198.51.100.1": 1}
+	if len(resolver.lookups) != len(want) || resolver.lookups["203.0.113.7"] != 1 || resolver.lookups["198.51.100.1"] != 1 {
+		t.Errorf("lookups = %v, want %v", resolver.lookups, want)
+	}
+}
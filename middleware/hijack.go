@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithConnCloseLog logs a "connection closed" record with the total duration and
+// the bytes transferred when a connection taken over by a handler, such as a
+// WebSocket, is closed. Only traffic through the hijacked net.Conn is counted.
+func WithConnCloseLog() Option {
+	return func(o *options) {
+		o.logConnClose = true
+	}
+}
+
+// hijacked logs the upgrade of the request's connection and returns the
+// connection to hand to the handler.
+func (req *Request) hijacked(w *responseWriter, conn net.Conn) net.Conn {
+	r := req.Request
+
+	protocol := w.Header().Get("Upgrade")
+	if protocol == "" {
+		protocol = r.Header.Get("Upgrade")
+	}
+	attrs := []slog.Attr{slog.String("protocol", protocol)}
+	if sub := w.Header().Get("Sec-WebSocket-Protocol"); sub != "" {
+		attrs = append(attrs, slog.String("subprotocol", sub))
+	}
+	req.Logger.LogAttrs(r.Context(), slog.LevelInfo, "connection upgraded", attrs...)
+
+	if !req.l.o.logConnClose {
+		return conn
+	}
+	return &trackedConn{Conn: conn, req: req, start: req.l.o.now()}
+}
+
+// trackedConn counts the bytes transferred over a hijacked connection and logs
+// when it is closed.
+type trackedConn struct {
+	net.Conn
+	req     *Request
+	start   time.Time
+	read    atomic.Int64
+	written atomic.Int64
+	once    sync.Once
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *trackedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.req.Logger.LogAttrs(c.req.Request.Context(), slog.LevelInfo, "connection closed",
+			slog.Duration("duration", c.req.l.o.now().Sub(c.start)),
+			slog.Int64("bytes_read", c.read.Load()),
+			slog.Int64("bytes_written", c.written.Load()),
+		)
+	})
+	return err
+}
+
+// hijackStatus returns the status to log for a hijacked connection when the
+// handler wrote the upgrade response directly to the connection.
+func hijackStatus(status int) int {
+	if status == 0 {
+		return http.StatusSwitchingProtocols
+	}
+	return status
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+const upgradeResponse = "HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"
+
+// echoUpgrade upgrades the connection to a protocol echoing one message. The
+// client sends it after the upgrade, so it isn't buffered in the returned
+// bufio.ReadWriter.
+func echoUpgrade(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	io.WriteString(conn, upgradeResponse)
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return
+	}
+	conn.Write(buf)
+}
+
+// waitFor polls capture until it has a record with msg.
+func waitFor(t *testing.T, capture *testutil.CaptureHandler, msg string) testutil.CapturedRecord {
+	t.Helper()
+	for range 200 {
+		if recs := capture.Find(msg); len(recs) > 0 {
+			return recs[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no %q record", msg)
+	return testutil.CapturedRecord{}
+}
+
+func TestHijackedConnection(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	srv := httptest.NewServer(NewLoggerMiddleware(slog.New(capture), WithConnCloseLog())(http.HandlerFunc(echoUpgrade)))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	io.WriteString(conn, "ping")
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(br, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("echo %q, %v", buf, err)
+	}
+
+	upgraded := waitFor(t, capture, "connection upgraded")
+	if v, _ := upgraded.Attr("protocol"); v.String() != "echo" {
+		t.Errorf("protocol = %v", v)
+	}
+	closed := waitFor(t, capture, "connection closed")
+	for key, want := range map[string]int64{"bytes_read": 4, "bytes_written": int64(len(upgradeResponse) + 4)} {
+		if v, _ := closed.Attr(key); v.Int64() != want {
+			t.Errorf("%s = %v, want %d", key, v, want)
+		}
+	}
+	completed := waitFor(t, capture, "request completed")
+	if v, _ := completed.Attr("status"); v.Int64() != http.StatusSwitchingProtocols {
+		t.Errorf("status = %v", v)
+	}
+	if v, _ := completed.Attr("hijacked"); !v.Bool() {
+		t.Error("hijacked not set")
+	}
+}
+
+func TestHijackWithoutCloseLog(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	srv := httptest.NewServer(NewLoggerMiddleware(slog.New(capture))(http.HandlerFunc(echoUpgrade)))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	if _, err := http.ReadResponse(br, nil); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(conn, "ping")
+	io.ReadAll(br)
+
+	waitFor(t, capture, "request completed")
+	if len(capture.Find("connection closed")) != 0 {
+		t.Error("connection closed logged without WithConnCloseLog")
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+)
+
+// httpAttrs returns protocol and connection details of the request and response.
+func httpAttrs(r *http.Request, flushed bool) slog.Attr {
+	attrs := []any{
+		slog.String("proto", r.Proto),
+		slog.Bool("flushed", flushed),
+	}
+	if r.TLS != nil {
+		attrs = append(attrs, slog.Group("tls",
+			slog.String("version", tls.VersionName(r.TLS.Version)),
+			slog.String("cipher", tls.CipherSuiteName(r.TLS.CipherSuite)),
+		))
+	}
+	return slog.Group("http", attrs...)
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestHTTPAttrsTLS(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	srv := httptest.NewTLSServer(NewLoggerMiddleware(slog.New(capture))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("part"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("rest"))
+	})))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	srv.Close() // wait for the handler to return
+
+	recs := capture.Find("request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d completion records", len(recs))
+	}
+	for key, want := range map[string]any{
+		"http.proto":       "HTTP/1.1",
+		"http.flushed":     true,
+		"http.tls.version": tls.VersionName(resp.TLS.Version),
+		"http.tls.cipher":  tls.CipherSuiteName(resp.TLS.CipherSuite),
+		"size":             int64(8),
+	} {
+		if v, ok := recs[0].Attr(key); !ok || v.Any() != want {
+			t.Errorf("%s = %v, want %v", key, v, want)
+		}
+	}
+}
+
+func TestHTTPAttrsPlain(t *testing.T) {
+	_, recs := serve(t, ok, httptest.NewRequest(http.MethodGet, "/", nil))
+	if v, _ := recs[0].Attr("http.flushed"); v.Bool() {
+		t.Error("flushed without Flush")
+	}
+	if _, ok := recs[0].Attr("http.tls"); ok {
+		t.Error("tls group without TLS")
+	}
+}
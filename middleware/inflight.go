@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InflightRequest describes a request that is still being served.
+type InflightRequest struct {
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	RequestID string    `json:"request_id"`
+	Start     time.Time `json:"start"`
+}
+
+// inflight holds the requests being served by all request loggers, keyed by *Request.
+var inflight sync.Map
+
+func (req *Request) trackInflight(reqID string) {
+	inflight.Store(req, InflightRequest{
+		Method:    req.Request.Method,
+		Path:      req.Request.URL.Path,
+		RequestID: reqID,
+		Start:     req.start,
+	})
+}
+
+func (req *Request) untrackInflight() {
+	inflight.Delete(req)
+}
+
+// Inflight returns the requests currently being served, oldest first.
+func Inflight() []InflightRequest {
+	var reqs []InflightRequest
+	inflight.Range(func(_, v any) bool {
+		reqs = append(reqs, v.(InflightRequest))
+		return true
+	})
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Start.Before(reqs[j].Start)
+	})
+	return reqs
+}
+
+// DumpInflight logs the requests currently being served, typically after
+// http.Server.Shutdown has given up waiting for them.
+func DumpInflight(log *slog.Logger) {
+	reqs := Inflight()
+	if len(reqs) == 0 {
+		return
+	}
+
+	log.Warn("requests still in flight", slog.Int("count", len(reqs)))
+	now := time.Now()
+	for _, r := range reqs {
+		log.Warn("request in flight",
+			slog.String("method", r.Method),
+			slog.String("path", r.Path),
+			slog.String("request_id", r.RequestID),
+			slog.Duration("elapsed", now.Sub(r.Start)),
+		)
+	}
+}
+
+// InflightHandler returns an http.Handler listing the requests currently being served as JSON.
+func InflightHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs := Inflight()
+		if reqs == nil {
+			reqs = []InflightRequest{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(reqs)
+	})
+}
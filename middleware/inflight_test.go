@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestInflight(t *testing.T) {
+	release := make(chan struct{})
+	h := NewLoggerMiddleware(slog.New(slog.DiscardHandler), WithRecover())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+	}))
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/reports", "/uploads", "/panic"} {
+		wg.Go(func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+		})
+		// Start the requests in order.
+		for len(Inflight()) < 1 || Inflight()[len(Inflight())-1].Path != path {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	reqs := Inflight()
+	if len(reqs) != 3 || reqs[0].Path != "/reports" || reqs[2].Path != "/panic" || reqs[0].RequestID == "" {
+		t.Fatalf("in flight: %+v", reqs)
+	}
+
+	capture := testutil.NewCaptureHandler()
+	DumpInflight(slog.New(capture))
+	if recs := capture.Find("requests still in flight"); len(recs) != 1 {
+		t.Error("no summary record")
+	} else if v, _ := recs[0].Attr("count"); v.Int64() != 3 {
+		t.Errorf("count = %v", v)
+	}
+	if recs := capture.ByAttr("path", "/uploads"); len(recs) != 1 || recs[0].Level != slog.LevelWarn {
+		t.Errorf("records for /uploads: %v", recs)
+	}
+
+	w := httptest.NewRecorder()
+	InflightHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/inflight", nil))
+	var listed []InflightRequest
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 3 {
+		t.Errorf("listed %s: %v", w.Body, err)
+	}
+
+	close(release)
+	wg.Wait()
+	if reqs := Inflight(); len(reqs) != 0 {
+		t.Errorf("still in flight after completion: %+v", reqs)
+	}
+	w = httptest.NewRecorder()
+	InflightHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/inflight", nil))
+	if got := w.Body.String(); got != "[]\n" {
+		t.Errorf("empty list is %q", got)
+	}
+	capture.Reset()
+	DumpInflight(slog.New(capture))
+	if capture.Len() != 0 {
+		t.Error("DumpInflight logged without requests in flight")
+	}
+}
@@ -2,41 +2,57 @@ package middleware
 
 import (
 	"log/slog"
+	"net"
 	"net/http"
-	"time"
-
-	"github.com/go-chi/chi/v5/middleware"
 )
 
+func NewLoggerMiddleware(log *slog.Logger, opts ...Option) func(next http.Handler) http.Handler {
+	l := NewRequestLogger(log, opts...)
 
-func NewLoggerMiddleware(log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		log = log.With(
-			slog.String("component", "middleware/logger"),
-		)
-
-		log.Info("logger middleware enabled")
+		l.logger().Info("logger middleware enabled")
 
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			entry := log.With(
-				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
-				slog.String("remote_addr", r.RemoteAddr),
-				slog.String("user_agent", r.UserAgent()),
-				slog.String("request_id", middleware.GetReqID(r.Context())),
-			)
-
-			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			req := l.Begin(w, r)
+			if req.Skipped() {
+				if !req.auditing() {
+					next.ServeHTTP(w, req.Request)
+					return
+				}
+				ww, wrapped := wrapResponseWriter(w)
+				defer func() {
+					req.End(ww.Status(), ww.BytesWritten())
+				}()
+				next.ServeHTTP(wrapped, req.Request)
+				return
+			}
+
+			ww, wrapped := wrapResponseWriter(w)
+			ww.onHijack = func(conn net.Conn) net.Conn {
+				return req.hijacked(ww, conn)
+			}
+			req.writer = ww
 
-			t1 := time.Now()
 			defer func() {
-				entry.Info("request completed",
-					slog.Int("status", ww.Status()),
-					slog.Int("size", ww.BytesWritten()),
-					slog.Duration("duration", time.Since(t1)),
-				)
+				var rec any
+				if l.o.recover {
+					rec = recover()
+					if rec != nil && req.Recovered(rec) && ww.Status() == 0 {
+						ww.WriteHeader(http.StatusInternalServerError)
+					}
+				}
+
+				if ww.hijacked {
+					req.End(hijackStatus(ww.Status()), ww.BytesWritten(), slog.Bool("hijacked", true))
+				} else {
+					req.End(ww.Status(), ww.BytesWritten())
+				}
+
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
 			}()
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(wrapped, req.Request)
 		}
 		return http.HandlerFunc(fn)
 	}
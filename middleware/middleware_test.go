@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/testutil"
+)
+
+// serve passes a request for target through the logger middleware with opts
+// around next, and returns the response and the records of the request.
+func serve(t *testing.T, next http.HandlerFunc, r *http.Request, opts ...Option) (*httptest.ResponseRecorder, []testutil.CapturedRecord) {
+	t.Helper()
+	capture := testutil.NewCaptureHandler()
+	h := NewLoggerMiddleware(slog.New(capture), opts...)(next)
+	capture.Reset() // drop "logger middleware enabled"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, capture.Records()
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func TestLogStart(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		path string
+		opts []Option
+		want []string
+	}{
+		{"disabled", "/orders", nil, []string{"request completed"}},
+		{"enabled", "/orders", []Option{WithLogStart()}, []string{"request started", "request completed"}},
+		{"skipped", "/healthz", []Option{WithLogStart(), WithSkipPaths("/healthz")}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, recs := serve(t, ok, httptest.NewRequest(http.MethodGet, tc.path, nil), tc.opts...)
+			if len(recs) != len(tc.want) {
+				t.Fatalf("got %d records, want %d", len(recs), len(tc.want))
+			}
+			var id string
+			for i, rec := range recs {
+				if rec.Message != tc.want[i] {
+					t.Errorf("record %d is %q, want %q", i, rec.Message, tc.want[i])
+				}
+				v, _ := rec.Attr("request_id")
+				if i == 0 {
+					id = v.String()
+				} else if v.String() != id {
+					t.Errorf("request_id %q, want %q of the first record", v, id)
+				}
+			}
+			if len(recs) == 2 && recs[0].Level != slog.LevelDebug {
+				t.Errorf("start record at %v, want DEBUG", recs[0].Level)
+			}
+		})
+	}
+}
+
+func TestRequestScopedLogger(t *testing.T) {
+	var inner *slog.Logger
+	next := func(w http.ResponseWriter, r *http.Request) {
+		inner = logger.FromContext(r.Context())
+		inner.Info("loading order", "order_id", 7)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+	_, recs := serve(t, next, r, WithRequestIDGenerator(func() string { return "req-1" }))
+	if inner == slog.Default() {
+		t.Fatal("handler got the default logger")
+	}
+	if len(recs) != 2 || recs[0].Message != "loading order" {
+		t.Fatalf("got %v", recs)
+	}
+	for key, want := range map[string]any{"method": "GET", "path": "/orders/7", "request_id": "req-1", "order_id": int64(7)} {
+		if v, ok := recs[0].Attr(key); !ok || v.Any() != want {
+			t.Errorf("%s = %v, want %v", key, v, want)
+		}
+	}
+	if _, ok := recs[0].Attr("status"); ok {
+		t.Error("handler record has completion fields")
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	before, after := testutil.NewCaptureHandler(), testutil.NewCaptureHandler()
+	slog.SetDefault(slog.New(before))
+
+	mw := NewLoggerMiddleware(nil)
+	// Building handlers from several goroutines doesn't race.
+	var wg sync.WaitGroup
+	handlers := make([]http.Handler, 4)
+	for i := range handlers {
+		wg.Go(func() {
+			handlers[i] = mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logger.FromContext(r.Context()).Info("loading order")
+			}))
+		})
+	}
+	wg.Wait()
+	if n := len(before.Find("logger middleware enabled")); n != len(handlers) {
+		t.Errorf("got %d enabled records from the default logger, want %d", n, len(handlers))
+	}
+
+	// The default logger set after the router was built logs the requests.
+	slog.SetDefault(slog.New(after))
+	handlers[0].ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+	if n := len(before.Find("request completed")); n != 0 {
+		t.Errorf("got %d completion records from the default logger at setup", n)
+	}
+	for _, msg := range []string{"loading order", "request completed"} {
+		recs := after.Find(msg)
+		if len(recs) != 1 {
+			t.Fatalf("got %d %q records from the current default logger, want 1", len(recs), msg)
+		}
+		if v, _ := recs[0].Attr("path"); v.String() != "/orders/7" {
+			t.Errorf("%q: path %v, want /orders/7", msg, v)
+		}
+	}
+	if v, _ := after.Find("request completed")[0].Attr("component"); v.String() != "middleware/logger" {
+		t.Errorf("component %v, want middleware/logger", v)
+	}
+}
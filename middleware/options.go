@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/netip"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+// Option configures the logger middleware.
+type Option func(*options)
+
+type options struct {
+	logStart  bool
+	recover   bool
+	skipPaths map[string]struct{}
+	preflight PreflightMode
+
+	genRequestID    func() string
+	getRequestID    func(ctx context.Context) string
+	setRequestID    func(ctx context.Context, id string) context.Context
+	requestIDHeader string
+	trustRequestID  bool
+
+	tenant         func(r *http.Request) string
+	extractors     []logger.ContextExtractor
+	attrExtractors []AttrExtractor
+	filters        []Filter
+
+	w3c *W3CLog
+
+	auditLog     *slog.Logger
+	auditSubject func(r *http.Request) string
+	auditFields  []string
+
+	accessLog  *slog.Logger
+	copyErrors bool
+
+	countRequestBody bool
+	logConnClose     bool
+	parseUserAgent   bool
+	pprofLabels      bool
+	geo              *geoCache
+	trustedProxies   []netip.Prefix
+
+	slowThreshold  time.Duration
+	statusLevels   []statusLevel
+	cancelledLevel slog.Level
+	buckets        []time.Duration
+	bucketLabels   []string
+	sampling       bool
+	sampleRate     float64
+	rand           func() float64
+	now            func() time.Time
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		genRequestID: newUUIDv7,
+		getRequestID: logger.RequestIDFromContext,
+		rand:         rand.Float64,
+		now:          time.Now,
+
+		cancelledLevel: slog.LevelInfo,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithLogStart makes the middleware emit a Debug "request started" record
+// before the request is passed to the next handler.
+func WithLogStart() Option {
+	return func(o *options) {
+		o.logStart = true
+	}
+}
+
+// WithSkipPaths disables logging for requests whose path matches one of paths exactly.
+func WithSkipPaths(paths ...string) Option {
+	return func(o *options) {
+		if o.skipPaths == nil {
+			o.skipPaths = make(map[string]struct{}, len(paths))
+		}
+		for _, p := range paths {
+			o.skipPaths[p] = struct{}{}
+		}
+	}
+}
+
+// WithSlowThreshold logs completion records of requests that took longer than d
+// at Warn level with a slow attribute.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = d
+	}
+}
+
+type statusLevel struct {
+	min   int
+	level slog.Level
+}
+
+// WithStatusLevel logs completion records of responses with status min or above
+// at level. When several minimums match, the highest one wins.
+func WithStatusLevel(min int, level slog.Level) Option {
+	return func(o *options) {
+		o.statusLevels = append(o.statusLevels, statusLevel{min: min, level: level})
+	}
+}
+
+func (o *options) statusLevel(status int) slog.Level {
+	level, best := slog.LevelInfo, 0
+	for _, sl := range o.statusLevels {
+		if status >= sl.min && sl.min >= best {
+			level, best = sl.level, sl.min
+		}
+	}
+	return level
+}
+
+// WithAccessLogger sends the start and completion records of requests to l
+// instead of the middleware's logger, which keeps receiving panics and the
+// records of handlers using the request-scoped logger.
+func WithAccessLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.accessLog = l.With(slog.String("component", "middleware/logger"))
+	}
+}
+
+// WithErrorCopy also sends completion records at Error level or above to the
+// middleware's logger when an access logger is set.
+func WithErrorCopy() Option {
+	return func(o *options) {
+		o.copyErrors = true
+	}
+}
+
+func (o *options) skip(r *http.Request) bool {
+	if o.preflight == PreflightSkip && isPreflight(r) {
+		return true
+	}
+	_, ok := o.skipPaths[r.URL.Path]
+	return ok
+}
+
+// WithContextExtractors adds the attributes returned by extractors for the
+// request context to every record logged for the request.
+func WithContextExtractors(extractors ...logger.ContextExtractor) Option {
+	return func(o *options) {
+		o.extractors = append(o.extractors, extractors...)
+	}
+}
+
+// WithClock replaces the clock used to measure request and connection durations,
+// e.g. with a fake clock in tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
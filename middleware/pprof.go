@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+
+	logger "github.com/corray333/go-log"
+)
+
+// WithPprofLabels sets runtime/pprof labels for request_id, method and route
+// while a request is served, so CPU profiles can be matched with its records.
+// The route is r.Pattern, or a route attribute passed to RequestLogger.Begin.
+func WithPprofLabels() Option {
+	return func(o *options) {
+		o.pprofLabels = true
+	}
+}
+
+// pprofLabels labels the request goroutine and returns the request with the
+// labeled context and the context to restore the labels from when it ends.
+func pprofLabels(r *http.Request, reqID string, attrs []slog.Attr) (*http.Request, context.Context) {
+	labels := []slog.Attr{
+		slog.String("request_id", reqID),
+		slog.String("method", r.Method),
+	}
+	route := r.Pattern
+	for _, a := range attrs {
+		if a.Key == "route" {
+			route = a.Value.String()
+		}
+	}
+	if route != "" {
+		labels = append(labels, slog.String("route", route))
+	}
+
+	parent := r.Context()
+	return r.WithContext(logger.WithPprofLabels(parent, labels...)), parent
+}
+
+// restorePprofLabels resets the goroutine labels set by pprofLabels.
+func (req *Request) restorePprofLabels() {
+	if req.pprofParent != nil {
+		pprof.SetGoroutineLabels(req.pprofParent)
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestPprofLabels(t *testing.T) {
+	labels := map[string]string{}
+	next := func(w http.ResponseWriter, r *http.Request) {
+		pprof.ForLabels(r.Context(), func(k, v string) bool {
+			labels[k] = v
+			return true
+		})
+	}
+
+	capture := testutil.NewCaptureHandler()
+	mux := http.NewServeMux()
+	mux.Handle("GET /orders/{id}", NewLoggerMiddleware(slog.New(capture), WithPprofLabels(), WithTrustRequestID())(http.HandlerFunc(next)))
+
+	r := httptest.NewRequest("GET", "/orders/7", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := map[string]string{"request_id": "req-1", "method": "GET", "route": "GET /orders/{id}"}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, labels[k], v)
+		}
+	}
+	if len(labels) != len(want) {
+		t.Errorf("labels = %v, want only %v", labels, want)
+	}
+}
+
+func TestPprofLabelsDisabled(t *testing.T) {
+	var n int
+	_, _ = serve(t, func(w http.ResponseWriter, r *http.Request) {
+		pprof.ForLabels(r.Context(), func(string, string) bool {
+			n++
+			return true
+		})
+	}, httptest.NewRequest("GET", "/", nil))
+	if n != 0 {
+		t.Errorf("%d labels set without WithPprofLabels", n)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import "net/http"
+
+// PreflightMode controls how CORS preflight requests are logged.
+type PreflightMode int
+
+const (
+	// PreflightNormal logs preflight requests like any other request.
+	PreflightNormal PreflightMode = iota
+	// PreflightSkip doesn't log preflight requests.
+	PreflightSkip
+	// PreflightDebug logs the completion records of preflight requests at Debug level.
+	PreflightDebug
+)
+
+// WithPreflightHandling sets how CORS preflight requests are logged. Only OPTIONS
+// requests carrying both Origin and Access-Control-Request-Method headers are
+// considered preflights; other OPTIONS requests are logged normally.
+func WithPreflightHandling(mode PreflightMode) Option {
+	return func(o *options) {
+		o.preflight = mode
+	}
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
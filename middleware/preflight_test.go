@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreflightHandling(t *testing.T) {
+	preflight := func() *http.Request {
+		r := httptest.NewRequest(http.MethodOptions, "/api", nil)
+		r.Header.Set("Origin", "https://example.com")
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		return r
+	}
+	plain := func() *http.Request {
+		return httptest.NewRequest(http.MethodOptions, "/api", nil)
+	}
+	originOnly := func() *http.Request {
+		r := plain()
+		r.Header.Set("Origin", "https://example.com")
+		return r
+	}
+
+	for _, tc := range []struct {
+		name    string
+		mode    PreflightMode
+		r       *http.Request
+		logged  bool
+		wantLvl slog.Level
+	}{
+		{"normal preflight", PreflightNormal, preflight(), true, slog.LevelInfo},
+		{"normal plain", PreflightNormal, plain(), true, slog.LevelInfo},
+		{"skip preflight", PreflightSkip, preflight(), false, 0},
+		{"skip plain", PreflightSkip, plain(), true, slog.LevelInfo},
+		{"skip origin only", PreflightSkip, originOnly(), true, slog.LevelInfo},
+		{"debug preflight", PreflightDebug, preflight(), true, slog.LevelDebug},
+		{"debug plain", PreflightDebug, plain(), true, slog.LevelInfo},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, recs := serve(t, ok, tc.r, WithPreflightHandling(tc.mode))
+			if !tc.logged {
+				if len(recs) != 0 {
+					t.Fatalf("got %d records, want none", len(recs))
+				}
+				return
+			}
+			if len(recs) != 1 {
+				t.Fatalf("got %d records, want 1", len(recs))
+			}
+			if recs[0].Level != tc.wantLvl {
+				t.Errorf("level = %v, want %v", recs[0].Level, tc.wantLvl)
+			}
+		})
+	}
+}
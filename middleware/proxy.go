@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// WithTrustedProxies makes the middleware take the client address of requests
+// from proxies in the given networks from their X-Forwarded-For header, for
+// WithGeoIP. The header is read from the right, skipping the addresses of
+// trusted proxies, so that clients can't spoof their address by sending the
+// header themselves. X-Real-Ip is used when X-Forwarded-For is missing.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(o *options) {
+		o.trustedProxies = append(o.trustedProxies, prefixes...)
+	}
+}
+
+// clientIP returns the address of the client of r, resolved through trusted
+// proxies.
+func (o *options) clientIP(r *http.Request) string {
+	host := clientIP(r)
+	if !o.trusted(host) {
+		return host
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		if real := strings.TrimSpace(r.Header.Get("X-Real-Ip")); real != "" {
+			return real
+		}
+		return host
+	}
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if !o.trusted(hop) {
+			return hop
+		}
+		host = hop
+	}
+	// All hops are trusted, so the leftmost one is the client.
+	return host
+}
+
+// trusted reports whether host is the address of a trusted proxy.
+func (o *options) trusted(host string) bool {
+	if len(o.trustedProxies) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range o.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
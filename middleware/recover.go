@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	logger "github.com/corray333/go-log"
+)
+
+// WithRecover makes the middleware recover panics from the next handler,
+// log them at Error level with a stack trace and respond with 500.
+// http.ErrAbortHandler is re-panicked after the request is logged.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recover = true
+	}
+}
+
+// Recovers reports whether panics from the next handler are recovered, as set
+// with WithRecover. Framework adapters only call recover when it is true, and
+// otherwise let the panic continue once the request is logged.
+func (l *RequestLogger) Recovers() bool {
+	return l.o.recover
+}
+
+// Recovered logs rec, a panic recovered from the next handler, at Error level
+// with a stack trace. It reports false for http.ErrAbortHandler, which isn't
+// logged and must be re-panicked once the request is logged.
+func (req *Request) Recovered(rec any) bool {
+	if rec == http.ErrAbortHandler {
+		return false
+	}
+	log := req.Logger
+	if log == nil {
+		log = req.log
+	}
+	log.Error("panic recovered",
+		slog.Any("panic", rec),
+		slog.String("stack", logger.PanicStack()),
+	)
+	return true
+}
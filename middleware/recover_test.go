@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestRecover(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		code    int
+		logged  bool
+		escapes bool
+	}{
+		{"panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, true, false},
+		{"after write", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}, http.StatusAccepted, true, false},
+		{"abort", func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) }, 0, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			capture := testutil.NewCaptureHandler()
+			l := NewRequestLogger(slog.New(capture), WithRecover())
+			if !l.Recovers() {
+				t.Error("Recovers() = false with WithRecover")
+			}
+			h := NewLoggerMiddleware(slog.New(capture), WithRecover())(tc.handler)
+
+			w := httptest.NewRecorder()
+			escaped := func() (rec any) {
+				defer func() { rec = recover() }()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				return nil
+			}()
+			if (escaped != nil) != tc.escapes {
+				t.Fatalf("panic escaped: %v", escaped)
+			}
+			if !tc.escapes && w.Code != tc.code {
+				t.Errorf("status %d, want %d", w.Code, tc.code)
+			}
+			recs := capture.Find("panic recovered")
+			if (len(recs) == 1) != tc.logged {
+				t.Fatalf("got %d panic records", len(recs))
+			}
+			if tc.logged {
+				if v, _ := recs[0].Attr("stack"); v.String() == "" {
+					t.Error("no stack")
+				}
+			}
+			completed := capture.Find("request completed")
+			if len(completed) != 1 {
+				t.Fatalf("got %d completion records", len(completed))
+			}
+			if v, _ := completed[0].Attr("status"); !tc.escapes && v.Int64() != int64(tc.code) {
+				t.Errorf("logged status %v, want %d", v, tc.code)
+			}
+		})
+	}
+	if NewRequestLogger(nil).Recovers() {
+		t.Error("Recovers() = true without WithRecover")
+	}
+}
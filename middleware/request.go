@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+// RequestLogger logs requests independently of the HTTP framework serving them.
+// NewLoggerMiddleware is built on it, and framework adapters use it to produce
+// the same records with the same options.
+type RequestLogger struct {
+	log *slog.Logger
+	o   *options
+}
+
+// NewRequestLogger returns a RequestLogger logging to log. A nil log means the
+// default logger at the time of each request, so slog.SetDefault may be called
+// after the router was built.
+func NewRequestLogger(log *slog.Logger, opts ...Option) *RequestLogger {
+	l := &RequestLogger{o: newOptions(opts)}
+	if log != nil {
+		l.log = withComponent(log)
+	}
+	return l
+}
+
+func withComponent(log *slog.Logger) *slog.Logger {
+	return log.With(slog.String("component", "middleware/logger"))
+}
+
+// logger returns the logger to use for the current request.
+func (l *RequestLogger) logger() *slog.Logger {
+	if l.log != nil {
+		return l.log
+	}
+	return withComponent(slog.Default())
+}
+
+// Request is the logging state of a single request.
+type Request struct {
+	// Request is the request to pass downstream. Its context carries the request ID
+	// and the request-scoped logger.
+	Request *http.Request
+	// Logger carries the request fields and is nil when the request is skipped.
+	Logger *slog.Logger
+
+	access    *slog.Logger
+	log       *slog.Logger
+	l         *RequestLogger
+	start     time.Time
+	body      *countingBody
+	auditBody *limitedBuffer
+	writer    *responseWriter
+
+	pprofParent context.Context
+}
+
+// Begin starts logging r. The attrs are added to all records of the request.
+// The response header is only touched to echo the request ID.
+func (l *RequestLogger) Begin(w http.ResponseWriter, r *http.Request, attrs ...slog.Attr) *Request {
+	o := l.o
+
+	var reqID string
+	reqID, r = o.requestID(w, r)
+	r = o.withTenant(r)
+
+	req := &Request{Request: r, l: l, log: l.logger()}
+	if o.skip(r) {
+		// Skipped requests are still audited.
+		req.auditBody = o.auditBody(r)
+		return req
+	}
+
+	fields := []any{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("user_agent", r.UserAgent()),
+		slog.String("request_id", reqID),
+	}
+	for _, a := range attrs {
+		fields = append(fields, a)
+	}
+	fields = append(fields, o.extractAttrs(req.log, r)...)
+
+	req.Logger = req.log.With(fields...)
+	req.access = req.Logger
+	if o.accessLog != nil {
+		req.access = o.accessLog.With(fields...)
+	}
+
+	r = r.WithContext(logger.IntoContext(r.Context(), req.Logger))
+	if o.pprofLabels {
+		r, req.pprofParent = pprofLabels(r, reqID, attrs)
+	}
+
+	if o.logStart {
+		req.access.DebugContext(r.Context(), "request started")
+	}
+
+	req.body = o.requestBody(r)
+	req.auditBody = o.auditBody(r)
+	req.Request = r
+	req.start = o.now()
+	req.trackInflight(reqID)
+	return req
+}
+
+// Skipped reports whether the request is excluded from logging. End must still
+// be called for skipped requests, which are audited with WithAuditLog.
+func (req *Request) Skipped() bool {
+	return req.Logger == nil
+}
+
+// auditing reports whether End writes an audit record for the request.
+func (req *Request) auditing() bool {
+	return req.l.o.auditLog != nil && isMutating(req.Request.Method)
+}
+
+// End logs the completion record of the request with the given response status
+// and size. The attrs are added to the completion record only.
+func (req *Request) End(status, size int, attrs ...slog.Attr) {
+	req.audit(status)
+	if req.Skipped() {
+		return
+	}
+	req.untrackInflight()
+	req.restorePprofLabels()
+	o, r := req.l.o, req.Request
+
+	elapsed := o.now().Sub(req.start)
+	if o.w3c != nil {
+		if err := o.w3c.Log(r, status, size, elapsed, req.start.Add(elapsed)); err != nil {
+			req.log.Warn("failed to write W3C access log", slog.String("error", err.Error()))
+		}
+	}
+	if o.filtered(r, status, elapsed) {
+		return
+	}
+
+	var flushed bool
+	if req.writer != nil {
+		flushed = req.writer.flushed
+	}
+
+	level := o.statusLevel(status)
+	if o.preflight == PreflightDebug && isPreflight(r) {
+		level = slog.LevelDebug
+	}
+	attrs = append([]slog.Attr{
+		slog.Int("status", status),
+		slog.Int("size", size),
+		slog.Duration("duration", elapsed),
+		httpAttrs(r, flushed),
+	}, attrs...)
+	attrs = append(attrs, requestAttrs(r, req.body)...)
+
+	ctxAttrs, level := o.contextAttrs(r.Context(), level)
+	attrs = append(attrs, ctxAttrs...)
+	if o.parseUserAgent {
+		attrs = append(attrs, parseUserAgent(r.UserAgent()).attr())
+	}
+	if o.geo != nil {
+		if geo, ok := o.geo.lookup(req.log, o.clientIP(r)); ok {
+			attrs = append(attrs, geo.attr())
+		}
+	}
+
+	if o.buckets != nil {
+		attrs = append(attrs, o.durationBucket(elapsed))
+	}
+
+	slow := o.slowThreshold > 0 && elapsed > o.slowThreshold
+	if slow {
+		level = max(level, slog.LevelWarn)
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+
+	keep, rate := o.sampled(status, slow)
+	if rate > 0 {
+		attrs = append(attrs, slog.Float64("sample_rate", rate))
+	}
+	if keep {
+		req.access.LogAttrs(r.Context(), level, "request completed", attrs...)
+		if o.copyErrors && req.access != req.Logger && level >= slog.LevelError {
+			req.Logger.LogAttrs(r.Context(), level, "request completed", attrs...)
+		}
+	}
+}
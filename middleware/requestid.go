@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+const defaultRequestIDHeader = "X-Request-Id"
+
+// WithRequestIDGenerator replaces the function used to generate request IDs
+// for requests that don't carry one yet. The default generates UUIDv7 strings.
+func WithRequestIDGenerator(gen func() string) Option {
+	return func(o *options) {
+		o.genRequestID = gen
+	}
+}
+
+// WithRequestIDHeader echoes the request ID back to the client in the given
+// response header. The same header is read when incoming IDs are trusted.
+func WithRequestIDHeader(name string) Option {
+	return func(o *options) {
+		o.requestIDHeader = name
+	}
+}
+
+// WithTrustRequestID makes the middleware accept a request ID supplied by the
+// client in the request ID header instead of generating a new one.
+func WithTrustRequestID() Option {
+	return func(o *options) {
+		o.trustRequestID = true
+	}
+}
+
+// WithRequestIDContext replaces how request IDs are read from and stored in
+// request contexts, so that IDs set by a router's own request ID middleware are
+// picked up and IDs generated here are visible to it downstream. IDs are always
+// stored for logger.RequestIDFromContext as well.
+func WithRequestIDContext(get func(ctx context.Context) string, set func(ctx context.Context, id string) context.Context) Option {
+	return func(o *options) {
+		o.getRequestID = get
+		o.setRequestID = set
+	}
+}
+
+// requestID returns the request ID for r, storing a new one in the context when none is present.
+func (o *options) requestID(w http.ResponseWriter, r *http.Request) (string, *http.Request) {
+	ctx := r.Context()
+	id := o.getRequestID(ctx)
+	if id == "" {
+		if o.trustRequestID {
+			header := o.requestIDHeader
+			if header == "" {
+				header = defaultRequestIDHeader
+			}
+			id = r.Header.Get(header)
+		}
+		if id == "" {
+			id = o.genRequestID()
+		}
+		if o.setRequestID != nil {
+			ctx = o.setRequestID(ctx, id)
+		}
+	}
+	if logger.RequestIDFromContext(ctx) != id {
+		ctx = logger.ContextWithRequestID(ctx, id)
+	}
+	if ctx != r.Context() {
+		r = r.WithContext(ctx)
+	}
+
+	if o.requestIDHeader != "" {
+		w.Header().Set(o.requestIDHeader, id)
+	}
+	return id, r
+}
+
+// newUUIDv7 returns a time-ordered UUID as described in RFC 9562.
+func newUUIDv7() string {
+	var u [16]byte
+	_, _ = rand.Read(u[6:])
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ts[2:])
+
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
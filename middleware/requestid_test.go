@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	logger "github.com/corray333/go-log"
+)
+
+type routerKey struct{}
+
+func TestRequestID(t *testing.T) {
+	uuidV7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	routerID := WithRequestIDContext(
+		func(ctx context.Context) string { id, _ := ctx.Value(routerKey{}).(string); return id },
+		func(ctx context.Context, id string) context.Context { return context.WithValue(ctx, routerKey{}, id) },
+	)
+	for _, tc := range []struct {
+		name    string
+		opts    []Option
+		header  string // incoming X-Request-Id
+		present string // ID set by a router middleware
+		want    string // empty for a generated ID
+		echoed  bool
+		router  bool
+	}{
+		{name: "absent"},
+		{name: "generator", opts: []Option{WithRequestIDGenerator(func() string { return "gen-1" })}, want: "gen-1"},
+		{name: "client untrusted", opts: []Option{WithRequestIDHeader("X-Request-Id")}, header: "client-1", echoed: true},
+		{name: "client trusted", opts: []Option{WithRequestIDHeader("X-Request-Id"), WithTrustRequestID()}, header: "client-1", want: "client-1", echoed: true},
+		{name: "trusted default header", opts: []Option{WithTrustRequestID()}, header: "client-1", want: "client-1"},
+		{name: "stored for router", opts: []Option{routerID}, router: true},
+		{name: "present", opts: []Option{routerID, WithTrustRequestID()}, header: "client-1", present: "router-1", want: "router-1", router: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("X-Request-Id", tc.header)
+			}
+			if tc.present != "" {
+				r = r.WithContext(context.WithValue(r.Context(), routerKey{}, tc.present))
+			}
+			var seen, seenRouter string
+			next := func(w http.ResponseWriter, r *http.Request) {
+				seen = logger.RequestIDFromContext(r.Context())
+				seenRouter, _ = r.Context().Value(routerKey{}).(string)
+			}
+			w, recs := serve(t, next, r, tc.opts...)
+
+			v, _ := recs[len(recs)-1].Attr("request_id")
+			id := v.String()
+			if tc.want == "" {
+				if !uuidV7.MatchString(id) {
+					t.Errorf("generated request_id %q isn't a UUIDv7", id)
+				}
+			} else if id != tc.want {
+				t.Errorf("request_id %q, want %q", id, tc.want)
+			}
+			if seen != id {
+				t.Errorf("next handler saw %q, want %q", seen, id)
+			}
+			if tc.router && seenRouter != id {
+				t.Errorf("router context has %q, want %q", seenRouter, id)
+			}
+			echoed := w.Header().Get("X-Request-Id")
+			if tc.echoed && echoed != id || !tc.echoed && echoed != "" {
+				t.Errorf("response header %q", echoed)
+			}
+		})
+	}
+}
+
+func TestNewUUIDv7Ordered(t *testing.T) {
+	prev := newUUIDv7()
+	for range 100 {
+		id := newUUIDv7()
+		if id[:13] < prev[:13] {
+			t.Fatalf("%s sorts before %s", id, prev)
+		}
+		if id == prev {
+			t.Fatalf("duplicate %s", id)
+		}
+		prev = id
+	}
+}
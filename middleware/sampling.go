@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WithAccessLogSampling keeps only the given fraction of completion records for
+// successful requests. Requests with status 400 or above and slow requests are
+// always logged. Sampled records carry a sample_rate attribute so that counts can
+// be re-weighted downstream.
+//
+// A rate of 0 drops all records of successful, fast requests, and a rate of 1
+// keeps them all without a sample_rate attribute. It panics if rate isn't
+// between 0 and 1.
+func WithAccessLogSampling(rate float64) Option {
+	if !(rate >= 0 && rate <= 1) {
+		panic(fmt.Sprintf("middleware: sample rate %v isn't between 0 and 1", rate))
+	}
+	return func(o *options) {
+		o.sampling = true
+		o.sampleRate = rate
+	}
+}
+
+// WithSamplingRand replaces the source of random numbers in [0, 1) used for sampling.
+func WithSamplingRand(fn func() float64) Option {
+	return func(o *options) {
+		o.rand = fn
+	}
+}
+
+// sampled reports whether the completion record should be kept and the sample
+// rate to attach to it, which is zero when the record isn't subject to sampling.
+func (o *options) sampled(status int, slow bool) (bool, float64) {
+	if !o.sampling || o.sampleRate == 1 || slow || status >= http.StatusBadRequest {
+		return true, 0
+	}
+	return o.rand() < o.sampleRate, o.sampleRate
+}
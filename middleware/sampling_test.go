@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccessLogSampling(t *testing.T) {
+	// A clock advancing 1s per call makes every request take 1s.
+	clock := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tick := func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	for _, tc := range []struct {
+		name   string
+		code   int
+		rand   float64
+		opts   []Option
+		logged bool
+		rate   float64
+	}{
+		{"sampled in", http.StatusOK, 0.05, nil, true, 0.1},
+		{"sampled out", http.StatusOK, 0.5, nil, false, 0},
+		{"client error", http.StatusNotFound, 0.5, nil, true, 0},
+		{"server error", http.StatusInternalServerError, 0.5, nil, true, 0},
+		{"slow", http.StatusOK, 0.5, []Option{WithSlowThreshold(500 * time.Millisecond)}, true, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{
+				WithAccessLogSampling(0.1),
+				WithSamplingRand(func() float64 { return tc.rand }),
+				WithClock(tick),
+			}, tc.opts...)
+			next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(tc.code) }
+			w, recs := serve(t, next, httptest.NewRequest(http.MethodGet, "/", nil), opts...)
+			if w.Code != tc.code {
+				t.Errorf("response status %d", w.Code)
+			}
+			if (len(recs) == 1) != tc.logged {
+				t.Fatalf("got %d records, want logged %v", len(recs), tc.logged)
+			}
+			if !tc.logged {
+				return
+			}
+			v, ok := recs[0].Attr("sample_rate")
+			if ok != (tc.rate > 0) || ok && v.Float64() != tc.rate {
+				t.Errorf("sample_rate = %v, want %v", v, tc.rate)
+			}
+		})
+	}
+}
+
+func TestAccessLogSamplingDisabled(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithAccessLogSampling(1)}} {
+		_, recs := serve(t, ok, httptest.NewRequest(http.MethodGet, "/", nil),
+			append(opts, WithSamplingRand(func() float64 { return 0.99 }))...)
+		if len(recs) != 1 {
+			t.Fatalf("%d options: got %d records", len(opts), len(recs))
+		}
+		if _, ok := recs[0].Attr("sample_rate"); ok {
+			t.Errorf("%d options: sample_rate added", len(opts))
+		}
+	}
+}
+
+func TestAccessLogSamplingZero(t *testing.T) {
+	for _, tc := range []struct {
+		code   int
+		logged bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusInternalServerError, true},
+	} {
+		next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(tc.code) }
+		_, recs := serve(t, next, httptest.NewRequest(http.MethodGet, "/", nil),
+			WithAccessLogSampling(0), WithSamplingRand(func() float64 { return 0 }))
+		if (len(recs) == 1) != tc.logged {
+			t.Errorf("status %d: got %d records, want logged %v", tc.code, len(recs), tc.logged)
+		}
+	}
+}
+
+func TestAccessLogSamplingInvalidRate(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("rate %v didn't panic", rate)
+				}
+			}()
+			WithAccessLogSampling(rate)
+		}()
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	logger "github.com/corray333/go-log"
+)
+
+// WithTenant stores the tenant returned by fn, for example from a header or a
+// JWT claim, in the request context with logger.ContextWithTenant. Handlers with
+// HandlerOptions.Tenant then add it to the records logged with that context,
+// guarding against unexpected tenants. An empty tenant is not stored.
+func WithTenant(fn func(r *http.Request) string) Option {
+	return func(o *options) {
+		o.tenant = fn
+	}
+}
+
+// withTenant returns r with its tenant in the context.
+func (o *options) withTenant(r *http.Request) *http.Request {
+	if o.tenant == nil {
+		return r
+	}
+	tenant := o.tenant(r)
+	if tenant == "" {
+		return r
+	}
+	return r.WithContext(logger.ContextWithTenant(r.Context(), tenant))
+}
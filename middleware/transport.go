@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+// TransportOption configures the logging transport.
+type TransportOption func(*transport)
+
+// WithTransportErrorStatus logs responses with status min or above at Error level.
+// It defaults to 500.
+func WithTransportErrorStatus(min int) TransportOption {
+	return func(t *transport) {
+		t.errorStatus = min
+	}
+}
+
+// WithTransportRequestIDHeader sets the header the request ID from the request
+// context is propagated in. It defaults to "X-Request-Id".
+func WithTransportRequestIDHeader(name string) TransportOption {
+	return func(t *transport) {
+		t.requestIDHeader = name
+	}
+}
+
+// WithCaptureBodies logs up to limit bytes of request and response bodies.
+// Bodies are neither read nor buffered unless this option is set. They are
+// captured as they are read, so with this option requests are logged once the
+// caller has read the response body to the end or closed it. The bodies of 101
+// Switching Protocols responses are left alone.
+func WithCaptureBodies(limit int) TransportOption {
+	return func(t *transport) {
+		t.captureLimit = limit
+	}
+}
+
+type transport struct {
+	base            http.RoundTripper
+	log             *slog.Logger
+	errorStatus     int
+	requestIDHeader string
+	captureLimit    int
+}
+
+// NewTransport returns an http.RoundTripper logging every outbound request made
+// through base, or http.DefaultTransport when base is nil. The request ID from
+// the request context is propagated to the server in a header, which keeps it
+// stable across retries of the same request.
+func NewTransport(base http.RoundTripper, log *slog.Logger, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &transport{
+		base:            base,
+		log:             log.With(slog.String("component", "http/client")),
+		errorStatus:     http.StatusInternalServerError,
+		requestIDHeader: defaultRequestIDHeader,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+
+	// RoundTrip must not modify the caller's request, so work on a copy.
+	id := logger.RequestIDFromContext(ctx)
+	if id != "" && r.Header.Get(t.requestIDHeader) == "" {
+		r = r.Clone(ctx)
+		r.Header.Set(t.requestIDHeader, id)
+	}
+
+	// The base transport may still be reading the request body when it
+	// returns, so it is captured in a captureBuffer.
+	var reqBody *captureBuffer
+	if t.captureLimit > 0 && r.Body != nil && r.Body != http.NoBody {
+		reqBody = &captureBuffer{buf: limitedBuffer{limit: t.captureLimit}}
+		body := r.Body
+		r = r.Clone(ctx)
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(body, reqBody), body}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("host", r.URL.Host),
+		slog.String("path", r.URL.Path),
+	}
+	if id := r.Header.Get(t.requestIDHeader); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(r)
+	attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+
+	if err != nil {
+		if reqBody != nil {
+			attrs = append(attrs, slog.String("request_body", reqBody.String()))
+		}
+		attrs = append(attrs, slog.String("error", err.Error()))
+		t.log.LogAttrs(ctx, slog.LevelError, "outbound request failed", attrs...)
+		return resp, err
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= t.errorStatus {
+		level = slog.LevelError
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+	if t.captureLimit > 0 && resp.Body != nil && resp.Body != http.NoBody &&
+		resp.StatusCode != http.StatusSwitchingProtocols {
+		// Streaming bodies may never end, so the body is captured as the
+		// caller reads it, and the request logged when it is done with it.
+		body := &loggedBody{ReadCloser: resp.Body, buf: captureBuffer{buf: limitedBuffer{limit: t.captureLimit}}}
+		body.done = func(readErr error) {
+			if reqBody != nil {
+				attrs = append(attrs, slog.String("request_body", reqBody.String()))
+			}
+			attrs = append(attrs, slog.String("response_body", body.buf.String()))
+			if readErr != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", readErr.Error()))
+			}
+			t.log.LogAttrs(ctx, level, "outbound request completed", attrs...)
+		}
+		resp.Body = body
+		return resp, nil
+	}
+
+	if reqBody != nil {
+		attrs = append(attrs, slog.String("request_body", reqBody.String()))
+	}
+	t.log.LogAttrs(ctx, level, "outbound request completed", attrs...)
+	return resp, nil
+}
+
+// loggedBody captures the beginning of a response body as it is read, and
+// calls done once at EOF, at the first read error or when closed, whichever
+// comes first.
+type loggedBody struct {
+	io.ReadCloser
+	buf  captureBuffer
+	once sync.Once
+	done func(readErr error)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	switch {
+	case err == io.EOF:
+		b.once.Do(func() { b.done(nil) })
+	case err != nil:
+		b.once.Do(func() { b.done(err) })
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(nil) })
+	return err
+}
+
+// captureBuffer is a limitedBuffer safe for concurrent use.
+type captureBuffer struct {
+	mu  sync.Mutex
+	buf limitedBuffer
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *captureBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if rem := b.limit - b.Len(); rem > 0 {
+		b.Buffer.Write(p[:min(len(p), rem)])
+	}
+	return len(p), nil
+}
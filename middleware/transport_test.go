@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/corray333/go-log"
+	"github.com/corray333/go-log/testutil"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Request-Id") != "req-1" {
+			t.Errorf("request ID header = %q, want req-1", r.Header.Get("X-Request-Id"))
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	h := testutil.NewCaptureHandler()
+	client := &http.Client{Transport: NewTransport(nil, slog.New(h))}
+	r, _ := http.NewRequestWithContext(logger.ContextWithRequestID(context.Background(), "req-1"), http.MethodGet, srv.URL+"/items", nil)
+	resp, err := client.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	recs := h.Find("outbound request completed")
+	if len(recs) != 1 || recs[0].Level != slog.LevelError {
+		t.Fatalf("got %v, want one Error record", recs)
+	}
+	for key, want := range map[string]any{"method": "GET", "path": "/items", "request_id": "req-1", "status": int64(503)} {
+		if v, ok := recs[0].Attr(key); !ok || v.Any() != want {
+			t.Errorf("%s = %v, want %v", key, v, want)
+		}
+	}
+}
+
+func TestTransportCaptureBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		io.WriteString(w, "response body that is long")
+	}))
+	defer srv.Close()
+
+	h := testutil.NewCaptureHandler()
+	client := &http.Client{Transport: NewTransport(nil, slog.New(h), WithCaptureBodies(13))}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("request body that is long"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != 0 {
+		t.Error("request logged before the response body was read")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "response body that is long" {
+		t.Errorf("caller read %q, want the whole body", body)
+	}
+
+	recs := h.Find("outbound request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d records, want 1", len(recs))
+	}
+	for key, want := range map[string]string{"request_body": "request body ", "response_body": "response body"} {
+		if v, _ := recs[0].Attr(key); v.String() != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+}
+
+func TestTransportCaptureStreamingBody(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "event: 1\n")
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	h := testutil.NewCaptureHandler()
+	client := &http.Client{Transport: NewTransport(nil, slog.New(h), WithCaptureBodies(1024))}
+	done := make(chan *http.Response)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- resp
+	}()
+	var resp *http.Response
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RoundTrip blocked on the streaming body")
+	}
+	if resp == nil {
+		return
+	}
+
+	line := make([]byte, len("event: 1\n"))
+	if _, err := io.ReadFull(resp.Body, line); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	recs := h.Find("outbound request completed")
+	if len(recs) != 1 {
+		t.Fatalf("got %d records after Close, want 1", len(recs))
+	}
+	if v, _ := recs[0].Attr("response_body"); v.String() != "event: 1\n" {
+		t.Errorf("response_body = %q, want the part read", v)
+	}
+}
+
+// upgradeTransport returns 101 responses with a writable body, like
+// http.Transport does for protocol upgrades.
+type upgradeTransport struct{ body io.ReadWriteCloser }
+
+func (t upgradeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusSwitchingProtocols, Body: t.body, Request: r}, nil
+}
+
+type rwc struct {
+	io.Reader
+	io.Writer
+}
+
+func (rwc) Close() error { return nil }
+
+func TestTransportUpgrade(t *testing.T) {
+	h := testutil.NewCaptureHandler()
+	body := rwc{strings.NewReader("frames"), io.Discard}
+	rt := NewTransport(upgradeTransport{body}, slog.New(h), WithCaptureBodies(1024))
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Body.(io.Writer); !ok {
+		t.Error("the body of the 101 response is no longer writable")
+	}
+	if h.Len() != 1 {
+		t.Errorf("got %d records, want the upgrade logged right away", h.Len())
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// WithUserAgentParsing adds a "ua" group with the browser, its version, the
+// operating system, the device type and whether the client is a bot to
+// completion records. The raw user_agent attribute is kept.
+func WithUserAgentParsing() Option {
+	return func(o *options) {
+		o.parseUserAgent = true
+	}
+}
+
+type userAgent struct {
+	browser string
+	version string
+	os      string
+	device  string
+	bot     bool
+}
+
+func (ua userAgent) attr() slog.Attr {
+	return slog.Group("ua",
+		slog.String("browser", ua.browser),
+		slog.String("version", ua.version),
+		slog.String("os", ua.os),
+		slog.String("device", ua.device),
+		slog.Bool("is_bot", ua.bot),
+	)
+}
+
+type uaMatcher struct {
+	token string
+	name  string
+}
+
+// Order matters: many browsers mention the engines of the others.
+var (
+	botMatchers = []uaMatcher{
+		{"Googlebot/", "Googlebot"},
+		{"bingbot/", "Bingbot"},
+		{"YandexBot/", "YandexBot"},
+		{"DuckDuckBot/", "DuckDuckBot"},
+		{"Baiduspider/", "Baiduspider"},
+		{"facebookexternalhit/", "facebookexternalhit"},
+		{"kube-probe/", "kube-probe"},
+		{"curl/", "curl"},
+		{"Wget/", "Wget"},
+		{"python-requests/", "python-requests"},
+		{"Go-http-client/", "Go-http-client"},
+		{"PostmanRuntime/", "Postman"},
+	}
+	browserMatchers = []uaMatcher{
+		{"Edg/", "Edge"},
+		{"EdgA/", "Edge"},
+		{"EdgiOS/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"YaBrowser/", "Yandex"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Version/", "Safari"},
+		{"MSIE ", "Internet Explorer"},
+		{"Trident/", "Internet Explorer"},
+	}
+	osMatchers = []uaMatcher{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"iPod", "iOS"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+const uaOther = "other"
+
+// parseUserAgent classifies s with a small set of substring rules covering
+// common browsers and bots. Unknown values are reported as "other".
+func parseUserAgent(s string) userAgent {
+	ua := userAgent{browser: uaOther, os: uaOther, device: uaOther}
+
+	for _, m := range botMatchers {
+		if i := strings.Index(s, m.token); i >= 0 {
+			ua.browser, ua.version, ua.bot = m.name, uaVersion(s[i+len(m.token):]), true
+			break
+		}
+	}
+	if !ua.bot {
+		lower := strings.ToLower(s)
+		ua.bot = strings.Contains(lower, "bot") || strings.Contains(lower, "crawler") || strings.Contains(lower, "spider")
+	}
+	if !ua.bot {
+		for _, m := range browserMatchers {
+			if i := strings.Index(s, m.token); i >= 0 {
+				if m.name == "Safari" && !strings.Contains(s, "Safari/") {
+					continue
+				}
+				ua.browser, ua.version = m.name, uaVersion(s[i+len(m.token):])
+				break
+			}
+		}
+	}
+
+	for _, m := range osMatchers {
+		if strings.Contains(s, m.token) {
+			ua.os = m.name
+			break
+		}
+	}
+
+	switch {
+	case ua.bot:
+		ua.device = "bot"
+	case strings.Contains(s, "iPad") || strings.Contains(s, "Tablet"):
+		ua.device = "tablet"
+	case strings.Contains(s, "Mobi") || strings.Contains(s, "iPhone"):
+		ua.device = "mobile"
+	case ua.os == "Android":
+		ua.device = "tablet"
+	case ua.os != uaOther:
+		ua.device = "desktop"
+	}
+	return ua
+}
+
+// uaVersion returns the version at the start of s.
+func uaVersion(s string) string {
+	end := strings.IndexAny(s, " ;)")
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end]
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ua   string
+		want userAgent
+	}{
+		{
+			"chrome windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			userAgent{browser: "Chrome", version: "120.0.6099.109", os: "Windows", device: "desktop"},
+		},
+		{
+			"edge",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.77",
+			userAgent{browser: "Edge", version: "120.0.2210.77", os: "Windows", device: "desktop"},
+		},
+		{
+			"firefox linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			userAgent{browser: "Firefox", version: "121.0", os: "Linux", device: "desktop"},
+		},
+		{
+			"safari macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			userAgent{browser: "Safari", version: "17.2", os: "macOS", device: "desktop"},
+		},
+		{
+			"safari iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			userAgent{browser: "Safari", version: "17.2", os: "iOS", device: "mobile"},
+		},
+		{
+			"chrome ipad",
+			"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			userAgent{browser: "Chrome", version: "120.0.6099.119", os: "iOS", device: "tablet"},
+		},
+		{
+			"chrome android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			userAgent{browser: "Chrome", version: "120.0.6099.144", os: "Android", device: "mobile"},
+		},
+		{
+			"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Safari/537.36",
+			userAgent{browser: "Chrome", version: "120.0.6099.144", os: "Android", device: "tablet"},
+		},
+		{
+			"googlebot",
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			userAgent{browser: "Googlebot", version: "2.1", os: "other", device: "bot", bot: true},
+		},
+		{
+			"curl",
+			"curl/8.4.0",
+			userAgent{browser: "curl", version: "8.4.0", os: "other", device: "bot", bot: true},
+		},
+		{
+			"kube-probe",
+			"kube-probe/1.28",
+			userAgent{browser: "kube-probe", version: "1.28", os: "other", device: "bot", bot: true},
+		},
+		{
+			"unknown crawler",
+			"ExampleCrawler/1.0 (+https://example.com)",
+			userAgent{browser: "other", os: "other", device: "bot", bot: true},
+		},
+		{
+			"unknown",
+			"SomeClient/3",
+			userAgent{browser: "other", os: "other", device: "other"},
+		},
+		{
+			"empty",
+			"",
+			userAgent{browser: "other", os: "other", device: "other"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseUserAgent(tc.ua); got != tc.want {
+				t.Errorf("parseUserAgent(%q) = %+v, want %+v", tc.ua, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithUserAgentParsing(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", "curl/8.4.0")
+
+	_, recs := serve(t, ok, r)
+	if _, ok := recs[0].Attr("ua"); ok {
+		t.Error("ua group logged without WithUserAgentParsing")
+	}
+
+	_, recs = serve(t, ok, r, WithUserAgentParsing())
+	if v, _ := recs[0].Attr("ua.browser"); v.String() != "curl" {
+		t.Errorf("ua.browser = %v, want curl", v)
+	}
+	if v, _ := recs[0].Attr("ua.is_bot"); !v.Bool() {
+		t.Errorf("ua.is_bot = %v, want true", v)
+	}
+	if v, _ := recs[0].Attr("user_agent"); v.String() != "curl/8.4.0" {
+		t.Errorf("user_agent = %v, want the raw string", v)
+	}
+}
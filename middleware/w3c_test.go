@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestW3CLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewW3CLog(&buf)
+	now := time.Date(2024, 1, 15, 10, 30, 45, 0, time.FixedZone("CET", 3600))
+
+	r := httptest.NewRequest(http.MethodGet, "/search%20page?q=a+b", nil)
+	r.RemoteAddr = "192.0.2.7:51234"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (X11)\x01")
+	if err := l.Log(r, 200, 512, 1234*time.Millisecond, now); err != nil {
+		t.Fatal(err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.RemoteAddr = "[2001:db8::1]:443"
+	r.Header.Del("User-Agent")
+	if err := l.Log(r, 201, 0, 5*time.Millisecond, now); err != nil {
+		t.Fatal(err)
+	}
+	l.Reset()
+	if err := l.Log(r, 201, 0, 5*time.Millisecond, now); err != nil {
+		t.Fatal(err)
+	}
+
+	header := "#Version: 1.0\n#Date: 2024-01-15 09:30:45\n#Fields: " + w3cFields + "\n"
+	first := "2024-01-15 09:30:45 192.0.2.7 GET /search%20page q=a+b 200 512 1.234 Mozilla/5.0+(X11)%01\n"
+	second := "2024-01-15 09:30:45 2001:db8::1 POST /orders - 201 0 0.005 -\n"
+	if got, want := buf.String(), header+first+second+header+second; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestW3CLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	_, recs := serve(t, ok, httptest.NewRequest(http.MethodGet, "/orders", nil), WithW3CLog(NewW3CLog(&buf)))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[3], " GET /orders - 200 2 ") {
+		t.Errorf("got %q", lines)
+	}
+	if len(recs) != 1 {
+		t.Errorf("got %d records, want the completion record too", len(recs))
+	}
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter records the status code and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+	flushed     bool
+	hijacked    bool
+
+	// onHijack is called with the connection taken over by the handler and
+	// returns the connection to hand out instead.
+	onHijack func(conn net.Conn) net.Conn
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// Informational responses other than 101 may precede the final status.
+	informational := code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+	if !w.wroteHeader && !informational {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Status returns the status code written, or 0 if none has been written yet.
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (w *responseWriter) BytesWritten() int {
+	return w.bytes
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type flusher struct{ *responseWriter }
+
+func (w flusher) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.flushed = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ *responseWriter }
+
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return conn, brw, err
+	}
+	w.hijacked = true
+	if w.onHijack != nil {
+		conn = w.onHijack(conn)
+	}
+	return conn, brw, nil
+}
+
+type readerFrom struct{ *responseWriter }
+
+func (w readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	w.bytes += int(n)
+	return n, err
+}
+
+type pusher struct{ *responseWriter }
+
+func (w pusher) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrapResponseWriter wraps w so that the returned writer implements the same
+// optional interfaces among http.Flusher, http.Hijacker, io.ReaderFrom and
+// http.Pusher as w does.
+func wrapResponseWriter(w http.ResponseWriter) (*responseWriter, http.ResponseWriter) {
+	rw := &responseWriter{ResponseWriter: w}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isReaderFrom := w.(io.ReaderFrom)
+	_, isPusher := w.(http.Pusher)
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom && isPusher:
+		return rw, struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+			pusher
+		}{rw, flusher{rw}, hijacker{rw}, readerFrom{rw}, pusher{rw}}
+	case isHijacker && isReaderFrom && isPusher:
+		return rw, struct {
+			*responseWriter
+			hijacker
+			readerFrom
+			pusher
+		}{rw, hijacker{rw}, readerFrom{rw}, pusher{rw}}
+	case isFlusher && isReaderFrom && isPusher:
+		return rw, struct {
+			*responseWriter
+			flusher
+			readerFrom
+			pusher
+		}{rw, flusher{rw}, readerFrom{rw}, pusher{rw}}
+	case isFlusher && isHijacker && isPusher:
+		return rw, struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+		}{rw, flusher{rw}, hijacker{rw}, pusher{rw}}
+	case isFlusher && isHijacker && isReaderFrom:
+		return rw, struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+		}{rw, flusher{rw}, hijacker{rw}, readerFrom{rw}}
+	case isReaderFrom && isPusher:
+		return rw, struct {
+			*responseWriter
+			readerFrom
+			pusher
+		}{rw, readerFrom{rw}, pusher{rw}}
+	case isHijacker && isPusher:
+		return rw, struct {
+			*responseWriter
+			hijacker
+			pusher
+		}{rw, hijacker{rw}, pusher{rw}}
+	case isFlusher && isPusher:
+		return rw, struct {
+			*responseWriter
+			flusher
+			pusher
+		}{rw, flusher{rw}, pusher{rw}}
+	case isHijacker && isReaderFrom:
+		return rw, struct {
+			*responseWriter
+			hijacker
+			readerFrom
+		}{rw, hijacker{rw}, readerFrom{rw}}
+	case isFlusher && isReaderFrom:
+		return rw, struct {
+			*responseWriter
+			flusher
+			readerFrom
+		}{rw, flusher{rw}, readerFrom{rw}}
+	case isFlusher && isHijacker:
+		return rw, struct {
+			*responseWriter
+			flusher
+			hijacker
+		}{rw, flusher{rw}, hijacker{rw}}
+	case isPusher:
+		return rw, struct {
+			*responseWriter
+			pusher
+		}{rw, pusher{rw}}
+	case isReaderFrom:
+		return rw, struct {
+			*responseWriter
+			readerFrom
+		}{rw, readerFrom{rw}}
+	case isHijacker:
+		return rw, struct {
+			*responseWriter
+			hijacker
+		}{rw, hijacker{rw}}
+	case isFlusher:
+		return rw, struct {
+			*responseWriter
+			flusher
+		}{rw, flusher{rw}}
+	default:
+		return rw, rw
+	}
+}
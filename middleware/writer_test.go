@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fullWriter implements all the optional interfaces of a response writer.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	pushed string
+}
+
+func (w *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	client, server := net.Pipe()
+	client.Close()
+	return server, nil, nil
+}
+
+func (w *fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func (w *fullWriter) Push(target string, _ *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+// plainWriter implements none of them.
+type plainWriter struct{ http.ResponseWriter }
+
+func TestWrapResponseWriterInterfaces(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		w    http.ResponseWriter
+		want [4]bool // Flusher, Hijacker, ReaderFrom, Pusher
+	}{
+		{"all", &fullWriter{ResponseRecorder: httptest.NewRecorder()}, [4]bool{true, true, true, true}},
+		{"recorder", httptest.NewRecorder(), [4]bool{true, false, false, false}},
+		{"none", plainWriter{httptest.NewRecorder()}, [4]bool{}},
+	} {
+		_, wrapped := wrapResponseWriter(tc.w)
+		_, f := wrapped.(http.Flusher)
+		_, h := wrapped.(http.Hijacker)
+		_, rf := wrapped.(io.ReaderFrom)
+		_, p := wrapped.(http.Pusher)
+		if got := [4]bool{f, h, rf, p}; got != tc.want {
+			t.Errorf("%s: got Flusher, Hijacker, ReaderFrom, Pusher %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWrapResponseWriterRecords(t *testing.T) {
+	fw := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	rw, wrapped := wrapResponseWriter(fw)
+
+	n, err := wrapped.(io.ReaderFrom).ReadFrom(strings.NewReader("hello"))
+	if n != 5 || err != nil {
+		t.Fatalf("ReadFrom: %d, %v", n, err)
+	}
+	wrapped.Write([]byte(" world"))
+	wrapped.(http.Flusher).Flush()
+	wrapped.(http.Pusher).Push("/app.css", nil)
+
+	if rw.Status() != http.StatusOK || rw.BytesWritten() != 11 || !rw.flushed {
+		t.Errorf("status %d, bytes %d, flushed %v", rw.Status(), rw.BytesWritten(), rw.flushed)
+	}
+	if fw.pushed != "/app.css" || fw.Body.String() != "hello world" {
+		t.Errorf("pushed %q, body %q", fw.pushed, fw.Body)
+	}
+	if rc := http.NewResponseController(wrapped); rc.Flush() != nil {
+		t.Error("ResponseController can't reach the writer")
+	}
+}
+
+func TestWrapResponseWriterInformational(t *testing.T) {
+	rw, wrapped := wrapResponseWriter(plainWriter{nopWriter{}})
+	wrapped.WriteHeader(http.StatusEarlyHints)
+	if rw.Status() != 0 {
+		t.Errorf("informational status recorded as %d", rw.Status())
+	}
+	wrapped.WriteHeader(http.StatusCreated)
+	wrapped.WriteHeader(http.StatusInternalServerError)
+	if rw.Status() != http.StatusCreated {
+		t.Errorf("status %d, want the first final status", rw.Status())
+	}
+}
+
+// nopWriter accepts any status, like a real connection does.
+type nopWriter struct{}
+
+func (nopWriter) Header() http.Header         { return http.Header{} }
+func (nopWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (nopWriter) WriteHeader(int)             {}
+
+func TestMiddlewareKeepsFlusher(t *testing.T) {
+	var isFlusher bool
+	next := func(w http.ResponseWriter, r *http.Request) {
+		_, isFlusher = w.(http.Flusher)
+	}
+	serve(t, next, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !isFlusher {
+		t.Error("handler's writer isn't an http.Flusher")
+	}
+}
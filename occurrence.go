@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// occurrenceKeys is the number of keys whose occurrences are counted. The keys
+// used least recently are forgotten beyond it.
+const occurrenceKeys = 10000
+
+// Once returns a logger writing to the handler of slog.Default that only logs
+// its first record for key, for warnings inside loops:
+//
+//	logger.Once("legacy-config").Warn("legacy config format is deprecated")
+//
+// Records are counted per key across all loggers returned by Once and EveryN.
+// Only the last 10000 keys used are remembered, so a forgotten key logs again.
+func Once(key string) *slog.Logger {
+	return slog.New(everyN(key, 0)(slog.Default().Handler()))
+}
+
+// EveryN is like Once but logs the first record for key and every nth after
+// it, with an "occurrences" attr counting all records for key.
+func EveryN(key string, n int) *slog.Logger {
+	return slog.New(everyN(key, max(n, 1))(slog.Default().Handler()))
+}
+
+// everyN returns the HandlerMiddleware of EveryN, or of Once for n 0.
+func everyN(key string, n int) HandlerMiddleware {
+	return func(next slog.Handler) slog.Handler {
+		return &occurrenceHandler{h: next, key: key, n: n}
+	}
+}
+
+type occurrenceHandler struct {
+	h   slog.Handler
+	key string
+	// n is zero for Once.
+	n int
+}
+
+func (h *occurrenceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *occurrenceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &occurrenceHandler{h: h.h.WithAttrs(attrs), key: h.key, n: h.n}
+}
+
+func (h *occurrenceHandler) WithGroup(name string) slog.Handler {
+	return &occurrenceHandler{h: h.h.WithGroup(name), key: h.key, n: h.n}
+}
+
+func (h *occurrenceHandler) Handle(ctx context.Context, r slog.Record) error {
+	count := occurrences.add(h.key)
+	if h.n == 0 {
+		if count > 1 {
+			return nil
+		}
+		return h.h.Handle(ctx, r)
+	}
+	if (count-1)%uint64(h.n) != 0 {
+		return nil
+	}
+	r = r.Clone()
+	r.AddAttrs(slog.Uint64("occurrences", count))
+	return h.h.Handle(ctx, r)
+}
+
+var occurrences = &occurrenceCache{
+	entries: make(map[string]*list.Element),
+	order:   list.New(),
+}
+
+type occurrenceEntry struct {
+	key   string
+	count uint64
+}
+
+// occurrenceCache is an LRU cache of the number of records per key.
+type occurrenceCache struct {
+	m       sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// add counts a record for key and returns the number of records for it.
+func (c *occurrenceCache) add(key string) uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		e := el.Value.(*occurrenceEntry)
+		e.count++
+		return e.count
+	}
+	c.entries[key] = c.order.PushFront(&occurrenceEntry{key: key, count: 1})
+	if c.order.Len() > occurrenceKeys {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*occurrenceEntry).key)
+	}
+	return 1
+}
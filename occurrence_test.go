@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOnceEveryN(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	for _, tc := range []struct {
+		name   string
+		logger func() *slog.Logger
+		want   []uint64
+	}{
+		{"Once", func() *slog.Logger { return Once(t.Name() + "/once") }, []uint64{0}},
+		{"EveryN", func() *slog.Logger { return EveryN(t.Name()+"/every", 10) }, []uint64{1, 11, 21, 31, 41, 51, 61, 71, 81, 91}},
+		{"EveryN 1", func() *slog.Logger { return EveryN(t.Name()+"/every1", 1) }, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+			for range 100 {
+				tc.logger().Warn("deprecated")
+			}
+
+			var got []uint64
+			for line := range strings.Lines(buf.String()) {
+				var record struct{ Occurrences uint64 }
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, record.Occurrences)
+			}
+			want := tc.want
+			if want == nil {
+				for i := range 100 {
+					want = append(want, uint64(i+1))
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got occurrences %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEveryNConcurrent(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{Writer: &buf, JSON: true})))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 10 {
+				EveryN(t.Name(), 10).Info("tick")
+			}
+		})
+	}
+	wg.Wait()
+	if n := strings.Count(buf.String(), `"occurrences":`); n != 10 {
+		t.Errorf("got %d records, want 10:\n%s", n, buf.String())
+	}
+}
@@ -0,0 +1,112 @@
+package otellog
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewSpanEventHandler returns a handler passing records to h that also adds
+// records at Warn level and above as events to the recording span in the
+// context of the logging call. Error level records with an error attribute set
+// the span status to Error.
+func NewSpanEventHandler(h slog.Handler) slog.Handler {
+	return &spanEventHandler{h: h}
+}
+
+type spanEventHandler struct {
+	h      slog.Handler
+	attrs  []attribute.KeyValue
+	prefix string
+}
+
+func (h *spanEventHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *spanEventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.h = h.h.WithAttrs(attrs)
+	h2.attrs = appendKeyValues(slices.Clip(h.attrs), h.prefix, attrs)
+	return &h2
+}
+
+func (h *spanEventHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.h = h.h.WithGroup(name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+func (h *spanEventHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		if span := trace.SpanFromContext(ctx); span.IsRecording() {
+			h.addEvent(span, r)
+		}
+	}
+	return h.h.Handle(ctx, r)
+}
+
+func (h *spanEventHandler) addEvent(span trace.Span, r slog.Record) {
+	kvs := make([]attribute.KeyValue, 0, len(h.attrs)+r.NumAttrs()+1)
+	kvs = append(kvs, attribute.String("level", r.Level.String()))
+	kvs = append(kvs, h.attrs...)
+
+	var errMsg string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "error" && errMsg == "" {
+			errMsg = a.Value.Resolve().String()
+		}
+		kvs = appendKeyValues(kvs, h.prefix, []slog.Attr{a})
+		return true
+	})
+
+	span.AddEvent(r.Message, trace.WithTimestamp(r.Time), trace.WithAttributes(kvs...))
+	if r.Level >= slog.LevelError && errMsg != "" {
+		span.SetStatus(codes.Error, errMsg)
+	}
+}
+
+// appendKeyValues converts attrs to OpenTelemetry attributes. Keys of grouped
+// attributes are joined with dots.
+func appendKeyValues(kvs []attribute.KeyValue, prefix string, attrs []slog.Attr) []attribute.KeyValue {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		key := prefix + a.Key
+		switch v.Kind() {
+		case slog.KindGroup:
+			groupPrefix := prefix
+			if a.Key != "" {
+				groupPrefix = key + "."
+			}
+			kvs = appendKeyValues(kvs, groupPrefix, v.Group())
+			continue
+		case slog.KindBool:
+			kvs = append(kvs, attribute.Bool(key, v.Bool()))
+		case slog.KindInt64:
+			kvs = append(kvs, attribute.Int64(key, v.Int64()))
+		case slog.KindUint64:
+			if u := v.Uint64(); u <= math.MaxInt64 {
+				kvs = append(kvs, attribute.Int64(key, int64(u)))
+			} else {
+				kvs = append(kvs, attribute.String(key, v.String()))
+			}
+		case slog.KindFloat64:
+			kvs = append(kvs, attribute.Float64(key, v.Float64()))
+		case slog.KindTime:
+			kvs = append(kvs, attribute.String(key, v.Time().Format(time.RFC3339Nano)))
+		default:
+			if a.Key == "" {
+				continue
+			}
+			kvs = append(kvs, attribute.String(key, v.String()))
+		}
+	}
+	return kvs
+}
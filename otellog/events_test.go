@@ -0,0 +1,119 @@
+package otellog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traced runs fn with a logger writing through a span event handler and the
+// context of a recording span, and returns the ended span and the log output.
+func traced(t *testing.T, fn func(ctx context.Context, l *slog.Logger)) (sdktrace.ReadOnlySpan, string) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	var buf bytes.Buffer
+	l := slog.New(NewSpanEventHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	fn(ctx, l)
+	span.End()
+
+	spans := exporter.GetSpans().Snapshots()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0], buf.String()
+}
+
+func attrMap(kvs []attribute.KeyValue) map[string]any {
+	m := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
+
+func TestSpanEvents(t *testing.T) {
+	span, out := traced(t, func(ctx context.Context, l *slog.Logger) {
+		l = l.With("svc", "api").WithGroup("req")
+		l.InfoContext(ctx, "not an event")
+		l.WarnContext(ctx, "slow query", "ms", 250, "ok", true, "ratio", 0.5, slog.Group("db", "table", "users"))
+	})
+
+	if !strings.Contains(out, "not an event") || !strings.Contains(out, "slow query") {
+		t.Errorf("records not passed on: %s", out)
+	}
+	events := span.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want only the warning", len(events))
+	}
+	if events[0].Name != "slow query" {
+		t.Errorf("event name = %q, want slow query", events[0].Name)
+	}
+	got := attrMap(events[0].Attributes)
+	want := map[string]any{
+		"level":        "WARN",
+		"svc":          "api",
+		"req.ms":       int64(250),
+		"req.ok":       true,
+		"req.ratio":    0.5,
+		"req.db.table": "users",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v (%T), want %v", k, got[k], got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+	if span.Status().Code != codes.Unset {
+		t.Errorf("status = %v after a warning, want Unset", span.Status())
+	}
+}
+
+func TestSpanStatus(t *testing.T) {
+	span, _ := traced(t, func(ctx context.Context, l *slog.Logger) {
+		l.ErrorContext(ctx, "no error attr")
+	})
+	if span.Status().Code != codes.Unset {
+		t.Errorf("status = %v without an error attr, want Unset", span.Status())
+	}
+
+	span, _ = traced(t, func(ctx context.Context, l *slog.Logger) {
+		l.WarnContext(ctx, "warned", "error", errors.New("retrying"))
+		l.ErrorContext(ctx, "failed", "error", errors.New("connection refused"))
+	})
+	if got := span.Status(); got.Code != codes.Error || got.Description != "connection refused" {
+		t.Errorf("status = %v, want Error connection refused", got)
+	}
+	if len(span.Events()) != 2 {
+		t.Errorf("got %d events, want 2", len(span.Events()))
+	}
+}
+
+func TestSpanEventsWithoutRecordingSpan(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewSpanEventHandler(slog.NewTextHandler(&buf, nil)))
+
+	// A valid but non-recording span context, as propagated from a remote parent.
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext(t))
+	l.ErrorContext(ctx, "failed", "error", errors.New("boom"))
+	l.ErrorContext(context.Background(), "failed")
+
+	if n := bytes.Count(buf.Bytes(), []byte("failed")); n != 2 {
+		t.Errorf("got %d records, want 2", n)
+	}
+}
@@ -0,0 +1,23 @@
+// Package otellog connects OpenTelemetry tracing with go-log.
+package otellog
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceAttrs returns trace_id and span_id attributes for the span stored in ctx.
+// It returns nil when ctx carries no valid span context, so it can be used as a
+// logger.ContextExtractor for both the handler and the HTTP middleware.
+func TraceAttrs(ctx context.Context) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("trace_id", sc.TraceID().String()),
+		slog.String("span_id", sc.SpanID().String()),
+	}
+}
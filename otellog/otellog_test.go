@@ -0,0 +1,40 @@
+package otellog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanContext(t *testing.T) trace.SpanContext {
+	t.Helper()
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+}
+
+func TestTraceAttrs(t *testing.T) {
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext(t))
+	got := map[string]string{}
+	for _, a := range TraceAttrs(ctx) {
+		got[a.Key] = a.Value.String()
+	}
+	if got["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || got["span_id"] != "00f067aa0ba902b7" || len(got) != 2 {
+		t.Errorf("got %v", got)
+	}
+
+	if attrs := TraceAttrs(context.Background()); attrs != nil {
+		t.Errorf("got %v without a span", attrs)
+	}
+	invalid := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+	if attrs := TraceAttrs(invalid); attrs != nil {
+		t.Errorf("got %v for an invalid span context", attrs)
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime/pprof"
+)
+
+// pprofLabelKeys are the attribute keys WithPprofLabels turns into labels.
+var pprofLabelKeys = map[string]bool{
+	"request_id": true,
+	"route":      true,
+	"method":     true,
+	"component":  true,
+}
+
+// WithPprofLabels returns ctx with runtime/pprof labels mirroring attrs and sets
+// them on the calling goroutine, so profile samples can be matched with log
+// records. Only the keys request_id, route, method and component are used;
+// other attributes are dropped to keep the number of label values bounded.
+func WithPprofLabels(ctx context.Context, attrs ...slog.Attr) context.Context {
+	var labels []string
+	for _, a := range attrs {
+		if !pprofLabelKeys[a.Key] {
+			continue
+		}
+		if v := a.Value.Resolve().String(); v != "" {
+			labels = append(labels, a.Key, v)
+		}
+	}
+	if len(labels) == 0 {
+		return ctx
+	}
+	ctx = pprof.WithLabels(ctx, pprof.Labels(labels...))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
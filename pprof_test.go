@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestWithPprofLabels(t *testing.T) {
+	ctx := WithPprofLabels(context.Background(),
+		slog.String("request_id", "req-1"),
+		slog.String("route", "GET /orders/{id}"),
+		slog.Int("user_id", 42),
+		slog.String("component", ""),
+		slog.Any("method", slog.StringValue("GET")),
+	)
+	defer pprof.SetGoroutineLabels(context.Background())
+
+	got := map[string]string{}
+	pprof.ForLabels(ctx, func(k, v string) bool {
+		got[k] = v
+		return true
+	})
+	want := map[string]string{"request_id": "req-1", "route": "GET /orders/{id}", "method": "GET"}
+	if !maps.Equal(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+}
+
+func TestWithPprofLabelsNone(t *testing.T) {
+	ctx := context.Background()
+	if got := WithPprofLabels(ctx, slog.Int("user_id", 42)); got != ctx {
+		t.Error("context replaced without allowed attributes")
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PrunePolicy selects the rotated log files Prune removes. Zero limits don't
+// limit.
+type PrunePolicy struct {
+	// MaxAge removes backups last modified longer ago.
+	MaxAge time.Duration
+	// MaxTotalBytes removes the oldest backups beyond which the backups take
+	// more space.
+	MaxTotalBytes int64
+	// MaxFiles removes the oldest backups beyond this number.
+	MaxFiles int
+	// Active names the file being written to, like "app.log". Only its rotated
+	// backups are removed, and never the file itself. It is required.
+	Active string
+}
+
+// Prune removes the rotated backups of policy.Active from dir, the oldest
+// first, as policy says. Backups are named like the active file with a suffix
+// starting with a digit, before or after its extension, and may be gzipped:
+// for app.log, app.log.1, app.log-20240115.gz and app-2024-01-15T10-30-45.log
+// are backups, and app-worker.log and other.log aren't. Subdirectories and
+// other files are left alone. It keeps going when a file can't be removed and
+// returns the errors.
+func Prune(dir string, policy PrunePolicy) error {
+	active := filepath.Base(policy.Active)
+	if policy.Active == "" || active == "." || active == string(filepath.Separator) {
+		return errors.New("prune: no active file")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	type logFile struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []logFile
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !isBackup(e.Name(), active) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{e.Name(), info.Size(), info.ModTime()})
+	}
+	// Newest first.
+	slices.SortFunc(files, func(a, b logFile) int { return b.modTime.Compare(a.modTime) })
+
+	now := time.Now()
+	var total int64
+	var errs []error
+	for i, f := range files {
+		total += f.size
+		if (policy.MaxFiles > 0 && i >= policy.MaxFiles) ||
+			(policy.MaxTotalBytes > 0 && total > policy.MaxTotalBytes) ||
+			(policy.MaxAge > 0 && now.Sub(f.modTime) > policy.MaxAge) {
+			if err := os.Remove(filepath.Join(dir, f.name)); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			total -= f.size
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isBackup reports whether name is a rotated backup of the file active.
+func isBackup(name, active string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	if name == active {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(name, active); ok && backupSuffix(suffix) {
+		return true
+	}
+	ext := filepath.Ext(active)
+	if ext == "" || !strings.HasSuffix(name, ext) {
+		return false
+	}
+	suffix, ok := strings.CutPrefix(strings.TrimSuffix(name, ext), strings.TrimSuffix(active, ext))
+	return ok && backupSuffix(suffix)
+}
+
+// backupSuffix reports whether s is the suffix of a backup, like ".1" or
+// "-20240115".
+func backupSuffix(s string) bool {
+	return len(s) >= 2 && strings.ContainsRune(".-_", rune(s[0])) && s[1] >= '0' && s[1] <= '9'
+}
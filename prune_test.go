@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// pruneDir creates files in a temporary directory, each of the given size and
+// the given age, and returns the directory.
+func pruneDir(t *testing.T, files map[string]time.Duration, size int) string {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app.log.0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func remaining(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestPrune(t *testing.T) {
+	const day = 24 * time.Hour
+	files := map[string]time.Duration{
+		"app.log":                         0,
+		"app.log.1":                       1 * day,
+		"app.log.2.gz":                    2 * day,
+		"app.log-20240110.gz":             3 * day,
+		"app-2024-01-09T10-30-45.000.log": 4 * day,
+		"app.log.5":                       5 * day,
+		// Not backups of app.log.
+		"app-worker.log":   30 * day,
+		"app-worker.log.1": 30 * day,
+		"other.log":        30 * day,
+		"other.log.1.gz":   30 * day,
+		"app.log.old":      30 * day,
+		"notes.txt":        30 * day,
+	}
+	unrelated := []string{"app-worker.log", "app-worker.log.1", "app.log.0", "app.log.old", "notes.txt", "other.log", "other.log.1.gz"}
+
+	for _, tc := range []struct {
+		name   string
+		policy PrunePolicy
+		kept   []string
+	}{
+		{"no limits", PrunePolicy{}, []string{"app.log.1", "app.log.2.gz", "app.log-20240110.gz", "app-2024-01-09T10-30-45.000.log", "app.log.5"}},
+		{"max age", PrunePolicy{MaxAge: 2*day + time.Hour}, []string{"app.log.1", "app.log.2.gz"}},
+		{"max files", PrunePolicy{MaxFiles: 3}, []string{"app.log.1", "app.log.2.gz", "app.log-20240110.gz"}},
+		{"max total bytes", PrunePolicy{MaxTotalBytes: 2 * 100}, []string{"app.log.1", "app.log.2.gz"}},
+		{"combined", PrunePolicy{MaxAge: 10 * day, MaxFiles: 4, MaxTotalBytes: 1}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := pruneDir(t, files, 100)
+			tc.policy.Active = filepath.Join(dir, "app.log")
+			if err := Prune(dir, tc.policy); err != nil {
+				t.Fatal(err)
+			}
+			want := append(append([]string{"app.log"}, tc.kept...), unrelated...)
+			slices.Sort(want)
+			if got := remaining(t, dir); !slices.Equal(got, want) {
+				t.Errorf("remaining files = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPruneWithoutActive(t *testing.T) {
+	dir := pruneDir(t, map[string]time.Duration{"app.log.1": time.Hour}, 1)
+	if err := Prune(dir, PrunePolicy{MaxFiles: 1}); err == nil {
+		t.Error("Prune without an active file succeeded")
+	}
+	if got := remaining(t, dir); len(got) != 2 {
+		t.Errorf("remaining files = %v, want them all", got)
+	}
+}
+
+func TestFileWriterPrune(t *testing.T) {
+	dir := pruneDir(t, map[string]time.Duration{"app.log.1": time.Hour, "app.log.2": 2 * time.Hour, "db.log.1": 3 * time.Hour}, 1)
+	w, err := NewFileWriter(filepath.Join(dir, "app.log"), &FileWriterOptions{Prune: &PrunePolicy{MaxFiles: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	want := []string{"app.log", "app.log.0", "app.log.1", "db.log.1"}
+	if got := remaining(t, dir); !slices.Equal(got, want) {
+		t.Errorf("remaining files = %v, want %v", got, want)
+	}
+}
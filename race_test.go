@@ -0,0 +1,7 @@
+//go:build race
+
+package logger
+
+// raceEnabled is whether the tests run with the race detector, which makes
+// code allocate that doesn't otherwise.
+const raceEnabled = true
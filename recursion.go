@@ -0,0 +1,234 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errRecursive is returned by handler.write for records logged while the
+// calling goroutine writes another record, either holding the writeLock or as
+// the goroutine of a timeoutWriter.
+var errRecursive = errors.New("recursive log")
+
+// recursionCheckDelay is how long a record waits for the writer before the
+// handler checks whether the writer is busy logging that very record.
+const recursionCheckDelay = time.Millisecond
+
+// handleEntry and timeoutHandleEntry are the entry addresses of handler.Handle
+// and timeoutWriter.handle. They're set in init because Handle refers to them.
+var handleEntry, timeoutHandleEntry uintptr
+
+func init() {
+	handleEntry = reflect.ValueOf((*handler).Handle).Pointer()
+	timeoutHandleEntry = reflect.ValueOf((*timeoutWriter).handle).Pointer()
+}
+
+// writeLock serializes the writes of a handler and the handlers derived from
+// it. Unlike with a sync.Mutex, a goroutine waiting for it finds out when it
+// holds it itself, which happens when the writer logs to the handler it writes
+// for.
+//
+// Finding out needs the goroutine holding the lock, which takes a stack trace
+// to get. So a lock only keeps track of it once a record logged by a writer, to
+// this handler or another one, had to wait for it: most writers never log, and
+// those that do pay for it. Until then, such a record gives up waiting after
+// recursionGiveUp.
+type writeLock struct {
+	ch chan struct{}
+	// track is set once a record logged by a writer waited for the lock, and
+	// owner is then the ID of the goroutine holding it, or 0.
+	track atomic.Bool
+	owner atomic.Int64
+}
+
+// recursionGiveUp is how long a record logged by a writer waits for a lock
+// whose holder isn't known, before it's taken for a record waiting for itself.
+// Writers stalling for longer are what WriteTimeout is for.
+const recursionGiveUp = time.Second
+
+func newWriteLock() *writeLock {
+	return &writeLock{ch: make(chan struct{}, 1)}
+}
+
+// lock locks l, or returns errRecursive if the calling goroutine holds it
+// already, or holds a lock that the holder of l waits for. It only walks the
+// stack to find out when l stays locked for recursionCheckDelay, so that
+// goroutines contending for the writer don't.
+func (l *writeLock) lock() error {
+	select {
+	case l.ch <- struct{}{}:
+		l.acquired()
+		return nil
+	default:
+	}
+	timer := time.NewTimer(recursionCheckDelay)
+	defer timer.Stop()
+	select {
+	case l.ch <- struct{}{}:
+		l.acquired()
+		return nil
+	case <-timer.C:
+	}
+	// With no other Handle on the stack, the record wasn't logged by a writer
+	// and can't be waiting for itself.
+	if !onStack(handleEntry, 2) {
+		l.ch <- struct{}{}
+		l.acquired()
+		return nil
+	}
+	l.track.Store(true)
+	me := goid()
+	waiting.register(me, l)
+	defer waiting.unregister(me)
+
+	ticker := time.NewTicker(recursionCheckDelay)
+	defer ticker.Stop()
+	var unknown time.Time
+	for {
+		select {
+		case l.ch <- struct{}{}:
+			l.owner.Store(me)
+			return nil
+		case <-ticker.C:
+		}
+		switch owner := l.owner.Load(); {
+		case owner == 0:
+			// l was locked before it was tracked, or is just being locked.
+			if unknown.IsZero() {
+				unknown = time.Now()
+			} else if time.Since(unknown) >= recursionGiveUp {
+				return errRecursive
+			}
+		case waiting.waitsFor(owner, me):
+			return errRecursive
+		default:
+			unknown = time.Time{}
+		}
+	}
+}
+
+// acquired records the calling goroutine as the holder of l if l is tracked.
+func (l *writeLock) acquired() {
+	if l.track.Load() {
+		l.owner.Store(goid())
+	}
+}
+
+func (l *writeLock) unlock() {
+	if l.track.Load() {
+		l.owner.Store(0)
+	}
+	<-l.ch
+}
+
+// waiting is the writeLock each goroutine waits for, of the goroutines waiting
+// for a tracked writeLock.
+var waiting = waitGraph{locks: map[int64]*writeLock{}}
+
+type waitGraph struct {
+	mu    sync.Mutex
+	locks map[int64]*writeLock
+}
+
+func (g *waitGraph) register(id int64, l *writeLock) {
+	g.mu.Lock()
+	g.locks[id] = l
+	g.mu.Unlock()
+}
+
+func (g *waitGraph) unregister(id int64) {
+	g.mu.Lock()
+	delete(g.locks, id)
+	g.mu.Unlock()
+}
+
+// waitsFor reports whether the goroutine id is the goroutine target, or waits
+// for a lock it holds, directly or through the holders of other locks.
+func (g *waitGraph) waitsFor(id, target int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for range len(g.locks) + 1 {
+		if id == target {
+			return true
+		}
+		l, ok := g.locks[id]
+		if !ok {
+			return false
+		}
+		if id = l.owner.Load(); id == 0 {
+			return false
+		}
+	}
+	return false
+}
+
+// goid returns the ID of the calling goroutine, which runtime.Stack starts its
+// trace with.
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b, _ = bytes.CutPrefix(b, []byte("goroutine "))
+	id, _ := strconv.ParseInt(string(b[:bytes.IndexByte(b, ' ')]), 10, 64)
+	return id
+}
+
+// onStack reports whether the function with the given entry address is on the
+// stack of the calling goroutine at least n times.
+func onStack(entry uintptr, n int) bool {
+	var pcs [64]uintptr
+	found := 0
+	for skip := 2; ; skip += len(pcs) {
+		m := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:m] {
+			if f := runtime.FuncForPC(pc - 1); f != nil && f.Entry() == entry {
+				if found++; found == n {
+					return true
+				}
+			}
+		}
+		if m < len(pcs) {
+			return false
+		}
+	}
+}
+
+// suppress writes a record logged while handling another one to the fallback
+// writer.
+func (h *handler) suppress(r slog.Record) error {
+	h.stats.suppressed.Add(1)
+	return h.handleRecursive(r)
+}
+
+// handleRecursive writes a record logged while handling another one to the
+// fallback writer only, so it can't feed back into the writer that logged it.
+// Handler attrs and context extractors are left out for the same reason.
+func (h *handler) handleRecursive(r slog.Record) error {
+	b := make([]byte, 0, 256)
+	if !r.Time.IsZero() {
+		b = r.Time.AppendFormat(b, h.timeLayout)
+		b = append(b, ' ')
+	}
+	b = append(b, levelName(r.Level)...)
+	b = append(b, ": recursive log suppressed: "...)
+	b = appendMessage(b, r.Message, h.controlChars)
+	b = append(b, ' ')
+
+	e := &attrEncoder{hexBytes: h.hexBytes, humanDurations: h.humanDurations, humanLaps: h.humanLaps}
+	b = append(b, '{')
+	r.Attrs(func(a slog.Attr) bool {
+		b, _ = e.appendAttr(b, a)
+		return true
+	})
+	b = append(b, '}')
+	b = append(b, h.lineEnding...)
+
+	_, err := h.fallback.Write(b)
+	return err
+}
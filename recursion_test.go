@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingSink is a sink logging its delivery failures through log, like an
+// HTTP sink logging through slog.Default.
+type failingSink struct {
+	mu    sync.Mutex
+	lines []string
+	log   *slog.Logger
+}
+
+func (s *failingSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.lines = append(s.lines, string(p))
+	s.mu.Unlock()
+	s.log.Error("delivery failed", "error", "503 Service Unavailable")
+	return len(p), nil
+}
+
+func TestRecursiveLog(t *testing.T) {
+	for _, single := range []bool{false, true} {
+		var fallback bytes.Buffer
+		sink := &failingSink{}
+		h := NewHandler(&HandlerOptions{Writer: sink, FallbackWriter: &fallback, SingleWriter: single})
+		sink.log = slog.New(h)
+
+		slog.New(h).With("svc", "api").Info("order placed")
+
+		if len(sink.lines) != 1 || !strings.Contains(sink.lines[0], "order placed") {
+			t.Errorf("SingleWriter %v: sink got %q, want the record only", single, sink.lines)
+		}
+		want := `ERROR: recursive log suppressed: delivery failed {"error":"503 Service Unavailable"}`
+		if got := fallback.String(); !strings.Contains(got, want) || strings.Count(got, "\n") != 1 {
+			t.Errorf("SingleWriter %v: fallback got %q, want %q", single, got, want)
+		}
+		if s := h.Stats(); s.Records != 1 || s.Suppressed != 1 {
+			t.Errorf("SingleWriter %v: stats %+v, want 1 record and 1 suppressed", single, s)
+		}
+	}
+}
+
+func TestRecursiveLogWriteTimeout(t *testing.T) {
+	var fallback bytes.Buffer
+	sink := &failingSink{}
+	h := NewHandler(&HandlerOptions{Writer: sink, FallbackWriter: &fallback, WriteTimeout: time.Second})
+	defer h.Close()
+	sink.log = slog.New(h)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		slog.New(h).Info("order placed")
+	}()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("record logged by the writer waited for the WriteTimeout")
+	}
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.mu.Lock()
+	lines := sink.lines
+	sink.mu.Unlock()
+	if len(lines) != 1 || !strings.Contains(lines[0], "order placed") {
+		t.Errorf("sink got %q, want the record only", lines)
+	}
+	if got := strings.Count(fallback.String(), "recursive log suppressed: delivery failed"); got != 1 {
+		t.Errorf("fallback got %q, want one suppressed record", fallback.String())
+	}
+	if s := h.Stats(); s.Records != 1 || s.Suppressed != 1 || s.Dropped != 0 {
+		t.Errorf("stats %+v, want 1 record and 1 suppressed", s)
+	}
+}
+
+func TestRecursiveLogThroughOtherHandler(t *testing.T) {
+	var fallback bytes.Buffer
+	sink := &failingSink{}
+	// The default logger writes to the same sink with its own handler.
+	sink.log = slog.New(NewHandler(&HandlerOptions{Writer: sink, FallbackWriter: &fallback}))
+
+	slog.New(NewHandler(&HandlerOptions{Writer: sink})).Info("order placed")
+
+	if len(sink.lines) != 2 {
+		t.Errorf("sink got %q, want the record and one failure", sink.lines)
+	}
+	if got := strings.Count(fallback.String(), "recursive log suppressed"); got != 1 {
+		t.Errorf("fallback got %q, want one suppressed record", fallback.String())
+	}
+}
+
+// slowWriter takes a while for every write, so that concurrent records find it
+// busy.
+type slowWriter struct{ n int }
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(10 * time.Microsecond)
+	w.n++
+	return len(p), nil
+}
+
+func TestConcurrentLogNotRecursive(t *testing.T) {
+	var fallback bytes.Buffer
+	w := &slowWriter{}
+	h := NewHandler(&HandlerOptions{Writer: w, FallbackWriter: &fallback})
+	l := slog.New(h)
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			for range 100 {
+				l.Info("record")
+			}
+		})
+	}
+	wg.Wait()
+
+	if s := h.Stats(); s.Suppressed != 0 || w.n != 1600 {
+		t.Errorf("stats %+v with %d writes, want 1600 written and none suppressed", s, w.n)
+	}
+}
+
+// teeWriter writes to w and logs every record it writes to log, like a writer
+// copying the output of one handler into another.
+type teeWriter struct {
+	w   io.Writer
+	log *slog.Logger
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	w.log.Info("copied", "bytes", len(p))
+	return w.w.Write(p)
+}
+
+// sleepyWriter holds up every write for d.
+type sleepyWriter struct {
+	d  time.Duration
+	mu sync.Mutex
+	n  int
+}
+
+func (w *sleepyWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.d)
+	w.mu.Lock()
+	w.n++
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+func TestLogThroughOtherHandlerNotRecursive(t *testing.T) {
+	// Records of a go to b while b is busy writing the records of another
+	// goroutine for longer than recursionCheckDelay.
+	var fallback bytes.Buffer
+	inner := &sleepyWriter{d: 5 * recursionCheckDelay}
+	b := NewHandler(&HandlerOptions{Writer: inner, FallbackWriter: &fallback})
+	a := NewHandler(&HandlerOptions{Writer: &teeWriter{w: io.Discard, log: slog.New(b)}, FallbackWriter: &fallback})
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for range 20 {
+			slog.New(b).Info("direct")
+		}
+	})
+	wg.Go(func() {
+		for range 20 {
+			slog.New(a).Info("teed")
+		}
+	})
+	wg.Wait()
+
+	if fallback.Len() != 0 {
+		t.Errorf("fallback got %q", fallback.String())
+	}
+	if s := b.Stats(); s.Suppressed != 0 || inner.n != 40 {
+		t.Errorf("stats %+v with %d writes, want 40 written and none suppressed", s, inner.n)
+	}
+}
@@ -0,0 +1,139 @@
+// Package redislog provides a go-redis hook logging commands and pipelines.
+package redislog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithFullKeys logs complete keys instead of their prefix up to and including
+// the first colon.
+func WithFullKeys() Option {
+	return func(h *Hook) {
+		h.fullKeys = true
+	}
+}
+
+// Hook is a redis.Hook logging every command at Debug level with its name, key
+// prefix and duration. Pipelines are logged as a single record with the number
+// of commands. Failed commands are logged at Warn level, except for redis.Nil
+// replies.
+type Hook struct {
+	log      *slog.Logger
+	fullKeys bool
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+// NewHook returns a Hook logging to log. Add it to a client with AddHook:
+//
+//	rdb.AddHook(redislog.NewHook(logger))
+func NewHook(log *slog.Logger, opts ...Option) *Hook {
+	h := &Hook{log: log.With(slog.String("component", "redis"))}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		elapsed := time.Since(start)
+
+		level := levelOf(err)
+		if !h.log.Enabled(ctx, level) {
+			return err
+		}
+		attrs := []slog.Attr{slog.String("command", cmd.Name())}
+		if key, ok := h.key(cmd); ok {
+			attrs = append(attrs, key)
+		}
+		attrs = append(attrs, slog.Duration("duration", elapsed))
+		if isError(err) {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		h.log.LogAttrs(ctx, level, "redis command", attrs...)
+		return err
+	}
+}
+
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		elapsed := time.Since(start)
+
+		// The pipeline error is the first command error, which may be redis.Nil.
+		if err == nil {
+			for _, cmd := range cmds {
+				if isError(cmd.Err()) {
+					err = cmd.Err()
+					break
+				}
+			}
+		}
+
+		level := levelOf(err)
+		if !h.log.Enabled(ctx, level) {
+			return err
+		}
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		attrs := []slog.Attr{
+			slog.Int("commands", len(cmds)),
+			slog.String("command_names", strings.Join(names, ",")),
+			slog.Duration("duration", elapsed),
+		}
+		if isError(err) {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		h.log.LogAttrs(ctx, level, "redis pipeline", attrs...)
+		return err
+	}
+}
+
+// key returns the key argument of cmd, or its prefix. Keys without a colon have
+// no prefix and are only logged with WithFullKeys.
+func (h *Hook) key(cmd redis.Cmder) (slog.Attr, bool) {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return slog.Attr{}, false
+	}
+	key := fmt.Sprint(args[1])
+	if h.fullKeys {
+		return slog.String("key", key), true
+	}
+	i := strings.IndexByte(key, ':')
+	if i < 0 {
+		return slog.Attr{}, false
+	}
+	return slog.String("key_prefix", key[:i+1]), true
+}
+
+func isError(err error) bool {
+	return err != nil && !errors.Is(err, redis.Nil)
+}
+
+func levelOf(err error) slog.Level {
+	if isError(err) {
+		return slog.LevelWarn
+	}
+	return slog.LevelDebug
+}
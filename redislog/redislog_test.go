@@ -0,0 +1,155 @@
+package redislog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newTest(t *testing.T, opts ...Option) (*Hook, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return NewHook(l, opts...), &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf, err)
+	}
+	buf.Reset()
+	return m
+}
+
+func process(h *Hook, cmd redis.Cmder, err error) error {
+	return h.ProcessHook(func(context.Context, redis.Cmder) error {
+		cmd.SetErr(err)
+		return err
+	})(context.Background(), cmd)
+}
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name    string
+		cmd     redis.Cmder
+		err     error
+		opts    []Option
+		level   string
+		key     string
+		wantKey string
+	}{
+		{"prefix", redis.NewStringCmd(ctx, "get", "user:42"), nil, nil, "DEBUG", "key_prefix", "user:"},
+		{"no prefix", redis.NewStringCmd(ctx, "get", "counter"), nil, nil, "DEBUG", "", ""},
+		{"full key", redis.NewStringCmd(ctx, "get", "user:42"), nil, []Option{WithFullKeys()}, "DEBUG", "key", "user:42"},
+		{"no key", redis.NewStatusCmd(ctx, "ping"), nil, nil, "DEBUG", "", ""},
+		{"nil reply", redis.NewStringCmd(ctx, "get", "user:1"), redis.Nil, nil, "DEBUG", "key_prefix", "user:"},
+		{"error", redis.NewStringCmd(ctx, "get", "user:1"), errors.New("READONLY"), nil, "WARN", "key_prefix", "user:"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, buf := newTest(t, tc.opts...)
+			if err := process(h, tc.cmd, tc.err); err != tc.err {
+				t.Errorf("err = %v, want %v passed through", err, tc.err)
+			}
+
+			m := decode(t, buf)
+			if m["msg"] != "redis command" || m["level"] != tc.level || m["command"] != tc.cmd.Name() {
+				t.Errorf("record = %v, want %s redis command %s", m, tc.level, tc.cmd.Name())
+			}
+			if m["component"] != "redis" {
+				t.Errorf("component = %v, want redis", m["component"])
+			}
+			if _, ok := m["duration"]; !ok {
+				t.Error("duration missing")
+			}
+			if tc.key != "" && m[tc.key] != tc.wantKey {
+				t.Errorf("%s = %v, want %s", tc.key, m[tc.key], tc.wantKey)
+			}
+			if tc.key != "key" && m["key"] != nil {
+				t.Errorf("full key %v logged", m["key"])
+			}
+			if tc.key == "" && m["key_prefix"] != nil {
+				t.Errorf("key_prefix = %v, want none", m["key_prefix"])
+			}
+			if wantErr := tc.level == "WARN"; (m["error"] != nil) != wantErr {
+				t.Errorf("error = %v, want it logged: %v", m["error"], wantErr)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	get := redis.NewStringCmd(ctx, "get", "a")
+	set := redis.NewStatusCmd(ctx, "set", "b", "1")
+	incr := redis.NewIntCmd(ctx, "incr", "c")
+	cmds := []redis.Cmder{get, set, incr}
+
+	for _, tc := range []struct {
+		name  string
+		errs  []error
+		level string
+		err   any
+	}{
+		{"ok", []error{nil, nil, nil}, "DEBUG", nil},
+		{"nil reply", []error{redis.Nil, nil, nil}, "DEBUG", nil},
+		{"command error", []error{redis.Nil, errors.New("WRONGTYPE"), nil}, "WARN", "WRONGTYPE"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, buf := newTest(t)
+			h.ProcessPipelineHook(func(context.Context, []redis.Cmder) error {
+				for i, cmd := range cmds {
+					cmd.SetErr(tc.errs[i])
+				}
+				return nil
+			})(ctx, cmds)
+
+			m := decode(t, buf)
+			if m["msg"] != "redis pipeline" || m["level"] != tc.level {
+				t.Errorf("record = %v, want %s redis pipeline", m, tc.level)
+			}
+			if m["commands"] != 3.0 || m["command_names"] != "get,set,incr" {
+				t.Errorf("commands = %v %v, want 3 get,set,incr", m["commands"], m["command_names"])
+			}
+			if m["error"] != tc.err {
+				t.Errorf("error = %v, want %v", m["error"], tc.err)
+			}
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHook(slog.New(slog.NewJSONHandler(&buf, nil)))
+	process(h, redis.NewStringCmd(context.Background(), "get", "a:b"), nil)
+	if buf.Len() != 0 {
+		t.Errorf("Debug record written at Info: %s", buf.String())
+	}
+	process(h, redis.NewStringCmd(context.Background(), "get", "a:b"), errors.New("boom"))
+	if buf.Len() == 0 {
+		t.Error("failed command not logged at Info")
+	}
+}
+
+func TestClientHook(t *testing.T) {
+	// No server listens on the address; the dial error reaches the hook.
+	h, buf := newTest(t)
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+	rdb.AddHook(h)
+
+	if err := rdb.Get(context.Background(), "session:abc").Err(); err == nil {
+		t.Fatal("Get succeeded without a server")
+	}
+	m := decode(t, buf)
+	if m["level"] != "WARN" || m["command"] != "get" || m["key_prefix"] != "session:" {
+		t.Errorf("record = %v, want WARN get session:", m)
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RepeatOptions configures a RepeatHandler.
+type RepeatOptions struct {
+	// Interval is the time between summaries of repeated records. It defaults
+	// to 30 seconds.
+	Interval time.Duration
+	// MaxMessages bounds the number of messages tracked at once. Records with
+	// other messages are passed through while it is reached. It defaults to
+	// 1000.
+	MaxMessages int
+}
+
+// RepeatHandler suppresses repeated records like syslog does: the first record
+// with a message and level is passed to the next handler, and repeats of it are
+// counted instead. Every interval, the next handler receives a record like
+// "last message repeated 412 times" at the same level for each message that was
+// repeated, with the message in a "message" attr. Messages that weren't
+// repeated during an interval are forgotten, so they pass through again.
+//
+// A RepeatHandler implements Flusher, so it can be passed to RegisterFlusher,
+// and must be closed to stop its background goroutine.
+type RepeatHandler struct {
+	next slog.Handler
+	r    *repeats
+}
+
+type repeatKey struct {
+	level slog.Level
+	msg   string
+}
+
+type repeatEntry struct {
+	// h is the handler that received the first record.
+	h     slog.Handler
+	count int
+}
+
+// repeats is shared with the handlers derived from a RepeatHandler.
+type repeats struct {
+	m        sync.Mutex
+	entries  map[repeatKey]*repeatEntry
+	max      int
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRepeatHandler returns a RepeatHandler passing records to next.
+func NewRepeatHandler(next slog.Handler, opts *RepeatOptions) *RepeatHandler {
+	var o RepeatOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = 30 * time.Second
+	}
+	if o.MaxMessages <= 0 {
+		o.MaxMessages = 1000
+	}
+	r := &repeats{
+		entries: make(map[repeatKey]*repeatEntry),
+		max:     o.MaxMessages,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	ticks, stop := newRepeatTicker(o.Interval)
+	go r.summarizeEvery(ticks, stop)
+	return &RepeatHandler{next: next, r: r}
+}
+
+// newRepeatTicker returns the ticks of a time.Ticker ticking every d and a func
+// stopping it. Tests replace it to tick when they want.
+var newRepeatTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// summarizeEvery writes the summaries at every tick until the handler is
+// closed, then calls stop.
+func (r *repeats) summarizeEvery(ticks <-chan time.Time, stop func()) {
+	defer close(r.done)
+	defer stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticks:
+			r.summarize()
+		}
+	}
+}
+
+func (h *RepeatHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RepeatHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RepeatHandler{next: h.next.WithAttrs(attrs), r: h.r}
+}
+
+func (h *RepeatHandler) WithGroup(name string) slog.Handler {
+	return &RepeatHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+func (h *RepeatHandler) Handle(ctx context.Context, r slog.Record) error {
+	k := repeatKey{r.Level, r.Message}
+	h.r.m.Lock()
+	if e, ok := h.r.entries[k]; ok {
+		e.count++
+		h.r.m.Unlock()
+		return nil
+	}
+	if len(h.r.entries) < h.r.max {
+		h.r.entries[k] = &repeatEntry{h: h.next}
+	}
+	h.r.m.Unlock()
+	return h.next.Handle(ctx, r)
+}
+
+// summarize writes the summaries of the repeated records and forgets the
+// messages that weren't repeated.
+func (r *repeats) summarize() error {
+	type summary struct {
+		repeatKey
+		h     slog.Handler
+		count int
+	}
+	var summaries []summary
+	r.m.Lock()
+	for k, e := range r.entries {
+		if e.count == 0 {
+			delete(r.entries, k)
+			continue
+		}
+		summaries = append(summaries, summary{k, e.h, e.count})
+		e.count = 0
+	}
+	r.m.Unlock()
+
+	var firstErr error
+	for _, s := range summaries {
+		rec := slog.NewRecord(time.Now(), s.level, fmt.Sprintf("last message repeated %d times", s.count), 0)
+		rec.AddAttrs(slog.String("message", s.msg))
+		if err := s.h.Handle(context.Background(), rec); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush writes the summaries of the records repeated since the last summary.
+func (h *RepeatHandler) Flush(context.Context) error {
+	return h.r.summarize()
+}
+
+// Close stops the background goroutine and writes the pending summaries.
+func (h *RepeatHandler) Close() error {
+	h.r.stopOnce.Do(func() { close(h.r.stop) })
+	<-h.r.done
+	return h.r.summarize()
+}
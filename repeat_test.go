@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeRepeatTicker replaces the ticker of RepeatHandlers for the test with
+// ticks, and reports the interval asked for and whether the ticker was stopped.
+func fakeRepeatTicker(t *testing.T) (ticks chan time.Time, interval *time.Duration, stopped *bool) {
+	ticks, interval, stopped = make(chan time.Time), new(time.Duration), new(bool)
+	saved := newRepeatTicker
+	t.Cleanup(func() { newRepeatTicker = saved })
+	newRepeatTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		*interval = d
+		return ticks, func() { *stopped = true }
+	}
+	return ticks, interval, stopped
+}
+
+// expectLines receives the next lines written to w, without their timestamps.
+func expectLines(t *testing.T, w lineWriter, want ...string) {
+	t.Helper()
+	for _, want := range want {
+		select {
+		case got := <-w:
+			if got = timeRE.ReplaceAllString(got, ""); got != want+"\n" {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no line written, want %q", want)
+		}
+	}
+	select {
+	case got := <-w:
+		t.Errorf("got extra line %q", got)
+	default:
+	}
+}
+
+func TestRepeatHandler(t *testing.T) {
+	ticks, interval, stopped := fakeRepeatTicker(t)
+	w := make(lineWriter, 16)
+	h := NewRepeatHandler(NewHandler(&HandlerOptions{Writer: w}), nil)
+	if *interval != 30*time.Second {
+		t.Errorf("interval %v, want the default of 30s", *interval)
+	}
+	l := slog.New(h)
+
+	// The first record passes through, and its repeats are counted.
+	l.With("disk", "sda").Warn("disk full", "free", 0)
+	l.Warn("disk full", "free", 1)
+	l.Warn("disk full", "free", 2)
+	l.Info("disk full")
+	expectLines(t, w, `WARN: disk full {"disk":"sda","free":0}`, `INFO: disk full {}`)
+
+	// The summary goes to the handler of the first record.
+	ticks <- time.Now()
+	expectLines(t, w, `WARN: last message repeated 2 times {"disk":"sda","message":"disk full"}`)
+
+	// Messages not repeated during an interval are forgotten by the time the
+	// summaries of the others are written.
+	l.Info("retrying")
+	l.Info("retrying")
+	expectLines(t, w, `INFO: retrying {}`)
+	ticks <- time.Now()
+	expectLines(t, w, `INFO: last message repeated 1 times {"message":"retrying"}`)
+	l.Warn("disk full")
+	expectLines(t, w, `WARN: disk full {}`)
+
+	// Close writes the pending summaries and stops the ticker.
+	l.Warn("disk full")
+	l.Warn("disk full")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, w, `WARN: last message repeated 2 times {"message":"disk full"}`)
+	if !*stopped {
+		t.Error("Close didn't stop the ticker")
+	}
+}
+
+func TestRepeatHandlerMaxMessages(t *testing.T) {
+	fakeRepeatTicker(t)
+	w := make(lineWriter, 16)
+	h := NewRepeatHandler(NewHandler(&HandlerOptions{Writer: w}), &RepeatOptions{Interval: time.Minute, MaxMessages: 1})
+	defer h.Close()
+	l := slog.New(h)
+
+	// Records with other messages pass through while one is tracked.
+	for range 2 {
+		l.Info("tracked")
+		l.Info("untracked")
+	}
+	expectLines(t, w, `INFO: tracked {}`, `INFO: untracked {}`, `INFO: untracked {}`)
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, w, `INFO: last message repeated 1 times {"message":"tracked"}`)
+}
@@ -0,0 +1,347 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Recorder writes records as NDJSON that Replay feeds to another handler, for
+// reproducing formatting bugs and load-testing sinks with a real log stream.
+// The level, time, message and attrs of records are kept with their kinds, and
+// the attrs and groups of WithAttrs and WithGroup are nested as the receiving
+// handler would see them.
+//
+// Values of kind Any are kept in their JSON encoding, and replayed as decoded
+// by encoding/json with numbers as json.Number. Errors are replayed as errors
+// with the same message, and values JSON can't encode as strings. Times keep
+// their offset but not the name of their location, and the location of the
+// logging call isn't kept.
+type Recorder struct {
+	s   *recorderState
+	ops []recorderOp
+}
+
+type recorderState struct {
+	m   sync.Mutex
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// recorderOp is a WithAttrs or WithGroup call applied to a Recorder.
+type recorderOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+// recordedRecord is a line written by a Recorder.
+type recordedRecord struct {
+	Time  string         `json:"time,omitempty"`
+	Level slog.Level     `json:"level"`
+	Msg   string         `json:"msg"`
+	Attrs []recordedAttr `json:"attrs,omitempty"`
+}
+
+type recordedAttr struct {
+	Key   string          `json:"k"`
+	Kind  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// NewRecorder returns a Recorder writing to w. It records all levels.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{s: &recorderState{w: w}}
+}
+
+func (rec *Recorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (rec *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return rec
+	}
+	return &Recorder{s: rec.s, ops: append(slices.Clip(rec.ops), recorderOp{attrs: resolveAttrs(attrs)})}
+}
+
+func (rec *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return rec
+	}
+	return &Recorder{s: rec.s, ops: append(slices.Clip(rec.ops), recorderOp{group: name})}
+}
+
+func (rec *Recorder) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs = resolveAttrs(attrs)
+	for i := len(rec.ops) - 1; i >= 0; i-- {
+		o := rec.ops[i]
+		switch {
+		case o.group == "":
+			attrs = append(slices.Clone(o.attrs), attrs...)
+		case len(attrs) > 0:
+			attrs = []slog.Attr{{Key: o.group, Value: slog.GroupValue(attrs...)}}
+		}
+	}
+
+	out := recordedRecord{Level: r.Level, Msg: r.Message}
+	if !r.Time.IsZero() {
+		out.Time = r.Time.Format(time.RFC3339Nano)
+	}
+	var err error
+	if out.Attrs, err = recordAttrs(attrs); err != nil {
+		return err
+	}
+
+	rec.s.m.Lock()
+	defer rec.s.m.Unlock()
+	rec.s.buf.Reset()
+	if err := json.NewEncoder(&rec.s.buf).Encode(out); err != nil {
+		return err
+	}
+	_, err = rec.s.w.Write(rec.s.buf.Bytes())
+	return err
+}
+
+// resolveAttrs returns a copy of attrs with LogValuers resolved, also in
+// groups.
+func resolveAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(resolveAttrs(a.Value.Group())...)
+		}
+		out[i] = a
+	}
+	return out
+}
+
+func recordAttrs(attrs []slog.Attr) ([]recordedAttr, error) {
+	out := make([]recordedAttr, 0, len(attrs))
+	for _, a := range attrs {
+		ra, err := recordAttr(a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ra)
+	}
+	return out, nil
+}
+
+func recordAttr(a slog.Attr) (recordedAttr, error) {
+	v := a.Value
+	ra := recordedAttr{Key: a.Key, Kind: v.Kind().String()}
+	var value any
+	switch v.Kind() {
+	case slog.KindString:
+		value = v.String()
+	case slog.KindInt64:
+		value = v.Int64()
+	case slog.KindUint64:
+		value = v.Uint64()
+	case slog.KindFloat64:
+		// Strings keep NaN and infinities.
+		value = strconv.FormatFloat(v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		value = v.Bool()
+	case slog.KindDuration:
+		value = int64(v.Duration())
+	case slog.KindTime:
+		value = v.Time().Format(time.RFC3339Nano)
+	case slog.KindGroup:
+		group, err := recordAttrs(v.Group())
+		if err != nil {
+			return ra, err
+		}
+		value = group
+	default:
+		if err, ok := v.Any().(error); ok {
+			ra.Kind, value = "error", err.Error()
+			break
+		}
+		data, err := json.Marshal(v.Any())
+		if err != nil {
+			ra.Kind, value = slog.KindString.String(), fmt.Sprint(v.Any())
+			break
+		}
+		ra.Value = data
+		return ra, nil
+	}
+	data, err := json.Marshal(value)
+	ra.Value = data
+	return ra, err
+}
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Context is passed to the handler and stops the replay when it is done.
+	// It defaults to context.Background().
+	Context context.Context
+	// Speed keeps the gaps between the times of records, divided by Speed: 1
+	// replays in real time and 10 ten times as fast. Zero replays as fast as
+	// possible.
+	Speed float64
+}
+
+// Replay reads the records written by a Recorder from r and passes those h is
+// enabled for to h, with their original times. It stops at the first invalid
+// line or error of h.
+func Replay(r io.Reader, h slog.Handler, opts ReplayOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	br := bufio.NewReader(r)
+	var first, start time.Time
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		rec, perr := parseRecorded(line)
+		if perr != nil {
+			return fmt.Errorf("line %d: %w", n, perr)
+		}
+		if opts.Speed > 0 && !rec.Time.IsZero() {
+			if first.IsZero() {
+				first, start = rec.Time, time.Now()
+			}
+			wait := time.Until(start.Add(time.Duration(float64(rec.Time.Sub(first)) / opts.Speed)))
+			if wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				case <-t.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if h.Enabled(ctx, rec.Level) {
+			if herr := h.Handle(ctx, rec); herr != nil {
+				return fmt.Errorf("line %d: %w", n, herr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func parseRecorded(line []byte) (slog.Record, error) {
+	var in recordedRecord
+	if err := json.Unmarshal(line, &in); err != nil {
+		return slog.Record{}, err
+	}
+	var t time.Time
+	if in.Time != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, in.Time); err != nil {
+			return slog.Record{}, err
+		}
+	}
+	attrs, err := replayAttrs(in.Attrs)
+	if err != nil {
+		return slog.Record{}, err
+	}
+	r := slog.NewRecord(t, in.Level, in.Msg, 0)
+	r.AddAttrs(attrs...)
+	return r, nil
+}
+
+func replayAttrs(in []recordedAttr) ([]slog.Attr, error) {
+	attrs := make([]slog.Attr, 0, len(in))
+	for _, ra := range in {
+		v, err := replayValue(ra)
+		if err != nil {
+			return nil, fmt.Errorf("attr %q: %w", ra.Key, err)
+		}
+		attrs = append(attrs, slog.Attr{Key: ra.Key, Value: v})
+	}
+	return attrs, nil
+}
+
+func replayValue(ra recordedAttr) (slog.Value, error) {
+	var err error
+	switch ra.Kind {
+	case slog.KindString.String():
+		var s string
+		err = json.Unmarshal(ra.Value, &s)
+		return slog.StringValue(s), err
+	case slog.KindInt64.String():
+		var i int64
+		err = json.Unmarshal(ra.Value, &i)
+		return slog.Int64Value(i), err
+	case slog.KindUint64.String():
+		var u uint64
+		err = json.Unmarshal(ra.Value, &u)
+		return slog.Uint64Value(u), err
+	case slog.KindFloat64.String():
+		var s string
+		if err = json.Unmarshal(ra.Value, &s); err != nil {
+			return slog.Value{}, err
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return slog.Float64Value(f), err
+	case slog.KindBool.String():
+		var b bool
+		err = json.Unmarshal(ra.Value, &b)
+		return slog.BoolValue(b), err
+	case slog.KindDuration.String():
+		var d int64
+		err = json.Unmarshal(ra.Value, &d)
+		return slog.DurationValue(time.Duration(d)), err
+	case slog.KindTime.String():
+		var s string
+		if err = json.Unmarshal(ra.Value, &s); err != nil {
+			return slog.Value{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return slog.TimeValue(t), err
+	case slog.KindGroup.String():
+		var group []recordedAttr
+		if err = json.Unmarshal(ra.Value, &group); err != nil {
+			return slog.Value{}, err
+		}
+		attrs, err := replayAttrs(group)
+		return slog.GroupValue(attrs...), err
+	case "error":
+		var s string
+		err = json.Unmarshal(ra.Value, &s)
+		return slog.AnyValue(errors.New(s)), err
+	case slog.KindAny.String():
+		dec := json.NewDecoder(bytes.NewReader(ra.Value))
+		dec.UseNumber()
+		var v any
+		err = dec.Decode(&v)
+		return slog.AnyValue(v), err
+	}
+	return slog.Value{}, fmt.Errorf("unknown kind %q", ra.Kind)
+}
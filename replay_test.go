@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type replayPoint struct{ X, Y int }
+
+type replayUser struct{ name string }
+
+func (u replayUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name))
+}
+
+// logReplayRecords logs records with attrs of every kind, in groups and with
+// the attrs and groups of With and WithGroup.
+func logReplayRecords(l *slog.Logger) {
+	at := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.FixedZone("", 2*60*60))
+	l.Debug("plain")
+	l.Info("kinds",
+		"s", "text\n\"quoted\"",
+		"i", -42,
+		"u", uint64(math.MaxUint64),
+		"f", 1.5,
+		"b", true,
+		"d", 1500*time.Millisecond,
+		"t", at,
+		"err", errors.New("boom"),
+		"point", replayPoint{1, 2},
+		"list", []string{"a", "b"},
+		"user", replayUser{"ann"},
+	)
+	l = l.With("service", "api").WithGroup("req").With("id", 7)
+	l.Warn("with", slog.Group("inner", "k", "v", slog.Group("deep", "n", 1)))
+	l.WithGroup("empty").Error("empty group")
+}
+
+func TestReplay(t *testing.T) {
+	// Replaying into a handler gives the output of logging to it directly, but
+	// for the times of records, which the recording below checks.
+	var want, got, recorded bytes.Buffer
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+	logReplayRecords(slog.New(slog.NewJSONHandler(&want, opts)))
+	logReplayRecords(slog.New(NewRecorder(&recorded)))
+	if err := Replay(bytes.NewReader(recorded.Bytes()), slog.NewJSONHandler(&got, opts), ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("got\n%s\nwant\n%s", got.String(), want.String())
+	}
+
+	// Recording a replay gives the same recording.
+	var again bytes.Buffer
+	if err := Replay(bytes.NewReader(recorded.Bytes()), NewRecorder(&again), ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != recorded.String() {
+		t.Fatalf("got\n%s\nwant\n%s", again.String(), recorded.String())
+	}
+
+	// Only enabled records are replayed.
+	got.Reset()
+	if err := Replay(bytes.NewReader(recorded.Bytes()), slog.NewJSONHandler(&got, &slog.HandlerOptions{ReplaceAttr: opts.ReplaceAttr}), ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got.String(), "\n"); n != 3 || strings.Contains(got.String(), `"plain"`) {
+		t.Fatalf("got %d records:\n%s", n, got.String())
+	}
+}
+
+func TestReplayKinds(t *testing.T) {
+	var recorded bytes.Buffer
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "floats", 0)
+	r.AddAttrs(
+		slog.Float64("nan", math.NaN()),
+		slog.Float64("inf", math.Inf(-1)),
+		slog.Any("ch", make(chan int)),
+		slog.Any("m", map[string]any{"n": uint64(math.MaxUint64)}),
+	)
+	if err := NewRecorder(&recorded).Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &captureHandler{}
+	if err := Replay(&recorded, h, ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records", len(h.records))
+	}
+	got := h.records[0]
+	if !got.Time.IsZero() {
+		t.Errorf("time: got %v, want zero", got.Time)
+	}
+	attrs := map[string]slog.Value{}
+	got.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	if v := attrs["nan"]; v.Kind() != slog.KindFloat64 || !math.IsNaN(v.Float64()) {
+		t.Errorf("nan: got %v", v)
+	}
+	if v := attrs["inf"]; v.Kind() != slog.KindFloat64 || !math.IsInf(v.Float64(), -1) {
+		t.Errorf("inf: got %v", v)
+	}
+	if v := attrs["ch"]; v.Kind() != slog.KindString || !strings.HasPrefix(v.String(), "0x") {
+		t.Errorf("ch: got %v", v)
+	}
+	// Numbers in values of kind Any keep their precision.
+	if v, ok := attrs["m"].Any().(map[string]any); !ok || v["n"] != json.Number("18446744073709551615") {
+		t.Errorf("m: got %v", attrs["m"])
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	valid := `{"level":"INFO","msg":"ok"}` + "\n"
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"invalid JSON", valid + "{\n", "line 2:"},
+		{"invalid time", `{"time":"yesterday","level":"INFO","msg":"x"}`, "line 1:"},
+		{"unknown kind", `{"level":"INFO","msg":"x","attrs":[{"k":"a","t":"Complex","v":1}]}`, `attr "a": unknown kind "Complex"`},
+		{"wrong value", `{"level":"INFO","msg":"x","attrs":[{"k":"g","t":"Group","v":[{"k":"a","t":"Int64","v":"1"}]}]}`, `attr "g": attr "a":`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &captureHandler{}
+			err := Replay(strings.NewReader(tc.in), h, ReplayOptions{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	// Errors of the handler stop the replay.
+	herr := errors.New("sink down")
+	err := Replay(strings.NewReader(valid+valid), &captureHandler{err: herr}, ReplayOptions{})
+	if !errors.Is(err, herr) || !strings.HasPrefix(err.Error(), "line 1:") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestReplaySpeed(t *testing.T) {
+	start := time.Now()
+	in := `{"time":"2024-01-01T00:00:00Z","level":"INFO","msg":"a"}
+{"time":"2024-01-01T00:00:10Z","level":"INFO","msg":"b"}
+`
+	// Ten seconds at a speed of 100 take 100ms.
+	h := &captureHandler{}
+	if err := Replay(strings.NewReader(in), h, ReplayOptions{Speed: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("replayed in %v", d)
+	}
+	if len(h.records) != 2 {
+		t.Fatalf("got %d records", len(h.records))
+	}
+
+	// A done context stops the wait.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	h = &captureHandler{}
+	err := Replay(strings.NewReader(in), h, ReplayOptions{Context: ctx, Speed: 1})
+	if !errors.Is(err, context.DeadlineExceeded) || len(h.records) != 1 {
+		t.Fatalf("got %v and %d records", err, len(h.records))
+	}
+}
+
+// captureHandler keeps the records it handles, or fails with err.
+type captureHandler struct {
+	records []*slog.Record
+	err     error
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	if h.err != nil {
+		return h.err
+	}
+	h.records = append(h.records, &r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
@@ -0,0 +1,96 @@
+package logger
+
+import "math"
+
+// SecretScan flags string values that look like secrets, such as API keys and
+// tokens, by their characters and Shannon entropy. It is a safety net for
+// secrets logged under keys that don't give them away.
+//
+// Values are suspect when their characters vary about as much as random ones
+// would and they consist of base64 or URL-safe base64 characters, including
+// upper and lower case letters and at least two digits or a digit and one of
+// "+/=", or of at least 32 hex digits. UUIDs, identifiers, paths and prose
+// aren't suspect, but hashes and UUIDs without dashes look like hex keys.
+type SecretScan struct {
+	// MinLength is the length below which values aren't scanned. It defaults to
+	// 20.
+	MinLength int
+	// Mask replaces suspect values with "[REDACTED]". Otherwise the record gets a
+	// "possible_secret_keys" attr listing the keys of suspect values, with the
+	// groups they are in separated by dots.
+	Mask bool
+}
+
+const (
+	// maxSecretLength is the length above which values aren't scanned, as they
+	// are rather payloads than secrets.
+	maxSecretLength = 256
+	// minHexSecretLength is the length below which hex values aren't suspect.
+	minHexSecretLength = 32
+	// minHexEntropy is the entropy in bits per character at which hex values are
+	// suspect. Random hex digits have up to 4.
+	minHexEntropy = 3.5
+	// minEntropyRatio is the share of the highest possible entropy for their
+	// length at which base64 values are suspect.
+	minEntropyRatio = 0.75
+)
+
+// suspect reports whether s looks like a secret.
+func (sc *SecretScan) suspect(s string) bool {
+	minLength := sc.MinLength
+	if minLength <= 0 {
+		minLength = 20
+	}
+	if len(s) < minLength || len(s) > maxSecretLength {
+		return false
+	}
+
+	hex := true
+	var upper, lower bool
+	digits, symbols := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'z':
+			lower = true
+			hex = hex && c <= 'f'
+		case c >= 'A' && c <= 'Z':
+			upper = true
+			hex = hex && c <= 'F'
+		case c == '+' || c == '/' || c == '=':
+			symbols++
+			hex = false
+		case c == '-' || c == '_':
+			hex = false
+		default:
+			return false
+		}
+	}
+	if hex {
+		return len(s) >= minHexSecretLength && digits > 0 && (upper || lower) && entropy(s) >= minHexEntropy
+	}
+	// Identifiers like "OrderHandlerV2" have a digit at most.
+	if !upper || !lower || digits == 0 || digits+symbols < 2 {
+		return false
+	}
+	return entropy(s) >= minEntropyRatio*math.Log2(float64(min(len(s), 64)))
+}
+
+// entropy returns the Shannon entropy of the bytes of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
@@ -0,0 +1,10 @@
+module github.com/corray333/go-log/sentrylog
+
+go 1.25.2
+
+require github.com/getsentry/sentry-go v0.49.0
+
+require (
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/text v0.39.0 // indirect
+)
@@ -0,0 +1,222 @@
+// Package sentrylog sends records to Sentry: errors as events, and records
+// logged before them as breadcrumbs.
+package sentrylog
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Option configures a Sentry handler.
+type Option func(*options)
+
+type options struct {
+	eventLevel      slog.Level
+	breadcrumbLevel slog.Level
+	maxBreadcrumbs  int
+}
+
+// WithEventLevel sets the level from which records are captured as Sentry
+// events. The default is Error.
+func WithEventLevel(level slog.Level) Option {
+	return func(o *options) {
+		o.eventLevel = level
+	}
+}
+
+// WithBreadcrumbLevel sets the level from which records below the event level
+// are recorded as breadcrumbs. The default is Info.
+func WithBreadcrumbLevel(level slog.Level) Option {
+	return func(o *options) {
+		o.breadcrumbLevel = level
+	}
+}
+
+// WithMaxBreadcrumbs caps the number of breadcrumbs kept on the scope. By
+// default the MaxBreadcrumbs option of the Sentry client applies.
+func WithMaxBreadcrumbs(n int) Option {
+	return func(o *options) {
+		o.maxBreadcrumbs = n
+	}
+}
+
+type field struct {
+	key   string
+	value slog.Value
+}
+
+type handler struct {
+	next     slog.Handler
+	o        *options
+	fields   []field
+	prefix   string
+	category string
+}
+
+// NewHandler returns a handler passing records to next and reporting them to
+// Sentry. Records at the event level are captured as events on the hub of the
+// logging context, or the current hub. Records below it are added as
+// breadcrumbs to the hub of the logging context, and skipped when the context
+// has no hub. The category of events and breadcrumbs is the logger or
+// component attribute, if any.
+func NewHandler(next slog.Handler, opts ...Option) slog.Handler {
+	o := &options{
+		eventLevel:      slog.LevelError,
+		breadcrumbLevel: slog.LevelInfo,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &handler{next: next, o: o}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.fields = slices.Clip(h.fields)
+	for _, a := range attrs {
+		if h.prefix == "" && (a.Key == "logger" || a.Key == "component") {
+			h2.category = a.Value.String()
+		}
+		h2.fields = appendFields(h2.fields, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	switch {
+	case r.Level >= h.o.eventLevel:
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		h.captureEvent(hub, r)
+	case r.Level >= h.o.breadcrumbLevel:
+		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+			h.addBreadcrumb(hub, r)
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) captureEvent(hub *sentry.Hub, r slog.Record) {
+	category, data, err := h.data(r)
+
+	event := sentry.NewEvent()
+	event.Level = level(r.Level)
+	event.Message = r.Message
+	event.Timestamp = r.Time
+	event.Logger = category
+	if len(data) > 0 {
+		event.Contexts["log"] = data
+	}
+	if err != nil {
+		event.SetException(err, 10)
+	}
+	hub.CaptureEvent(event)
+}
+
+func (h *handler) addBreadcrumb(hub *sentry.Hub, r slog.Record) {
+	category, data, _ := h.data(r)
+	if category == "" {
+		category = "log"
+	}
+
+	b := &sentry.Breadcrumb{
+		Type:      "default",
+		Category:  category,
+		Message:   r.Message,
+		Data:      data,
+		Level:     level(r.Level),
+		Timestamp: r.Time,
+	}
+	if h.o.maxBreadcrumbs > 0 {
+		hub.Scope().AddBreadcrumb(b, h.o.maxBreadcrumbs)
+	} else {
+		hub.AddBreadcrumb(b, nil)
+	}
+}
+
+// data returns the category, attributes and error value of r.
+func (h *handler) data(r slog.Record) (string, map[string]any, error) {
+	category := h.category
+	fields := slices.Clip(h.fields)
+	var err error
+	r.Attrs(func(a slog.Attr) bool {
+		if h.prefix == "" && (a.Key == "logger" || a.Key == "component") {
+			category = a.Value.String()
+		}
+		if e, ok := a.Value.Resolve().Any().(error); ok && a.Key == "error" {
+			err = e
+		}
+		fields = appendFields(fields, h.prefix, a)
+		return true
+	})
+
+	data := make(map[string]any, len(fields))
+	for _, f := range fields {
+		data[f.key] = value(f.value)
+	}
+	return category, data, err
+}
+
+// appendFields flattens a into fields, joining keys of grouped attributes with dots.
+func appendFields(fields []field, prefix string, a slog.Attr) []field {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return fields
+		}
+		return append(fields, field{key: prefix + a.Key, value: v})
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range v.Group() {
+		fields = appendFields(fields, prefix, ga)
+	}
+	return fields
+}
+
+func value(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	case slog.KindDuration, slog.KindTime:
+		return v.String()
+	default:
+		return v.Any()
+	}
+}
+
+func level(l slog.Level) sentry.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return sentry.LevelDebug
+	case l < slog.LevelWarn:
+		return sentry.LevelInfo
+	case l < slog.LevelError:
+		return sentry.LevelWarning
+	case l < slog.LevelError+4:
+		return sentry.LevelError
+	default:
+		return sentry.LevelFatal
+	}
+}
@@ -0,0 +1,161 @@
+package sentrylog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// transport records the events a client sends.
+type transport struct {
+	m      sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *transport) Flush(time.Duration) bool              { return true }
+func (t *transport) FlushWithContext(context.Context) bool { return true }
+func (t *transport) Configure(sentry.ClientOptions)        {}
+func (t *transport) Close()                                {}
+
+func (t *transport) SendEvent(e *sentry.Event) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.events = append(t.events, e)
+}
+
+func (t *transport) Events() []*sentry.Event {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.events
+}
+
+// newHub returns a context carrying a hub whose client sends events to the
+// returned transport.
+func newHub(t *testing.T) (context.Context, *transport) {
+	t.Helper()
+	tr := &transport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	return sentry.SetHubOnContext(context.Background(), hub), tr
+}
+
+// next is the handler wrapped in tests. It must be enabled at every level.
+var next = slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+func TestEvent(t *testing.T) {
+	ctx, tr := newHub(t)
+	l := slog.New(NewHandler(next)).With("component", "billing").WithGroup("order")
+
+	l.ErrorContext(ctx, "charge failed", "id", 42, "error", errors.New("card declined"), "took", time.Second)
+
+	events := tr.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Message != "charge failed" || e.Level != sentry.LevelError || e.Logger != "billing" {
+		t.Errorf("event = %q %v %q, want charge failed at error from billing", e.Message, e.Level, e.Logger)
+	}
+	data := e.Contexts["log"]
+	for k, want := range map[string]any{"component": "billing", "order.id": int64(42), "order.error": "card declined", "order.took": "1s"} {
+		if data[k] != want {
+			t.Errorf("log.%s = %v (%T), want %v", k, data[k], data[k], want)
+		}
+	}
+	if len(e.Exception) == 0 || e.Exception[len(e.Exception)-1].Value != "card declined" {
+		t.Errorf("exception = %+v, want card declined", e.Exception)
+	}
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	ctx, tr := newHub(t)
+	l := slog.New(NewHandler(next))
+
+	l.DebugContext(ctx, "below the breadcrumb level")
+	l.InfoContext(ctx, "cart loaded", "items", 3)
+	l.With("logger", "payments").WarnContext(ctx, "retrying charge")
+	l.ErrorContext(ctx, "charge failed")
+
+	events := tr.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	if len(crumbs) != 2 {
+		t.Fatalf("got %d breadcrumbs, want 2: %+v", len(crumbs), crumbs)
+	}
+	if b := crumbs[0]; b.Message != "cart loaded" || b.Category != "log" || b.Level != sentry.LevelInfo || b.Data["items"] != int64(3) {
+		t.Errorf("breadcrumb 0 = %+v", b)
+	}
+	if b := crumbs[1]; b.Message != "retrying charge" || b.Category != "payments" || b.Level != sentry.LevelWarning {
+		t.Errorf("breadcrumb 1 = %+v", b)
+	}
+}
+
+func TestMaxBreadcrumbs(t *testing.T) {
+	ctx, tr := newHub(t)
+	l := slog.New(NewHandler(next, WithMaxBreadcrumbs(2)))
+	for _, msg := range []string{"one", "two", "three"} {
+		l.InfoContext(ctx, msg)
+	}
+	l.ErrorContext(ctx, "failed")
+
+	crumbs := tr.Events()[0].Breadcrumbs
+	if len(crumbs) != 2 || crumbs[0].Message != "two" || crumbs[1].Message != "three" {
+		t.Errorf("breadcrumbs = %+v, want the last two", crumbs)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	ctx, tr := newHub(t)
+	l := slog.New(NewHandler(next, WithEventLevel(slog.LevelWarn), WithBreadcrumbLevel(slog.LevelDebug)))
+	l.DebugContext(ctx, "debug")
+	l.WarnContext(ctx, "warned")
+	l.Log(ctx, slog.LevelError+4, "fatal")
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Level != sentry.LevelWarning || events[1].Level != sentry.LevelFatal {
+		t.Errorf("levels = %v %v, want warning and fatal", events[0].Level, events[1].Level)
+	}
+	if crumbs := events[0].Breadcrumbs; len(crumbs) != 1 || crumbs[0].Level != sentry.LevelDebug {
+		t.Errorf("breadcrumbs = %+v, want the debug record", crumbs)
+	}
+}
+
+func TestNoHub(t *testing.T) {
+	// Breadcrumbs are skipped without a hub in the context; events go to the current hub.
+	tr := &transport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.CurrentHub()
+	prev := hub.Client()
+	hub.BindClient(client)
+	defer hub.BindClient(prev)
+	hub.Scope().ClearBreadcrumbs()
+
+	l := slog.New(NewHandler(next))
+	l.Info("no hub")
+	l.Error("failed")
+
+	events := tr.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if len(events[0].Breadcrumbs) != 0 {
+		t.Errorf("breadcrumbs = %+v, want none", events[0].Breadcrumbs)
+	}
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// InstrumentServer makes srv log through l. It sets ErrorLog to a NewStdLogger
+// logging at Error level with component=http.Server, a ConnState hook logging
+// connection state changes at Debug level, and a BaseContext carrying l for
+// FromContext. Fields that are already set are left untouched.
+func InstrumentServer(srv *http.Server, l *slog.Logger) {
+	l = l.With(slog.String("component", "http.Server"))
+
+	if srv.ErrorLog == nil {
+		srv.ErrorLog = NewStdLogger(l, slog.LevelError)
+	}
+	if srv.ConnState == nil {
+		srv.ConnState = func(conn net.Conn, state http.ConnState) {
+			l.Debug("connection state changed",
+				slog.String("remote_addr", conn.RemoteAddr().String()),
+				slog.String("state", state.String()),
+			)
+		}
+	}
+	if srv.BaseContext == nil {
+		srv.BaseContext = func(net.Listener) context.Context {
+			return IntoContext(context.Background(), l)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentServerTLSError(t *testing.T) {
+	records := newChanHandler(64)
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	InstrumentServer(srv.Config, slog.New(records))
+	srv.StartTLS()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	conn.Close()
+
+	r := records.wait(t, func(r slog.Record) bool {
+		return strings.HasPrefix(r.Message, "TLS handshake error")
+	})
+	if r.Level != slog.LevelError {
+		t.Errorf("level = %v, want ERROR", r.Level)
+	}
+	for key, want := range map[string]string{"component": "http.Server", "subsystem": "http"} {
+		if v, _ := findAttr(r, key); v.String() != want {
+			t.Errorf("%s = %v, want %s", key, v, want)
+		}
+	}
+}
+
+func TestInstrumentServerConnStateAndContext(t *testing.T) {
+	records := newChanHandler(64)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("in handler")
+	}))
+	InstrumentServer(srv.Config, slog.New(records))
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	r := records.wait(t, func(r slog.Record) bool {
+		return r.Message == "connection state changed"
+	})
+	if r.Level != slog.LevelDebug {
+		t.Errorf("level = %v, want DEBUG", r.Level)
+	}
+	if v, _ := findAttr(r, "state"); v.String() != "new" {
+		t.Errorf("state = %v, want new", v)
+	}
+	if v, ok := findAttr(r, "remote_addr"); !ok || v.String() == "" {
+		t.Error("remote_addr missing")
+	}
+
+	// The handler logs through the logger of its request context.
+	r = records.wait(t, func(r slog.Record) bool { return r.Message == "in handler" })
+	if v, _ := findAttr(r, "component"); v.String() != "http.Server" {
+		t.Errorf("component = %v, want http.Server", v)
+	}
+}
+
+func TestInstrumentServerKeepsFields(t *testing.T) {
+	errorLog := log.New(nil, "", 0)
+	var connState, baseContext bool
+	srv := &http.Server{
+		ErrorLog:    errorLog,
+		ConnState:   func(net.Conn, http.ConnState) { connState = true },
+		BaseContext: func(net.Listener) context.Context { baseContext = true; return context.Background() },
+	}
+	InstrumentServer(srv, slog.New(slog.DiscardHandler))
+
+	if srv.ErrorLog != errorLog {
+		t.Error("ErrorLog replaced")
+	}
+	srv.ConnState(nil, http.StateNew)
+	srv.BaseContext(nil)
+	if !connState || !baseContext {
+		t.Error("ConnState or BaseContext replaced")
+	}
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bytes"
+	"slices"
+)
+
+// field is a field of a rendered JSON object.
+type field struct {
+	key   []byte
+	value []byte
+}
+
+// appendSorted appends the JSON object obj with its fields, and those of the
+// objects nested in it, sorted by key. Fields with the same key keep their order.
+// obj must be valid JSON as written by attrEncoder.
+func appendSorted(b, obj []byte) []byte {
+	var fields []field
+	for i := 1; obj[i] != '}'; {
+		if obj[i] == ',' {
+			i++
+		}
+		keyEnd := skipValue(obj, i)
+		valueEnd := skipValue(obj, keyEnd+1)
+		fields = append(fields, field{key: obj[i:keyEnd], value: obj[keyEnd+1 : valueEnd]})
+		i = valueEnd
+	}
+	slices.SortStableFunc(fields, func(a, b field) int {
+		return bytes.Compare(a.key, b.key)
+	})
+
+	b = append(b, '{')
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, f.key...)
+		b = append(b, ':')
+		if f.value[0] == '{' {
+			b = appendSorted(b, f.value)
+		} else {
+			b = append(b, f.value...)
+		}
+	}
+	return append(b, '}')
+}
+
+// skipValue returns the end of the JSON value starting at obj[i].
+func skipValue(obj []byte, i int) int {
+	depth := 0
+	for inString := false; ; i++ {
+		switch c := obj[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case c == ',' && depth == 0:
+			return i
+		}
+	}
+}
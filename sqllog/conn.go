@@ -0,0 +1,246 @@
+package sqllog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+type conn struct {
+	c driver.Conn
+	l *sqlLogger
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.c.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.c.Prepare(query)
+	}
+	c.l.logOp(ctx, "prepare", query, nil, start, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{s: s, c: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return c.c.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var t driver.Tx
+	var err error
+	if bt, ok := c.c.(driver.ConnBeginTx); ok {
+		t, err = bt.BeginTx(ctx, opts)
+	} else {
+		t, err = c.c.Begin()
+	}
+	c.l.logOp(ctx, "begin", "", nil, start, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{t: t, l: c.l, ctx: ctx}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	switch e := c.c.(type) {
+	case driver.ExecerContext:
+		res, err = e.ExecContext(ctx, query, args)
+	case driver.Execer:
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = e.Exec(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.l.logOp(ctx, "exec", query, args, start, res, err)
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	switch q := c.c.(type) {
+	case driver.QueryerContext:
+		rows, err = q.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = q.Query(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.l.logOp(ctx, "query", query, args, start, nil, err)
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.c.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.c.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.c.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue defers to the wrapped connection, or to the default
+// conversion of database/sql when it doesn't check values itself.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.c.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	s     driver.Stmt
+	c     *conn
+	query string
+}
+
+var (
+	_ driver.Stmt              = (*stmt)(nil)
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error {
+	return s.s.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.s.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valueArgs(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valueArgs(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if e, ok := s.s.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = s.s.Exec(values)
+		}
+	}
+	s.c.l.logOp(ctx, "exec", s.query, args, start, res, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.s.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.s.Query(values)
+		}
+	}
+	s.c.l.logOp(ctx, "query", s.query, args, start, nil, err)
+	return rows, err
+}
+
+// CheckNamedValue defers to the wrapped statement or else its connection, like
+// database/sql does for unwrapped drivers.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.s.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.c.CheckNamedValue(nv)
+}
+
+type tx struct {
+	t   driver.Tx
+	l   *sqlLogger
+	ctx context.Context
+}
+
+func (t *tx) Commit() error {
+	start := time.Now()
+	err := t.t.Commit()
+	t.l.logOp(t.ctx, "commit", "", nil, start, nil, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	start := time.Now()
+	err := t.t.Rollback()
+	t.l.logOp(t.ctx, "rollback", "", nil, start, nil, err)
+	return err
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sqllog: driver does not support named arguments")
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
+
+func valueArgs(values []driver.Value) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(values))
+	for i, v := range values {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return args
+}
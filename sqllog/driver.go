@@ -0,0 +1,160 @@
+// Package sqllog wraps database/sql drivers to log the statements they execute.
+package sqllog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// WrapDriver returns a driver logging the statements executed through d to log.
+// Register it under a new name and open databases with that name:
+//
+//	sql.Register("postgres-logged", sqllog.WrapDriver(&pq.Driver{}, logger))
+//
+// Statements are logged at Debug level with their normalized SQL text, the
+// number of arguments, the duration and, for Exec, the rows affected. Failed
+// statements are logged at Error level, or at Warn for bad connections and
+// cancelled contexts.
+func WrapDriver(d driver.Driver, log *slog.Logger, opts ...Option) driver.Driver {
+	return &wrappedDriver{d: d, l: newLogger(log, opts)}
+}
+
+// WrapConnector is like WrapDriver for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, log *slog.Logger, opts ...Option) driver.Connector {
+	l := newLogger(log, opts)
+	return &connector{c: c, d: &wrappedDriver{d: c.Driver(), l: l}, l: l}
+}
+
+type wrappedDriver struct {
+	d driver.Driver
+	l *sqlLogger
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c: c, l: d.l}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{c: c, d: d, l: d.l}, nil
+	}
+	return &connector{c: dsnConnector{name: name, d: d.d}, d: d, l: d.l}, nil
+}
+
+type connector struct {
+	c driver.Connector
+	d driver.Driver
+	l *sqlLogger
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c: cn, l: c.l}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
+
+// dsnConnector opens connections of drivers that don't implement driver.DriverContext.
+type dsnConnector struct {
+	name string
+	d    driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.d.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.d
+}
+
+type sqlLogger struct {
+	log *slog.Logger
+	o   *options
+}
+
+func newLogger(log *slog.Logger, opts []Option) *sqlLogger {
+	return &sqlLogger{
+		log: log.With(slog.String("component", "sql")),
+		o:   newOptions(opts),
+	}
+}
+
+// logOp logs an operation started at start. Statements the driver doesn't
+// support, reported with driver.ErrSkip, are not logged.
+func (l *sqlLogger) logOp(ctx context.Context, op, query string, args []driver.NamedValue, start time.Time, res driver.Result, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	elapsed := time.Since(start)
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelError
+		if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			level = slog.LevelWarn
+		}
+	}
+	slow := l.o.slowThreshold > 0 && elapsed > l.o.slowThreshold
+	if slow {
+		level = max(level, slog.LevelWarn)
+	}
+	if !l.log.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 8)
+	if query != "" {
+		attrs = append(attrs, slog.String("query", l.normalize(query)))
+	}
+	if args != nil {
+		attrs = append(attrs, slog.Int("args", len(args)))
+		if l.o.logArgs {
+			values := make([]any, len(args))
+			for i, a := range args {
+				values[i] = a.Value
+			}
+			attrs = append(attrs, slog.Any("arg_values", values))
+		}
+	}
+	attrs = append(attrs, slog.Duration("duration", elapsed))
+	if res != nil {
+		if n, err := res.RowsAffected(); err == nil {
+			attrs = append(attrs, slog.Int64("rows_affected", n))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+
+	l.log.LogAttrs(ctx, level, "sql "+op, attrs...)
+}
+
+// normalize collapses whitespace in query and truncates it.
+func (l *sqlLogger) normalize(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if n := l.o.maxQueryLength; n > 0 && len(query) > n {
+		query = query[:n] + "..."
+	}
+	return query
+}
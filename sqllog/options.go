@@ -0,0 +1,46 @@
+package sqllog
+
+import "time"
+
+// Option configures the logging of a wrapped driver.
+type Option func(*options)
+
+type options struct {
+	slowThreshold  time.Duration
+	logArgs        bool
+	maxQueryLength int
+}
+
+const defaultMaxQueryLength = 1024
+
+func newOptions(opts []Option) *options {
+	o := &options{maxQueryLength: defaultMaxQueryLength}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSlowQueryThreshold logs statements slower than d at Warn level with
+// slow=true instead of Debug.
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = d
+	}
+}
+
+// WithArgValues logs the values of statement arguments in an arg_values
+// attribute. By default only their number is logged.
+func WithArgValues() Option {
+	return func(o *options) {
+		o.logArgs = true
+	}
+}
+
+// WithMaxQueryLength truncates logged SQL text to n bytes. The default is 1024,
+// and 0 disables truncation.
+func WithMaxQueryLength(n int) Option {
+	return func(o *options) {
+		o.maxQueryLength = n
+	}
+}
@@ -0,0 +1,267 @@
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corray333/go-log/testutil"
+)
+
+// point is a value only the stub driver knows how to send.
+type point struct{ x, y int }
+
+// stubDriver executes nothing. Statements containing "fail" return an error,
+// "badconn" returns driver.ErrBadConn and "slow" sleeps for 20ms.
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return &stubConn{}, nil }
+
+type stubConn struct{}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	if err := stubErr(query); err != nil {
+		return nil, err
+	}
+	return &stubStmt{query: query}, nil
+}
+
+func (c *stubConn) Close() error              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) { return stubTx{}, nil }
+
+func (c *stubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := stubErr(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (c *stubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := stubErr(query); err != nil {
+		return nil, err
+	}
+	return stubRows{}, nil
+}
+
+func (c *stubConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if p, ok := nv.Value.(point); ok {
+		nv.Value = []byte{byte(p.x), byte(p.y)}
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func stubErr(query string) error {
+	switch {
+	case strings.Contains(query, "badconn"):
+		return driver.ErrBadConn
+	case strings.Contains(query, "fail"):
+		return errors.New("syntax error")
+	case strings.Contains(query, "slow"):
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil
+}
+
+type stubStmt struct{ query string }
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+
+func (s *stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	return stubRows{}, nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string         { return []string{"n"} }
+func (stubRows) Close() error              { return nil }
+func (stubRows) Next([]driver.Value) error { return io.EOF }
+
+func openDB(t *testing.T, opts ...Option) (*sql.DB, *testutil.CaptureHandler) {
+	t.Helper()
+	capture := testutil.NewCaptureHandler()
+	c, err := WrapDriver(stubDriver{}, slog.New(capture), opts...).(driver.DriverContext).OpenConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(c)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, capture
+}
+
+func TestExecQuery(t *testing.T) {
+	db, capture := openDB(t)
+
+	if _, err := db.Exec("UPDATE users\n\t SET  name = ?", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT n FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	exec := capture.Find("sql exec")
+	if len(exec) != 1 {
+		t.Fatalf("got %d exec records, want 1", len(exec))
+	}
+	r := exec[0]
+	if r.Level != slog.LevelDebug {
+		t.Errorf("level = %v, want DEBUG", r.Level)
+	}
+	for key, want := range map[string]string{
+		"query":         "UPDATE users SET name = ?",
+		"args":          "1",
+		"rows_affected": "3",
+		"component":     "sql",
+	} {
+		if v, _ := r.Attr(key); v.String() != want {
+			t.Errorf("%s = %v, want %s", key, v, want)
+		}
+	}
+	if _, ok := r.Attr("arg_values"); ok {
+		t.Error("arg_values logged without WithArgValues")
+	}
+	if _, ok := r.Attr("duration"); !ok {
+		t.Error("duration missing")
+	}
+	if len(capture.Find("sql query")) != 1 {
+		t.Error("query not logged")
+	}
+}
+
+func TestArgValues(t *testing.T) {
+	db, capture := openDB(t, WithArgValues())
+	db.Exec("INSERT INTO t VALUES (?, ?)", 1, "x")
+
+	v, _ := capture.Find("sql exec")[0].Attr("arg_values")
+	if got := v.String(); got != "[1 x]" {
+		t.Errorf("arg_values = %s, want [1 x]", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	db, capture := openDB(t)
+	db.Exec("fail")
+	db.Exec("badconn")
+
+	failed := capture.Find("sql exec")
+	if len(failed) == 0 || failed[0].Level != slog.LevelError {
+		t.Fatalf("records = %v, want an Error record first", failed)
+	}
+	if v, _ := failed[0].Attr("error"); v.String() != "syntax error" {
+		t.Errorf("error = %v, want syntax error", v)
+	}
+	// database/sql retries bad connections; each attempt is a warning.
+	for _, r := range failed[1:] {
+		if r.Level != slog.LevelWarn {
+			t.Errorf("bad connection logged at %v, want WARN", r.Level)
+		}
+	}
+	if len(failed) < 2 {
+		t.Error("bad connection not logged")
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	db, capture := openDB(t, WithSlowQueryThreshold(5*time.Millisecond))
+	db.Exec("slow")
+	db.Exec("fast")
+
+	records := capture.Find("sql exec")
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Level != slog.LevelWarn {
+		t.Errorf("slow level = %v, want WARN", records[0].Level)
+	}
+	if v, _ := records[0].Attr("slow"); !v.Bool() {
+		t.Error("slow attr missing")
+	}
+	if records[1].Level != slog.LevelDebug {
+		t.Errorf("fast level = %v, want DEBUG", records[1].Level)
+	}
+}
+
+func TestMaxQueryLength(t *testing.T) {
+	db, capture := openDB(t, WithMaxQueryLength(10))
+	db.Exec("SELECT 1234567890")
+	if v, _ := capture.Find("sql exec")[0].Attr("query"); v.String() != "SELECT 123..." {
+		t.Errorf("query = %v, want it truncated", v)
+	}
+}
+
+func TestPrepareAndTx(t *testing.T) {
+	db, capture := openDB(t)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := tx.Prepare("DELETE FROM t WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Exec(1); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []string
+	for _, r := range capture.Records() {
+		msgs = append(msgs, r.Message)
+	}
+	if got := strings.Join(msgs, ","); got != "sql begin,sql prepare,sql exec,sql commit" {
+		t.Errorf("records = %s, want begin, prepare, exec and commit", got)
+	}
+	if v, _ := capture.Find("sql exec")[0].Attr("rows_affected"); v.Int64() != 1 {
+		t.Errorf("rows_affected = %v, want 1", v)
+	}
+}
+
+func TestNamedValueChecker(t *testing.T) {
+	db, capture := openDB(t)
+	if _, err := db.Exec("INSERT INTO t VALUES (?)", point{1, 2}); err != nil {
+		t.Fatalf("custom value rejected: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO t VALUES (?)", struct{}{}); err == nil {
+		t.Error("unsupported value accepted")
+	}
+	if capture.Len() != 1 {
+		t.Errorf("got %d records, want only the successful exec", capture.Len())
+	}
+}
+
+func TestWrapDriverRegister(t *testing.T) {
+	capture := testutil.NewCaptureHandler()
+	sql.Register("sqllog-stub", WrapDriver(stubDriver{}, slog.New(capture)))
+	db, err := sql.Open("sqllog-stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Exec("SELECT 1")
+	if capture.Len() != 1 {
+		t.Errorf("got %d records, want 1", capture.Len())
+	}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxStackDepth = 32
+
+// PanicStack returns the stack of the panicking goroutine with runtime frames
+// removed. It must be called directly from the deferred function that recovered the panic.
+func PanicStack() string {
+	pcs := make([]uintptr, maxStackDepth+8)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		b     strings.Builder
+		depth int
+	)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			depth++
+		}
+		if !more || depth == maxStackDepth {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// appendStack appends the stack of the logging call that produced a record with
+// the given pc as an indented block below the record, with the function and
+// file:line of each frame on lines of their own, like panics print them. With
+// AllGoroutines, the stacks of all goroutines follow, indented the same way.
+// Without a pc, the leading frames of this package and log/slog are skipped.
+func (h *handler) appendStack(b []byte, pc uintptr) []byte {
+	pcs := make([]uintptr, 64+maxStackDepth)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	i := slices.Index(pcs, pc)
+	skipping := pc == 0 || i < 0
+	if !skipping {
+		pcs = pcs[i:]
+	}
+
+	b = append(b, "  stack:"...)
+	b = append(b, h.lineEnding...)
+	frames := runtime.CallersFrames(pcs)
+	for depth := 0; depth < maxStackDepth; {
+		frame, more := frames.Next()
+		skipping = skipping && isLoggingFrame(frame.Function)
+		if !skipping && !strings.HasPrefix(frame.Function, "runtime.") {
+			b = append(b, "    "...)
+			b = appendMessage(b, frame.Function, h.controlChars)
+			b = append(b, h.lineEnding...)
+			b = append(b, "      "...)
+			b = appendMessage(b, frame.File, h.controlChars)
+			b = append(b, ':')
+			b = strconv.AppendInt(b, int64(frame.Line), 10)
+			b = append(b, h.lineEnding...)
+			depth++
+		}
+		if !more {
+			break
+		}
+	}
+	if h.allGoroutines {
+		b = append(b, "  goroutines:"...)
+		b = append(b, h.lineEnding...)
+		for line := range strings.Lines(goroutinesStack()) {
+			if line = strings.TrimSuffix(line, "\n"); line == "" {
+				continue
+			}
+			b = append(b, "    "...)
+			b = append(b, line...)
+			b = append(b, h.lineEnding...)
+		}
+	}
+	return b
+}
+
+func isLoggingFrame(function string) bool {
+	return strings.HasPrefix(function, "github.com/corray333/go-log.") ||
+		strings.HasPrefix(function, "log/slog.") ||
+		strings.HasPrefix(function, "runtime.")
+}
+
+// goroutinesStack returns the stacks of all goroutines.
+func goroutinesStack() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 8<<20 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestStackTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, StackTraceLevel: slog.LevelError}))
+
+	l.Warn("warned")
+	if strings.Contains(buf.String(), "stack") {
+		t.Fatalf("record below StackTraceLevel got a stack: %q", buf.String())
+	}
+	buf.Reset()
+
+	l.Error("failed", "id", 1)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("got %q, want a record and a stack block", buf.String())
+	}
+	m := lineRE.FindStringSubmatch(lines[0])
+	if m == nil {
+		t.Fatalf("record line %q", lines[0])
+	}
+	var attrs map[string]any
+	if err := json.Unmarshal([]byte(m[4]), &attrs); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := attrs["stack"]; ok {
+		t.Error("stack written as an attr")
+	}
+	if lines[1] != "  stack:" {
+		t.Errorf("stack header %q", lines[1])
+	}
+	if want := "    github.com/corray333/go-log.TestStackTrace"; lines[2] != want {
+		t.Errorf("first frame %q, want %q", lines[2], want)
+	}
+	if !strings.HasPrefix(lines[3], "      ") || !strings.Contains(lines[3], "stack_test.go:") {
+		t.Errorf("first frame location %q", lines[3])
+	}
+	for _, line := range lines[1:] {
+		if strings.Contains(line, "log/slog.") {
+			t.Errorf("slog frame %q", line)
+		}
+	}
+}
+
+func TestStackTraceAllGoroutines(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, StackTraceLevel: slog.LevelError, AllGoroutines: true, LineEnding: "\r\n"}))
+	l.Error("deadlock")
+
+	out := buf.String()
+	i := strings.Index(out, "  goroutines:\r\n")
+	if i < 0 {
+		t.Fatalf("no goroutines block in %q", out)
+	}
+	if !strings.Contains(out[i:], "    goroutine ") {
+		t.Errorf("goroutines block not indented: %q", out[i:])
+	}
+	for line := range strings.Lines(out) {
+		if !strings.HasSuffix(line, "\r\n") {
+			t.Errorf("line %q doesn't end with the line ending", line)
+		}
+	}
+}
+
+func BenchmarkStackTrace(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		level slog.Level
+	}{
+		{"below level", slog.LevelInfo},
+		{"with stack", slog.LevelError},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			l := slog.New(NewHandler(&HandlerOptions{Writer: io.Discard, StackTraceLevel: slog.LevelError}))
+			b.ReportAllocs()
+			for b.Loop() {
+				l.Log(context.Background(), bc.level, "request failed", "status", 500)
+			}
+		})
+	}
+}
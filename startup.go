@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartupInfo describes a service for LogStartup. Empty fields are filled in
+// from the build info and the runtime.
+type StartupInfo struct {
+	Service string
+	// Version defaults to the version of the main module.
+	Version string
+	// Commit defaults to the VCS revision the binary was built from, with
+	// "-dirty" appended for modified trees.
+	Commit    string
+	GoVersion string
+	OS        string
+	Arch      string
+	PID       int
+	// Config holds configuration values to log. Nested maps and structs are
+	// logged and redacted like DumpConfig does: values of keys that look like
+	// secrets, like "db_password" or "api_token", and passwords in URLs are
+	// redacted.
+	Config map[string]any
+}
+
+// redacted replaces the values LogStartup redacts.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the parts of config keys whose values are redacted.
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private_key", "credential"}
+
+// LogStartup logs info at Info level. A handler from NewHandler writes it as a
+// boxed banner, leaving out the attrs added with With; other handlers receive a
+// "service started" record with the fields as attrs and the config in a
+// "config" group.
+func LogStartup(l *slog.Logger, info StartupInfo) {
+	info.fill()
+	ctx := context.Background()
+	if h, ok := l.Handler().(*handler); ok {
+		if h.Enabled(ctx, slog.LevelInfo) {
+			h.write(slog.LevelInfo, "service started", info.appendBanner(nil, h.lineEnding))
+		}
+		return
+	}
+	l.LogAttrs(ctx, slog.LevelInfo, "service started", info.attrs()...)
+}
+
+func (info *StartupInfo) fill() {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		if info.Commit == "" {
+			info.Commit = buildCommit(bi)
+		}
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	if info.OS == "" {
+		info.OS = runtime.GOOS
+	}
+	if info.Arch == "" {
+		info.Arch = runtime.GOARCH
+	}
+	if info.PID == 0 {
+		info.PID = os.Getpid()
+	}
+}
+
+// buildCommit returns the VCS revision of bi.
+func buildCommit(bi *debug.BuildInfo) string {
+	var rev string
+	var dirty bool
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if rev != "" && dirty {
+		rev += "-dirty"
+	}
+	return rev
+}
+
+func (info *StartupInfo) attrs() []slog.Attr {
+	attrs := []slog.Attr{slog.String("service", info.Service)}
+	if info.Version != "" {
+		attrs = append(attrs, slog.String("version", info.Version))
+	}
+	if info.Commit != "" {
+		attrs = append(attrs, slog.String("commit", info.Commit))
+	}
+	attrs = append(attrs,
+		slog.String("go_version", info.GoVersion),
+		slog.String("os", info.OS),
+		slog.String("arch", info.Arch),
+		slog.Int("pid", info.PID),
+	)
+	if len(info.Config) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "config", Value: slog.GroupValue(info.configAttrs()...)})
+	}
+	return attrs
+}
+
+// configAttrs returns the attrs of the config values, redacted.
+func (info *StartupInfo) configAttrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(info.Config))
+	for _, k := range sortedKeys(info.Config) {
+		attrs = append(attrs, configAttr(k, reflect.ValueOf(info.Config[k]), false, 0))
+	}
+	return attrs
+}
+
+// appendBanner appends info as lines in a box, each ended with lineEnding.
+func (info *StartupInfo) appendBanner(b []byte, lineEnding string) []byte {
+	title := strings.TrimSpace(info.Service + " " + info.Version)
+	rows := [][2]string{
+		{"commit", info.Commit},
+		{"go", info.GoVersion + " " + info.OS + "/" + info.Arch},
+		{"pid", strconv.Itoa(info.PID)},
+		{"started", time.Now().Format(time.RFC3339)},
+	}
+	if info.Commit == "" {
+		rows = rows[1:]
+	}
+	configStart := len(rows)
+	rows = appendConfigRows(rows, "", info.configAttrs())
+
+	var lines []string
+	if title != "" {
+		lines = append(lines, title)
+	}
+	keyWidth := 0
+	for _, row := range rows {
+		keyWidth = max(keyWidth, displayWidth(row[0]))
+	}
+	for i, row := range rows {
+		if i == configStart && i < len(rows) {
+			lines = append(lines, "config")
+		}
+		pad := strings.Repeat(" ", keyWidth-displayWidth(row[0]))
+		lines = append(lines, row[0]+pad+"  "+row[1])
+	}
+
+	width := 0
+	for i, line := range lines {
+		// Config values could hold newlines and escape sequences.
+		lines[i] = string(appendMessage(nil, line, EscapeControlChars))
+		width = max(width, displayWidth(lines[i]))
+	}
+	rule := strings.Repeat("─", width+2)
+	b = append(b, "╭"+rule+"╮"+lineEnding...)
+	for _, line := range lines {
+		b = append(b, "│ "+line+strings.Repeat(" ", width-displayWidth(line))+" │"+lineEnding...)
+	}
+	return append(b, "╰"+rule+"╯"+lineEnding...)
+}
+
+// appendConfigRows appends rows for the config attrs, with the keys of nested
+// values joined with dots.
+func appendConfigRows(rows [][2]string, prefix string, attrs []slog.Attr) [][2]string {
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup {
+			rows = appendConfigRows(rows, prefix+a.Key+".", a.Value.Group())
+			continue
+		}
+		rows = append(rows, [2]string{"  " + prefix + a.Key, a.Value.String()})
+	}
+	return rows
+}
+
+// isSensitive reports whether the values of the config key k are secrets.
+func isSensitive(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns s with the password replaced if it is a URL.
+func redactURL(s string) string {
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil {
+			return u.Redacted()
+		}
+	}
+	return s
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package logger
+
+import "errors"
+
+// statfs is not supported on this platform, which disables DiskGuard unless it
+// is given a Statfs func.
+func statfs(string) (avail, total uint64, err error) {
+	return 0, 0, errors.New("statfs is not supported on this platform")
+}
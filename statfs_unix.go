@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package logger
+
+import "syscall"
+
+// statfs returns the space available to unprivileged users and the total
+// space of the file system holding path.
+func statfs(path string) (avail, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), uint64(st.Blocks) * uint64(st.Bsize), nil
+}
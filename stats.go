@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Stats are counters of a handler and the handlers derived from it with
+// WithAttrs and WithGroup.
+type Stats struct {
+	// Records is the number of records handled.
+	Records uint64
+	// Bytes is the number of bytes written.
+	Bytes uint64
+	// WriteErrors is the number of records the writer failed to write.
+	WriteErrors uint64
+	// Dropped is the number of records not written within WriteTimeout.
+	Dropped uint64
+	// Truncated is the number of records cut at MaxRecordBytes.
+	Truncated uint64
+	// Suppressed is the number of records sent to FallbackWriter because they were
+	// logged while another record was written.
+	Suppressed uint64
+	// ClampedTenants is the number of records whose tenant was replaced with
+	// "other" by HandlerOptions.Tenant.
+	ClampedTenants uint64
+	// QueueHighWater is the largest number of records that waited for the
+	// writer at once with WriteTimeout.
+	QueueHighWater uint64
+	// PoolSize is the number of render buffers of all handlers, in use or
+	// pooled, that the garbage collector hasn't freed.
+	PoolSize uint64
+}
+
+// handlerStats holds the counters of Stats, so that Handle doesn't contend with
+// reading them.
+type handlerStats struct {
+	records     atomic.Uint64
+	bytes       atomic.Uint64
+	writeErrors atomic.Uint64
+	dropped     atomic.Uint64
+	truncated   atomic.Uint64
+	suppressed  atomic.Uint64
+
+	clampedTenants atomic.Uint64
+	queueHighWater atomic.Uint64
+}
+
+// renderBuffers is the number of renderStates made for renderPool that are
+// still alive.
+var renderBuffers atomic.Int64
+
+// raise sets v to n if n is larger.
+func raise(v *atomic.Uint64, n uint64) {
+	for {
+		old := v.Load()
+		if n <= old || v.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// Stats returns the current counters of the handler.
+func (h *handler) Stats() Stats {
+	return Stats{
+		Records:     h.stats.records.Load(),
+		Bytes:       h.stats.bytes.Load(),
+		WriteErrors: h.stats.writeErrors.Load(),
+		Dropped:     h.stats.dropped.Load(),
+		Truncated:   h.stats.truncated.Load(),
+		Suppressed:  h.stats.suppressed.Load(),
+
+		ClampedTenants: h.stats.clampedTenants.Load(),
+		QueueHighWater: h.stats.queueHighWater.Load(),
+		PoolSize:       uint64(renderBuffers.Load()),
+	}
+}
+
+// PublishExpvar publishes the Stats of the handler with expvar as
+// "logger.internal". Like expvar.Publish, it panics when called twice.
+func (h *handler) PublishExpvar() {
+	expvar.Publish("logger.internal", expvar.Func(func() any {
+		return h.Stats()
+	}))
+}
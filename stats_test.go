@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails the writes of records containing "fail", writing nothing.
+// It reads stats, when set, while writing.
+type flakyWriter struct {
+	written int
+	stats   func() Stats
+	during  Stats
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.stats != nil {
+		w.during = w.stats()
+	}
+	if strings.Contains(string(p), "fail") {
+		return 0, errors.New("disk full")
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestStats(t *testing.T) {
+	w := &flakyWriter{}
+	h := NewHandler(&HandlerOptions{Writer: w, MaxRecordBytes: 100})
+	w.stats = h.Stats
+	l := slog.New(h)
+	l.Info("ok")
+	l.With("svc", "api").Info("fail")
+	l.WithGroup("g").Warn("ok", "n", 1)
+	l.Error("fail")
+	l.Info("long", "s", strings.Repeat("x", 200))
+
+	s := h.Stats()
+	if s.Records != 5 || s.Bytes != uint64(w.written) || s.WriteErrors != 2 || s.Truncated != 1 {
+		t.Errorf("stats %+v, want 5 records, %d bytes, 2 write errors and 1 truncated", s, w.written)
+	}
+	if s.Dropped != 0 || s.Suppressed != 0 || s.QueueHighWater != 0 {
+		t.Errorf("stats %+v, want nothing dropped, suppressed or queued", s)
+	}
+	// The record being written has a render buffer.
+	if w.during.PoolSize == 0 {
+		t.Errorf("stats %+v while writing, want render buffers", w.during)
+	}
+}
+
+func TestStatsQueueHighWater(t *testing.T) {
+	w := newBlockingWriter()
+	h := NewHandler(&HandlerOptions{Writer: w, WriteTimeout: time.Minute})
+	defer h.Close()
+	l := slog.New(h)
+
+	// The first record is being written, so the others wait in the queue.
+	var wg sync.WaitGroup
+	wg.Go(func() { l.Info("first") })
+	<-w.writing
+	for range 4 {
+		wg.Go(func() { l.Info("queued") })
+	}
+	for deadline := time.Now().Add(5 * time.Second); h.Stats().QueueHighWater < 4; {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats %+v, want 4 queued records", h.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(w.release)
+	wg.Wait()
+
+	if s := h.Stats(); s.QueueHighWater != 4 || s.Records != 5 || s.Dropped != 0 {
+		t.Errorf("stats %+v, want 5 records with at most 4 queued", s)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	h := NewHandler(&HandlerOptions{Writer: &flakyWriter{}})
+	h.PublishExpvar()
+	l := slog.New(h)
+	l.Info("ok")
+	l.Info("fail")
+
+	var got Stats
+	if err := json.Unmarshal([]byte(expvar.Get("logger.internal").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := h.Stats(); got != want || got.Records != 2 || got.WriteErrors != 1 {
+		t.Errorf("logger.internal = %+v, want %+v", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("publishing twice didn't panic")
+		}
+	}()
+	NewHandler(&HandlerOptions{}).PublishExpvar()
+}
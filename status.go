@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// statusInterval is the minimum time between the records written for the
+// updates of a status line when the writer isn't a terminal.
+const statusInterval = time.Second
+
+// clearLine moves the cursor to the start of the line and clears it.
+const clearLine = "\r\033[K"
+
+// statusState holds the status line shown on a terminal. It is shared with the
+// handlers derived from the one created by NewHandler.
+type statusState struct {
+	mu sync.Mutex
+	// owner is the StatusLine shown, and line its rendered text.
+	owner *StatusLine
+	line  []byte
+	buf   []byte
+}
+
+// StatusLine reports the progress of a task. On a terminal, it is a single line
+// below the records that is rewritten with each update and cleared while
+// records are written. Otherwise updates are written as Info records, at most
+// one per second.
+type StatusLine struct {
+	h    *handler
+	name string
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Status returns a status line for the task name. Only one status line is
+// shown at a time; updating another one replaces it.
+func (h *handler) Status(name string) *StatusLine {
+	return &StatusLine{h: h, name: name}
+}
+
+// Update shows progress, like "42/120", as the status of the task.
+func (l *StatusLine) Update(progress string) {
+	h := l.h
+	if !h.Enabled(context.Background(), slog.LevelInfo) {
+		return
+	}
+	st := h.status
+	if st == nil {
+		l.mu.Lock()
+		now := time.Now()
+		if now.Sub(l.last) < statusInterval {
+			l.mu.Unlock()
+			return
+		}
+		l.last = now
+		l.mu.Unlock()
+		l.log(l.name, slog.String("progress", progress))
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.owner = l
+	st.line = append(st.line[:0], h.palette.message...)
+	st.line = appendMessage(st.line, l.name+": "+progress, EscapeControlChars)
+	st.line = appendReset(st.line, h.palette.message)
+	st.buf = append(append(st.buf[:0], clearLine...), st.line...)
+	h.writeRecord(slog.LevelInfo, l.name, st.buf)
+}
+
+// Done removes the status line and logs msg as an Info record.
+func (l *StatusLine) Done(msg string) {
+	if st := l.h.status; st != nil {
+		st.mu.Lock()
+		if st.owner == l {
+			st.owner = nil
+			st.line = st.line[:0]
+			l.h.writeRecord(slog.LevelInfo, l.name, []byte(clearLine))
+		}
+		st.mu.Unlock()
+	}
+	l.log(msg)
+}
+
+func (l *StatusLine) log(msg string, attrs ...slog.Attr) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+	r.AddAttrs(attrs...)
+	l.h.Handle(context.Background(), r)
+}
+
+// withStatus returns b preceded by the sequence clearing the status line and
+// followed by the status line, if one is shown. st.mu must be held.
+func (st *statusState) withStatus(b []byte) []byte {
+	if st.owner == nil {
+		return b
+	}
+	st.buf = append(append(st.buf[:0], clearLine...), b...)
+	return append(st.buf, st.line...)
+}
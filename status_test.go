@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestStatusLine(t *testing.T) {
+	var w writeLog
+	h := NewHandler(&HandlerOptions{Writer: &w, Colorize: true, Theme: &Theme{}})
+	// Pretend the writer is a terminal.
+	h.status = new(statusState)
+	l := slog.New(h)
+
+	status := h.Status("migrating")
+	status.Update("1/3")
+	l.Info("table created", "name", "users")
+	status.Update("2/3\x1b[2J")
+	status.Done("migration finished")
+	l.Info("after")
+
+	want := []string{
+		clearLine + "migrating: 1/3",
+		clearLine + "INFO: table created {\"name\":\"users\"}\n" + "migrating: 1/3",
+		clearLine + `migrating: 2/3\x1b[2J`,
+		clearLine,
+		"INFO: migration finished {}\n",
+		"INFO: after {}\n",
+	}
+	if len(w) != len(want) {
+		t.Fatalf("got writes %q, want %q", w, want)
+	}
+	for i := range want {
+		got := timeRE.ReplaceAllString(w[i], "")
+		if strings.HasPrefix(want[i], clearLine) {
+			got = clearLine + timeRE.ReplaceAllString(strings.TrimPrefix(w[i], clearLine), "")
+		}
+		if got != want[i] {
+			t.Errorf("write %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestStatusLineNotTerminal(t *testing.T) {
+	var w writeLog
+	h := NewHandler(&HandlerOptions{Writer: &w})
+	status := h.Status("migrating")
+	for _, progress := range []string{"1/3", "2/3", "3/3"} {
+		status.Update(progress)
+	}
+	status.Done("migration finished")
+
+	// Updates within a second of the last one written are dropped.
+	if len(w) != 2 || !strings.HasSuffix(w[0], `INFO: migrating {"progress":"1/3"}`+"\n") ||
+		!strings.HasSuffix(w[1], "INFO: migration finished {}\n") || strings.Contains(w[0]+w[1], "\r") {
+		t.Errorf("got writes %q", w)
+	}
+
+	// Nothing is written when Info is disabled.
+	w = w[:0]
+	h = NewHandler(&HandlerOptions{Writer: &w, HandlerOptions: &slog.HandlerOptions{Level: slog.LevelWarn}})
+	h.Status("migrating").Update("1/3")
+	if len(w) != 0 {
+		t.Errorf("got writes %q", w)
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// NewStdLogger returns a *log.Logger writing to l at the given level, for APIs
+// such as http.Server.ErrorLog. Each line becomes a record, and a leading
+// "subsystem: " prefix like the one of "http: TLS handshake error" is moved to
+// a subsystem attribute.
+func NewStdLogger(l *slog.Logger, level slog.Level) *log.Logger {
+	return log.New(&stdWriter{l: l, level: level}, "", 0)
+}
+
+// RedirectStdLog sends the output of the log package to slog.Default at Info
+// level the way NewStdLogger does. The default logger must not write to the log
+// package itself. The returned function restores the previous output.
+func RedirectStdLog() (restore func()) {
+	w, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(&stdWriter{l: slog.Default(), level: slog.LevelInfo})
+	log.SetFlags(0)
+	log.SetPrefix("")
+	return func() {
+		log.SetOutput(w)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
+
+type stdWriter struct {
+	l     *slog.Logger
+	level slog.Level
+}
+
+var _ io.Writer = (*stdWriter)(nil)
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	if !w.l.Enabled(ctx, w.level) {
+		return len(p), nil
+	}
+
+	// Skip runtime.Callers, Write, log.(*Logger).output and the log.Print function.
+	var pcs [1]uintptr
+	runtime.Callers(4, pcs[:])
+
+	for line := range bytes.Lines(p) {
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		r := slog.NewRecord(time.Now(), w.level, "", pcs[0])
+		if sub, msg, ok := subsystem(line); ok {
+			r.Message = string(msg)
+			r.AddAttrs(slog.String("subsystem", sub))
+		} else {
+			r.Message = string(line)
+		}
+		_ = w.l.Handler().Handle(ctx, r)
+	}
+	return len(p), nil
+}
+
+// subsystem splits a "name: message" line, where name is a single lowercase
+// word such as "http" or "http2".
+func subsystem(line []byte) (string, []byte, bool) {
+	i := bytes.Index(line, []byte(": "))
+	if i <= 0 {
+		return "", nil, false
+	}
+	for _, c := range line[:i] {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' && c != '.' {
+			return "", nil, false
+		}
+	}
+	return string(line[:i]), line[i+2:], true
+}
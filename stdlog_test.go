@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanHandler sends every record it handles to a channel, so records logged by
+// other goroutines can be awaited. Attrs from WithAttrs are added to the
+// records; groups are ignored.
+type chanHandler struct {
+	c     chan slog.Record
+	attrs []slog.Attr
+}
+
+func newChanHandler(size int) *chanHandler {
+	return &chanHandler{c: make(chan slog.Record, size)}
+}
+
+func (h *chanHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *chanHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *chanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &chanHandler{c: h.c, attrs: append(slices.Clip(h.attrs), attrs...)}
+}
+
+func (h *chanHandler) Handle(_ context.Context, r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(h.attrs...)
+	h.c <- r
+	return nil
+}
+
+// wait returns the first record matching fn, failing the test if none arrives
+// within five seconds.
+func (h *chanHandler) wait(t *testing.T, fn func(r slog.Record) bool) slog.Record {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case r := <-h.c:
+			if fn(r) {
+				return r
+			}
+		case <-timeout:
+			t.Fatal("no matching record")
+		}
+	}
+}
+
+func findAttr(r slog.Record, key string) (v slog.Value, ok bool) {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			v, ok = a.Value, true
+			return false
+		}
+		return true
+	})
+	return v, ok
+}
+
+func TestNewStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf}))
+	std := NewStdLogger(l, slog.LevelWarn)
+
+	std.Print("http: TLS handshake error from 10.0.0.1:5000: EOF")
+	std.Print("first line\nsecond line\n\nthird line")
+	std.Print("Not a subsystem: capitalised")
+
+	records := parseLines(t, buf.Bytes())
+	want := []struct{ msg, subsystem string }{
+		{"TLS handshake error from 10.0.0.1:5000: EOF", "http"},
+		{"first line", ""},
+		{"second line", ""},
+		{"third line", ""},
+		{"Not a subsystem: capitalised", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d:\n%s", len(records), len(want), buf.String())
+	}
+	for i, w := range want {
+		r := records[i]
+		if r[slog.LevelKey] != "WARN" || r[slog.MessageKey] != w.msg {
+			t.Errorf("record %d = %s %q, want WARN %q", i, r[slog.LevelKey], r[slog.MessageKey], w.msg)
+		}
+		if sub, _ := r["subsystem"].(string); sub != w.subsystem {
+			t.Errorf("record %d subsystem = %q, want %q", i, sub, w.subsystem)
+		}
+	}
+}
+
+func TestNewStdLoggerDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&HandlerOptions{Writer: &buf, HandlerOptions: &slog.HandlerOptions{Level: slog.LevelError}}))
+	NewStdLogger(l, slog.LevelInfo).Print("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("disabled level wrote %q", buf.String())
+	}
+}
+
+func TestStdLoggerServerErrorLog(t *testing.T) {
+	records := newChanHandler(4)
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.Config.ErrorLog = NewStdLogger(slog.New(records), slog.LevelWarn)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// A plaintext request to a TLS listener fails the handshake.
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	conn.Close()
+
+	select {
+	case r := <-records.c:
+		if !strings.HasPrefix(r.Message, "TLS handshake error") {
+			t.Errorf("message = %q, want a TLS handshake error", r.Message)
+		}
+		if v, _ := findAttr(r, "subsystem"); v.String() != "http" {
+			t.Errorf("subsystem = %v, want http", v)
+		}
+		if r.Level != slog.LevelWarn {
+			t.Errorf("level = %v, want WARN", r.Level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record from http.Server.ErrorLog")
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	// slog.SetDefault redirects the log package as well; undo both.
+	records := newChanHandler(4)
+	prev, w, flags := slog.Default(), log.Writer(), log.Flags()
+	slog.SetDefault(slog.New(records))
+	defer func() {
+		slog.SetDefault(prev)
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}()
+
+	log.SetPrefix("app ")
+	restore := RedirectStdLog()
+	log.Printf("one\ntwo")
+	restore()
+	close(records.c)
+
+	var msgs []string
+	for r := range records.c {
+		msgs = append(msgs, r.Message)
+		if r.Level != slog.LevelInfo {
+			t.Errorf("level = %v, want INFO", r.Level)
+		}
+	}
+	if strings.Join(msgs, "|") != "one|two" {
+		t.Errorf("messages = %q, want [one two]", msgs)
+	}
+	if log.Prefix() != "app " {
+		t.Errorf("prefix after restore = %q, want %q", log.Prefix(), "app ")
+	}
+	log.SetPrefix("")
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Stopwatch times the phases of an operation for a single summary record:
+//
+//	sw := logger.NewStopwatch()
+//	fetch()
+//	sw.Lap("fetch")
+//	store()
+//	sw.Lap("store")
+//	slog.Info("sync finished", sw.Attr())
+//
+// The durations are written like "120ms" in text output and in nanoseconds in
+// JSON output, unless HandlerOptions.HumanDurations is set. A Stopwatch must
+// only be used by one goroutine.
+type Stopwatch struct {
+	now   func() time.Time
+	start time.Time
+	last  time.Time
+	laps  []slog.Attr
+}
+
+// NewStopwatch returns a Stopwatch started now.
+func NewStopwatch() *Stopwatch {
+	return NewStopwatchClock(time.Now)
+}
+
+// NewStopwatchClock returns a Stopwatch reading the time from now, started
+// now.
+func NewStopwatchClock(now func() time.Time) *Stopwatch {
+	start := now()
+	return &Stopwatch{now: now, start: start, last: start}
+}
+
+// Lap ends the phase name, which started at the end of the previous phase or
+// with the Stopwatch, and returns its duration.
+func (sw *Stopwatch) Lap(name string) time.Duration {
+	now := sw.now()
+	d := now.Sub(sw.last)
+	sw.last = now
+	sw.laps = append(sw.laps, slog.Any(name, lapDuration(d)))
+	return d
+}
+
+// Attr returns a "timings" group with the duration of each phase in the order
+// they ended and their "total". Without phases, the total is the time since the
+// Stopwatch started.
+func (sw *Stopwatch) Attr() slog.Attr {
+	total := sw.last.Sub(sw.start)
+	if len(sw.laps) == 0 {
+		total = sw.now().Sub(sw.start)
+	}
+	attrs := append(sw.laps[:len(sw.laps):len(sw.laps)], slog.Any("total", lapDuration(total)))
+	return slog.Attr{Key: "timings", Value: slog.GroupValue(attrs...)}
+}
+
+// lapDuration is a duration timed by a Stopwatch. Unlike other durations, the
+// text output of the handler writes it like "120ms" without HumanDurations, and
+// so do handlers formatting values with their String method, like
+// slog.TextHandler; JSON handlers write it in nanoseconds.
+type lapDuration time.Duration
+
+func (d lapDuration) String() string {
+	return time.Duration(d).String()
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopwatch(t *testing.T) {
+	clock := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	now := func() time.Time { return clock }
+	advance := func(d time.Duration) { clock = clock.Add(d) }
+
+	sw := NewStopwatchClock(now)
+	advance(120 * time.Millisecond)
+	if d := sw.Lap("fetch"); d != 120*time.Millisecond {
+		t.Errorf("fetch lap = %v", d)
+	}
+	advance(2300 * time.Millisecond)
+	sw.Lap("transform")
+	// Time after the last lap doesn't count.
+	advance(time.Second)
+
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{"text", HandlerOptions{}, `INFO: sync finished {"timings":{"fetch":"120ms","transform":"2.3s","total":"2.42s"}}`},
+		{"json", HandlerOptions{JSON: true}, `"msg":"sync finished","timings":{"fetch":120000000,"transform":2300000000,"total":2420000000}}`},
+		{"human json", HandlerOptions{JSON: true, HumanDurations: true}, `"msg":"sync finished","timings":{"fetch":"120ms","transform":"2.3s","total":"2.42s"}}`},
+	} {
+		var w writeLog
+		opts := tc.opts
+		opts.Writer = &w
+		opts.Now, opts.ForceNow = now, true
+		l := slog.New(NewHandler(&opts))
+		l.Info("sync finished", sw.Attr())
+		l.Info("sync finished", sw.Attr())
+		want := tc.want + "\n"
+		if len(w) != 2 || !strings.HasSuffix(w[0], want) || w[1] != w[0] {
+			t.Errorf("%s: got writes %q, want 2 ending with %q", tc.name, w, want)
+		}
+	}
+
+	// Other handlers write the laps like other values with a String method.
+	var text, js bytes.Buffer
+	slog.New(slog.NewTextHandler(&text, nil)).Info("sync finished", sw.Attr())
+	slog.New(slog.NewJSONHandler(&js, nil)).Info("sync finished", sw.Attr())
+	if want := "timings.fetch=120ms timings.transform=2.3s timings.total=2.42s\n"; !strings.HasSuffix(text.String(), want) {
+		t.Errorf("slog.TextHandler: got %q, want suffix %q", text.String(), want)
+	}
+	if want := `"timings":{"fetch":120000000,"transform":2300000000,"total":2420000000}}` + "\n"; !strings.HasSuffix(js.String(), want) {
+		t.Errorf("slog.JSONHandler: got %q, want suffix %q", js.String(), want)
+	}
+
+	empty := NewStopwatchClock(now)
+	advance(time.Minute)
+	if got := empty.Attr().Value.Group(); len(got) != 1 || got[0].Key != "total" || got[0].Value.Any() != lapDuration(time.Minute) {
+		t.Errorf("Attr without laps = %v", got)
+	}
+}
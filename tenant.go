@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// TenantOptions adds the tenant of the context to records, guarding against
+// tenant values that would blow up the label cardinality of log stores like
+// Loki.
+type TenantOptions struct {
+	// Key is the key of the attr. It defaults to "tenant_id".
+	Key string
+	// FromContext returns the tenant of ctx, or "" for none. It defaults to
+	// TenantFromContext.
+	FromContext func(ctx context.Context) string
+	// Allow lists the tenants written as they are. Other tenants are written as
+	// "other". Nil allows all tenants.
+	Allow []string
+	// MaxDistinct limits the number of distinct tenants written. Tenants seen
+	// after the limit is reached are written as "other". Zero means no limit.
+	MaxDistinct int
+}
+
+// otherTenant replaces the tenants TenantOptions doesn't allow.
+const otherTenant = "other"
+
+type tenantGuard struct {
+	key   string
+	from  func(ctx context.Context) string
+	allow map[string]struct{}
+	max   int
+	stats *handlerStats
+
+	m    sync.Mutex
+	seen map[string]struct{}
+}
+
+func newTenantGuard(opts *TenantOptions, stats *handlerStats) *tenantGuard {
+	g := &tenantGuard{key: opts.Key, from: opts.FromContext, max: opts.MaxDistinct, stats: stats}
+	if g.key == "" {
+		g.key = "tenant_id"
+	}
+	if g.from == nil {
+		g.from = TenantFromContext
+	}
+	if opts.Allow != nil {
+		g.allow = make(map[string]struct{}, len(opts.Allow))
+		for _, t := range opts.Allow {
+			g.allow[t] = struct{}{}
+		}
+	}
+	if g.max > 0 {
+		g.seen = make(map[string]struct{})
+	}
+	return g
+}
+
+// extract is the ContextExtractor of the tenant.
+func (g *tenantGuard) extract(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	tenant := g.from(ctx)
+	if tenant == "" {
+		return nil
+	}
+	if !g.allowed(tenant) {
+		g.stats.clampedTenants.Add(1)
+		tenant = otherTenant
+	}
+	return []slog.Attr{slog.String(g.key, tenant)}
+}
+
+func (g *tenantGuard) allowed(tenant string) bool {
+	if g.allow != nil {
+		if _, ok := g.allow[tenant]; !ok {
+			return false
+		}
+	}
+	if g.seen == nil {
+		return true
+	}
+	g.m.Lock()
+	defer g.m.Unlock()
+	if _, ok := g.seen[tenant]; ok {
+		return true
+	}
+	if len(g.seen) >= g.max {
+		return false
+	}
+	g.seen[tenant] = struct{}{}
+	return true
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	type orgKey struct{}
+	for _, tc := range []struct {
+		name    string
+		opts    TenantOptions
+		tenants []string
+		want    []string
+		clamped uint64
+	}{
+		{"all", TenantOptions{}, []string{"acme", "", "globex"}, []string{"acme", "", "globex"}, 0},
+		{"allow", TenantOptions{Allow: []string{"acme"}}, []string{"acme", "globex", "acme"}, []string{"acme", "other", "acme"}, 1},
+		{"max distinct", TenantOptions{MaxDistinct: 2}, []string{"a", "b", "c", "a", "d"}, []string{"a", "b", "other", "a", "other"}, 2},
+		{"allow and max distinct", TenantOptions{Allow: []string{"a", "b"}, MaxDistinct: 1}, []string{"b", "a", "c"}, []string{"b", "other", "other"}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewHandler(&HandlerOptions{Writer: &buf, JSON: true, Tenant: &tc.opts})
+			l := slog.New(h).With("k", 1).WithGroup("g")
+			for _, tenant := range tc.tenants {
+				ctx := context.Background()
+				if tenant != "" {
+					ctx = ContextWithTenant(ctx, tenant)
+				}
+				l.InfoContext(ctx, "msg", "a", 1)
+			}
+			// Logging without a context doesn't add a tenant.
+			l.Info("no context")
+
+			var got []string
+			for line := range strings.Lines(buf.String()) {
+				var record struct {
+					TenantID string `json:"tenant_id"`
+				}
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, record.TenantID)
+			}
+			if want := append(tc.want, ""); !slices.Equal(got, want) {
+				t.Errorf("got tenants %q, want %q:\n%s", got, want, buf.String())
+			}
+			if n := h.Stats().ClampedTenants; n != tc.clamped {
+				t.Errorf("got %d clamped tenants, want %d", n, tc.clamped)
+			}
+		})
+	}
+
+	// A custom key and context lookup.
+	var buf bytes.Buffer
+	slog.New(NewHandler(&HandlerOptions{Writer: &buf, Tenant: &TenantOptions{
+		Key: "org",
+		FromContext: func(ctx context.Context) string {
+			org, _ := ctx.Value(orgKey{}).(string)
+			return org
+		},
+	}})).InfoContext(context.WithValue(context.Background(), orgKey{}, "initech"), "msg")
+	if got := buf.String(); !strings.HasSuffix(got, ` {"org":"initech"}`+"\n") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package testutil
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// AssertLogged fails the test unless a record at level with a message
+// containing substr was captured.
+func (h *CaptureHandler) AssertLogged(t testing.TB, level slog.Level, substr string) {
+	t.Helper()
+	for _, r := range h.Records() {
+		if r.Level == level && strings.Contains(r.Message, substr) {
+			return
+		}
+	}
+	t.Errorf("no %s record with message containing %q was logged", level, substr)
+}
+
+// AssertLoggedRegexp fails the test unless a record at level with a message
+// matching the regular expression expr was captured.
+func (h *CaptureHandler) AssertLoggedRegexp(t testing.TB, level slog.Level, expr string) {
+	t.Helper()
+	re := regexp.MustCompile(expr)
+	for _, r := range h.Records() {
+		if r.Level == level && re.MatchString(r.Message) {
+			return
+		}
+	}
+	t.Errorf("no %s record with message matching %q was logged", level, expr)
+}
+
+// AssertAttr fails the test unless a captured record has the attribute key with
+// the given value. Keys of grouped attributes are joined with dots.
+func (h *CaptureHandler) AssertAttr(t testing.TB, key string, value any) {
+	t.Helper()
+	if len(h.ByAttr(key, value)) == 0 {
+		t.Errorf("no record with %s=%v was logged", key, slog.AnyValue(value))
+	}
+}
+
+// AssertNoErrors fails the test if a record at Error level or above was captured.
+func (h *CaptureHandler) AssertNoErrors(t testing.TB) {
+	t.Helper()
+	for _, r := range h.Records() {
+		if r.Level >= slog.LevelError {
+			t.Errorf("unexpected %s record: %s", r.Level, r.Message)
+		}
+	}
+}
+
+// equal compares values, treating integers of any kind as equal when their
+// values are and falling back to string comparison for other values.
+func equal(a, b slog.Value) bool {
+	switch {
+	case a.Kind() == slog.KindInt64 && b.Kind() == slog.KindUint64:
+		return a.Int64() >= 0 && uint64(a.Int64()) == b.Uint64()
+	case a.Kind() == slog.KindUint64 && b.Kind() == slog.KindInt64:
+		return b.Int64() >= 0 && uint64(b.Int64()) == a.Uint64()
+	case a.Kind() != b.Kind():
+		return false
+	case a.Kind() == slog.KindAny:
+		return a.String() == b.String()
+	default:
+		return a.Equal(b)
+	}
+}
@@ -0,0 +1,86 @@
+package testutil
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+	tb.failed = true
+}
+
+// checkout logs what a typical service would while declining a payment.
+func checkout() *CaptureHandler {
+	capture := NewCaptureHandler()
+	l := slog.New(capture).With("service", "billing")
+	l.Info("checkout started", "order_id", 42)
+	l.WithGroup("payment").Warn("payment declined", "code", "insufficient_funds", "amount", uint64(1999))
+	return capture
+}
+
+func TestAssertLogged(t *testing.T) {
+	capture := checkout()
+	capture.AssertLogged(t, slog.LevelWarn, "declined")
+
+	tb := &fakeTB{}
+	capture.AssertLogged(tb, slog.LevelError, "declined")
+	capture.AssertLogged(tb, slog.LevelWarn, "approved")
+	if len(tb.logs) != 2 {
+		t.Errorf("failures = %q, want wrong level and wrong message", tb.logs)
+	}
+}
+
+func TestAssertLoggedRegexp(t *testing.T) {
+	capture := checkout()
+	capture.AssertLoggedRegexp(t, slog.LevelInfo, `^checkout (started|resumed)$`)
+
+	tb := &fakeTB{}
+	capture.AssertLoggedRegexp(tb, slog.LevelInfo, `^started`)
+	if !tb.failed {
+		t.Error("unanchored match passed")
+	}
+}
+
+func TestAssertAttr(t *testing.T) {
+	capture := checkout()
+	capture.AssertAttr(t, "order_id", 42)
+	capture.AssertAttr(t, "service", "billing")
+	capture.AssertAttr(t, "payment.code", "insufficient_funds")
+	capture.AssertAttr(t, "payment.amount", 1999) // int against uint64
+
+	tb := &fakeTB{}
+	capture.AssertAttr(tb, "order_id", 43)
+	capture.AssertAttr(tb, "code", "insufficient_funds")
+	capture.AssertAttr(tb, "payment.amount", -1)
+	if len(tb.logs) != 3 {
+		t.Errorf("failures = %q, want wrong value, ungrouped key and negative int", tb.logs)
+	}
+}
+
+func TestAssertNoErrors(t *testing.T) {
+	capture := checkout()
+	capture.AssertNoErrors(t)
+
+	slog.New(capture).Error("charge failed")
+	tb := &fakeTB{}
+	capture.AssertNoErrors(tb)
+	if len(tb.logs) != 1 || tb.logs[0] != "unexpected ERROR record: charge failed" {
+		t.Errorf("failures = %q, want the error record", tb.logs)
+	}
+}
+
+func TestAssertStableRecords(t *testing.T) {
+	capture := NewCaptureHandler()
+	l := slog.New(capture)
+	l.Info("one")
+	records := capture.Records()
+
+	// Later records and resets don't change what was returned.
+	l.Info("two")
+	capture.Reset()
+	if len(records) != 1 || records[0].Message != "one" {
+		t.Errorf("records = %v, want the single first record", records)
+	}
+}
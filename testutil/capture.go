@@ -0,0 +1,191 @@
+package testutil
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedRecord is a record stored by a CaptureHandler. Attrs include those
+// added with WithAttrs, nested in groups the way a real handler would see them.
+type CapturedRecord struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// Attr returns the value of the attribute with the given key. Keys of grouped
+// attributes are joined with dots, like "http.status".
+func (r CapturedRecord) Attr(key string) (slog.Value, bool) {
+	return lookup(r.Attrs, key)
+}
+
+func lookup(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+		if a.Value.Kind() != slog.KindGroup {
+			continue
+		}
+		rest, ok := key, a.Key == ""
+		if !ok {
+			rest, ok = strings.CutPrefix(key, a.Key+".")
+		}
+		if ok {
+			if v, ok := lookup(a.Value.Group(), rest); ok {
+				return v, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
+
+type captureState struct {
+	m       sync.Mutex
+	records []CapturedRecord
+}
+
+// op is a WithAttrs or WithGroup call applied to a CaptureHandler.
+type op struct {
+	group string
+	attrs []slog.Attr
+}
+
+// CaptureHandler stores records in memory for assertions.
+type CaptureHandler struct {
+	s   *captureState
+	ops []op
+}
+
+// NewCaptureHandler returns an empty CaptureHandler accepting all levels.
+func NewCaptureHandler() *CaptureHandler {
+	return &CaptureHandler{s: &captureState{}}
+}
+
+func (h *CaptureHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *CaptureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &CaptureHandler{s: h.s, ops: append(slices.Clip(h.ops), op{attrs: resolve(attrs)})}
+}
+
+func (h *CaptureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &CaptureHandler{s: h.s, ops: append(slices.Clip(h.ops), op{group: name})}
+}
+
+func (h *CaptureHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs = resolve(attrs)
+
+	for i := len(h.ops) - 1; i >= 0; i-- {
+		o := h.ops[i]
+		switch {
+		case o.group == "":
+			attrs = append(slices.Clone(o.attrs), attrs...)
+		case len(attrs) > 0:
+			attrs = []slog.Attr{{Key: o.group, Value: slog.GroupValue(attrs...)}}
+		}
+	}
+
+	h.s.m.Lock()
+	h.s.records = append(h.s.records, CapturedRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   attrs,
+	})
+	h.s.m.Unlock()
+	return nil
+}
+
+// resolve returns a copy of attrs with LogValuers resolved and empty
+// attributes dropped.
+func resolve(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			group := resolve(a.Value.Group())
+			if len(group) == 0 {
+				continue
+			}
+			a.Value = slog.GroupValue(group...)
+		}
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// Records returns a copy of the captured records.
+func (h *CaptureHandler) Records() []CapturedRecord {
+	h.s.m.Lock()
+	defer h.s.m.Unlock()
+	return slices.Clone(h.s.records)
+}
+
+// Len returns the number of captured records.
+func (h *CaptureHandler) Len() int {
+	h.s.m.Lock()
+	defer h.s.m.Unlock()
+	return len(h.s.records)
+}
+
+// Reset drops all captured records.
+func (h *CaptureHandler) Reset() {
+	h.s.m.Lock()
+	defer h.s.m.Unlock()
+	h.s.records = nil
+}
+
+// Filter returns the captured records at level.
+func (h *CaptureHandler) Filter(level slog.Level) []CapturedRecord {
+	return h.match(func(r CapturedRecord) bool {
+		return r.Level == level
+	})
+}
+
+// Find returns the captured records with a message containing substr.
+func (h *CaptureHandler) Find(substr string) []CapturedRecord {
+	return h.match(func(r CapturedRecord) bool {
+		return strings.Contains(r.Message, substr)
+	})
+}
+
+// ByAttr returns the captured records having the attribute key with the given
+// value. Keys of grouped attributes are joined with dots.
+func (h *CaptureHandler) ByAttr(key string, value any) []CapturedRecord {
+	want := slog.AnyValue(value)
+	return h.match(func(r CapturedRecord) bool {
+		v, ok := r.Attr(key)
+		return ok && equal(v, want)
+	})
+}
+
+func (h *CaptureHandler) match(fn func(r CapturedRecord) bool) []CapturedRecord {
+	var out []CapturedRecord
+	for _, r := range h.Records() {
+		if fn(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type userID int
+
+func (id userID) LogValue() slog.Value {
+	return slog.StringValue("user-" + strconv.Itoa(int(id)))
+}
+
+func TestCaptureQueries(t *testing.T) {
+	capture := NewCaptureHandler()
+	l := slog.New(capture)
+	l.Debug("cache miss", "key", "a")
+	l.Info("request served", "status", 200)
+	l.Info("request served", "status", 404)
+	l.Error("request failed", "status", 500)
+
+	if capture.Len() != 4 {
+		t.Errorf("Len = %d, want 4", capture.Len())
+	}
+	if got := len(capture.Filter(slog.LevelInfo)); got != 2 {
+		t.Errorf("Filter(Info) = %d records, want 2", got)
+	}
+	if got := len(capture.Find("request")); got != 3 {
+		t.Errorf("Find(request) = %d records, want 3", got)
+	}
+	if got := capture.ByAttr("status", 404); len(got) != 1 || got[0].Message != "request served" {
+		t.Errorf("ByAttr(status, 404) = %v, want one served record", got)
+	}
+	if got := capture.ByAttr("status", "404"); len(got) != 0 {
+		t.Errorf("ByAttr(status, \"404\") = %v, want no string match", got)
+	}
+
+	capture.Reset()
+	if capture.Len() != 0 || len(capture.Records()) != 0 {
+		t.Error("records left after Reset")
+	}
+}
+
+func TestCaptureDerivation(t *testing.T) {
+	capture := NewCaptureHandler()
+	l := slog.New(capture).With("svc", "api").WithGroup("req").With("id", 7).WithGroup("empty")
+	l.Info("no attrs")
+	l.Info("attrs", "user", userID(3))
+
+	records := capture.Records()
+	// An empty group is dropped, as by slog's own handlers.
+	if got := records[0].Attrs; len(got) != 2 || got[1].String() != "req=[id=7]" {
+		t.Errorf("attrs = %v, want svc and req.id", got)
+	}
+	if v, _ := records[1].Attr("req.empty.user"); v.String() != "user-3" {
+		t.Errorf("req.empty.user = %v, want the resolved LogValuer", v)
+	}
+	if v, _ := records[1].Attr("svc"); v.String() != "api" {
+		t.Errorf("svc = %v, want api", v)
+	}
+	if _, ok := records[1].Attr("user"); ok {
+		t.Error("grouped attr found without its group")
+	}
+}
+
+func TestCaptureInlineGroup(t *testing.T) {
+	capture := NewCaptureHandler()
+	slog.New(capture).Info("msg", slog.Group("", "a", 1), slog.Group("g", slog.Group("", "b", 2)))
+
+	r := capture.Records()[0]
+	if v, _ := r.Attr("a"); v.Int64() != 1 {
+		t.Errorf("a = %v, want 1", v)
+	}
+	if v, _ := r.Attr("g.b"); v.Int64() != 2 {
+		t.Errorf("g.b = %v, want 2", v)
+	}
+}
+
+func TestCaptureConcurrent(t *testing.T) {
+	capture := NewCaptureHandler()
+	l := slog.New(capture)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			child := l.With("worker", i)
+			for range 100 {
+				child.Info("tick")
+				capture.Find("tick")
+			}
+		})
+	}
+	wg.Wait()
+
+	if capture.Len() != 800 {
+		t.Errorf("Len = %d, want 800", capture.Len())
+	}
+	if got := len(capture.ByAttr("worker", 3)); got != 100 {
+		t.Errorf("worker 3 logged %d records, want 100", got)
+	}
+}
@@ -0,0 +1,386 @@
+package testutil
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+// Filter selects captured records. Zero fields match all records.
+type Filter struct {
+	// MinLevel and MaxLevel bound the level of records, inclusively.
+	MinLevel, MaxLevel slog.Leveler
+	// MsgRegexp matches the message of records.
+	MsgRegexp *regexp.Regexp
+	// Attrs are conditions on attributes that must all hold.
+	Attrs []AttrCond
+	// Since and Until bound the time of records. Since is inclusive and Until
+	// exclusive.
+	Since, Until time.Time
+}
+
+// AttrCond compares the attribute Key with Value using Op, which is one of =,
+// !=, <, <=, >, >=, ~ and !~. Keys of grouped attributes are joined with dots.
+//
+// Numbers compare numerically, including strings holding numbers, durations
+// compare with values like "1.5s" and times with RFC 3339 values. Other values
+// compare as strings. ~ and !~ match the value written as a string against the
+// regular expression Value. Records without the attribute don't match, with any
+// operator.
+type AttrCond struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// AttrEquals returns the condition that the attribute key equals value.
+func AttrEquals(key string, value any) AttrCond {
+	return AttrCond{Key: key, Op: "=", Value: slog.AnyValue(value).String()}
+}
+
+// Select returns the captured records matching f. It fails if f has an invalid
+// condition.
+func (h *CaptureHandler) Select(f Filter) ([]CapturedRecord, error) {
+	match, err := f.compile()
+	if err != nil {
+		return nil, err
+	}
+	return h.match(match), nil
+}
+
+// Query returns the captured records matching the filter expression expr, as
+// parsed by ParseFilter.
+func (h *CaptureHandler) Query(expr string) ([]CapturedRecord, error) {
+	f, err := ParseFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	return h.Select(f)
+}
+
+func (f Filter) compile() (func(r CapturedRecord) bool, error) {
+	res := make([]*regexp.Regexp, len(f.Attrs))
+	for i, c := range f.Attrs {
+		switch c.Op {
+		case "=", "!=", "<", "<=", ">", ">=":
+		case "~", "!~":
+			re, err := regexp.Compile(c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression for %s: %w", c.Key, err)
+			}
+			res[i] = re
+		default:
+			return nil, fmt.Errorf("invalid operator %q for %s", c.Op, c.Key)
+		}
+	}
+	return func(r CapturedRecord) bool {
+		if (f.MinLevel != nil && r.Level < f.MinLevel.Level()) ||
+			(f.MaxLevel != nil && r.Level > f.MaxLevel.Level()) ||
+			(f.MsgRegexp != nil && !f.MsgRegexp.MatchString(r.Message)) ||
+			(!f.Since.IsZero() && r.Time.Before(f.Since)) ||
+			(!f.Until.IsZero() && !r.Time.Before(f.Until)) {
+			return false
+		}
+		for i, c := range f.Attrs {
+			v, ok := r.Attr(c.Key)
+			if !ok || !c.holds(v.Resolve(), res[i]) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (c AttrCond) holds(v slog.Value, re *regexp.Regexp) bool {
+	if v.Kind() == slog.KindGroup {
+		return false
+	}
+	switch c.Op {
+	case "~":
+		return re.MatchString(v.String())
+	case "!~":
+		return !re.MatchString(v.String())
+	}
+	n, ok := compareValue(v, c.Value)
+	if !ok {
+		return c.Op == "!="
+	}
+	switch c.Op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	default:
+		return n >= 0
+	}
+}
+
+// compareValue compares v with s, reporting false if s can't be compared with
+// values of the kind of v.
+func compareValue(v slog.Value, s string) (int, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return cmp.Compare(v.Int64(), i), true
+		}
+		return compareFloat(float64(v.Int64()), s)
+	case slog.KindUint64:
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return cmp.Compare(v.Uint64(), u), true
+		}
+		return compareFloat(float64(v.Uint64()), s)
+	case slog.KindFloat64:
+		return compareFloat(v.Float64(), s)
+	case slog.KindDuration:
+		d, err := time.ParseDuration(s)
+		return cmp.Compare(v.Duration(), d), err == nil
+	case slog.KindTime:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return v.Time().Compare(t), err == nil
+	case slog.KindBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil || v.Bool() == b {
+			return 0, err == nil
+		}
+		if b {
+			return -1, true
+		}
+		return 1, true
+	case slog.KindString:
+		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+			if n, ok := compareFloat(f, s); ok {
+				return n, true
+			}
+		}
+	}
+	return cmp.Compare(v.String(), s), true
+}
+
+func compareFloat(f float64, s string) (int, bool) {
+	g, err := strconv.ParseFloat(s, 64)
+	return cmp.Compare(f, g), err == nil
+}
+
+// ParseFilter parses a filter expression: conditions joined with AND, like
+//
+//	level>=warn AND msg~"timeout" AND attr.status>=500 AND time>=2024-01-15T10:00:00Z
+//
+// Conditions compare a field with a value using one of =, !=, <, <=, >, >=, ~
+// and !~. The fields are:
+//
+//   - level, with level names like "warn" and the operators =, <, <=, > and >=
+//   - msg, with ~ matching a regular expression and = the whole message
+//   - time, with RFC 3339 times and the operators <, <=, > and >=
+//   - attr.KEY, as described for AttrCond
+//
+// Values containing spaces or operators are quoted like Go strings. AND may be
+// written in lower case or as &&.
+func ParseFilter(expr string) (Filter, error) {
+	p := &filterParser{expr: expr, wantCond: true}
+	var f Filter
+	for {
+		p.skipSpace()
+		if p.pos == len(expr) {
+			if p.wantCond {
+				return Filter{}, p.errorf("missing condition")
+			}
+			return f, nil
+		}
+		if !p.wantCond {
+			if !p.and() {
+				return Filter{}, p.errorf("expected AND")
+			}
+			p.wantCond = true
+			continue
+		}
+		start := p.pos
+		field, op, value, err := p.cond()
+		if err != nil {
+			return Filter{}, err
+		}
+		if err := f.add(field, op, value); err != nil {
+			return Filter{}, fmt.Errorf("invalid filter %q at offset %d: %w", expr, start, err)
+		}
+		p.wantCond = false
+	}
+}
+
+type filterParser struct {
+	expr string
+	pos  int
+	// wantCond is set when a condition must follow, rather than AND.
+	wantCond bool
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid filter %q at offset %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) skipSpace() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *filterParser) and() bool {
+	for _, word := range []string{"AND", "and", "&&"} {
+		if rest, ok := strings.CutPrefix(p.expr[p.pos:], word); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			p.pos += len(word)
+			return true
+		}
+	}
+	return false
+}
+
+// cond parses a condition like attr.status>=500.
+func (p *filterParser) cond() (field, op, value string, err error) {
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune("=!<>~ \t\"", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	field = p.expr[start:p.pos]
+	if field == "" {
+		return "", "", "", p.errorf("expected a field")
+	}
+	p.skipSpace()
+	for _, o := range []string{">=", "<=", "!=", "!~", "=", "<", ">", "~"} {
+		if strings.HasPrefix(p.expr[p.pos:], o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return "", "", "", p.errorf("expected an operator after %s", field)
+	}
+	p.pos += len(op)
+	p.skipSpace()
+	if p.pos < len(p.expr) && p.expr[p.pos] == '"' {
+		quoted, err := strconv.QuotedPrefix(p.expr[p.pos:])
+		if err != nil {
+			return "", "", "", p.errorf("unterminated string")
+		}
+		value, _ = strconv.Unquote(quoted)
+		p.pos += len(quoted)
+		return field, op, value, nil
+	}
+	start = p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] != ' ' && p.expr[p.pos] != '\t' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", "", "", p.errorf("expected a value after %s%s", field, op)
+	}
+	return field, op, p.expr[start:p.pos], nil
+}
+
+// add adds the condition field op value to f. Conditions on the level and time
+// narrow the bounds set by earlier ones, as all conditions must hold.
+func (f *Filter) add(field, op, value string) error {
+	switch field {
+	case "level":
+		level, err := logger.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case "=":
+			f.atLeast(level)
+			f.atMost(level)
+		case ">=":
+			f.atLeast(level)
+		case ">":
+			f.atLeast(level + 1)
+		case "<=":
+			f.atMost(level)
+		case "<":
+			f.atMost(level - 1)
+		default:
+			return fmt.Errorf("operator %s not supported for level", op)
+		}
+	case "msg":
+		if f.MsgRegexp != nil {
+			return fmt.Errorf("msg given twice")
+		}
+		switch op {
+		case "=":
+			value = "^" + regexp.QuoteMeta(value) + "$"
+		case "~":
+		default:
+			return fmt.Errorf("operator %s not supported for msg", op)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return err
+		}
+		f.MsgRegexp = re
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case ">=":
+			f.since(t)
+		case ">":
+			f.since(t.Add(1))
+		case "<":
+			f.until(t)
+		case "<=":
+			f.until(t.Add(1))
+		default:
+			return fmt.Errorf("operator %s not supported for time", op)
+		}
+	default:
+		key, ok := strings.CutPrefix(field, "attr.")
+		if !ok || key == "" {
+			return fmt.Errorf("unknown field %q, want level, msg, time or attr.KEY", field)
+		}
+		if op == "~" || op == "!~" {
+			if _, err := regexp.Compile(value); err != nil {
+				return err
+			}
+		}
+		f.Attrs = append(f.Attrs, AttrCond{Key: key, Op: op, Value: value})
+	}
+	return nil
+}
+
+// atLeast raises MinLevel to level.
+func (f *Filter) atLeast(level slog.Level) {
+	if f.MinLevel == nil || level > f.MinLevel.Level() {
+		f.MinLevel = level
+	}
+}
+
+// atMost lowers MaxLevel to level.
+func (f *Filter) atMost(level slog.Level) {
+	if f.MaxLevel == nil || level < f.MaxLevel.Level() {
+		f.MaxLevel = level
+	}
+}
+
+// since moves Since forward to t.
+func (f *Filter) since(t time.Time) {
+	if f.Since.IsZero() || t.After(f.Since) {
+		f.Since = t
+	}
+}
+
+// until moves Until back to t.
+func (f *Filter) until(t time.Time) {
+	if f.Until.IsZero() || t.Before(f.Until) {
+		f.Until = t
+	}
+}
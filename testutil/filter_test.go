@@ -0,0 +1,153 @@
+package testutil
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+func TestQuery(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	capture := NewCaptureHandler()
+	log := func(minute int, level slog.Level, msg string, attrs ...slog.Attr) {
+		r := slog.NewRecord(start.Add(time.Duration(minute)*time.Minute), level, msg, 0)
+		r.AddAttrs(attrs...)
+		_ = capture.Handle(context.Background(), r)
+	}
+	log(0, slog.LevelDebug, "cache miss", slog.String("key", "a b"))
+	log(1, slog.LevelInfo, "request served", slog.Group("http", slog.Int("status", 200)), slog.Duration("took", 20*time.Millisecond))
+	log(2, slog.LevelWarn, "request timeout", slog.Group("http", slog.Int("status", 504)), slog.Duration("took", 5*time.Second))
+	log(3, slog.LevelError, "request failed", slog.Group("http", slog.String("status", "500")), slog.Bool("retry", true))
+	log(4, logger.LevelCritical, "disk failed", slog.Float64("free", 0.5))
+
+	for _, tc := range []struct {
+		expr string
+		want []string
+	}{
+		{`level>=warn`, []string{"request timeout", "request failed", "disk failed"}},
+		{`level=info`, []string{"request served"}},
+		{`level<info`, []string{"cache miss"}},
+		{`level>error`, []string{"disk failed"}},
+		{`level<=notice`, []string{"cache miss", "request served"}},
+		{`msg~"^request"`, []string{"request served", "request timeout", "request failed"}},
+		{`msg="request failed"`, []string{"request failed"}},
+		{`msg=request`, nil},
+		{`attr.http.status>=500`, []string{"request timeout", "request failed"}},
+		{`attr.http.status=500`, []string{"request failed"}},
+		{`attr.http.status!=200`, []string{"request timeout", "request failed"}},
+		{`attr.took>1s`, []string{"request timeout"}},
+		{`attr.took<=20ms`, []string{"request served"}},
+		{`attr.key="a b"`, []string{"cache miss"}},
+		{`attr.key!~"^a"`, nil},
+		{`attr.retry=true`, []string{"request failed"}},
+		{`attr.free<1`, []string{"disk failed"}},
+		{`attr.missing!=1`, nil},
+		{`time>=2024-01-15T10:02:00Z AND time<2024-01-15T10:04:00Z`, []string{"request timeout", "request failed"}},
+		{`time>2024-01-15T10:03:00Z`, []string{"disk failed"}},
+		{`time<=2024-01-15T10:00:00Z`, []string{"cache miss"}},
+		{`level>=info and msg~timeout && attr.http.status>500`, []string{"request timeout"}},
+		{` level = warn `, []string{"request timeout"}},
+	} {
+		records, err := capture.Query(tc.expr)
+		if err != nil {
+			t.Errorf("Query(%q): %v", tc.expr, err)
+			continue
+		}
+		var got []string
+		for _, r := range records {
+			got = append(got, r.Message)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Query(%q) = %q, want %q", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseFilterRepeated(t *testing.T) {
+	since := time.Date(2024, 1, 15, 10, 2, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 15, 10, 4, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		expr     string
+		min, max slog.Leveler
+		since    time.Time
+		until    time.Time
+	}{
+		{`level>=warn AND level>=info`, slog.LevelWarn, nil, time.Time{}, time.Time{}},
+		{`level>=info AND level>warn`, slog.LevelWarn + 1, nil, time.Time{}, time.Time{}},
+		{`level<=error AND level<warn AND level<=info`, nil, slog.LevelInfo, time.Time{}, time.Time{}},
+		{`level>=warn AND level=info`, slog.LevelWarn, slog.LevelInfo, time.Time{}, time.Time{}},
+		{`level=error AND level<=critical AND level>=debug`, slog.LevelError, slog.LevelError, time.Time{}, time.Time{}},
+		{`time>=2024-01-15T10:02:00Z AND time>=2024-01-15T10:00:00Z`, nil, nil, since, time.Time{}},
+		{`time<2024-01-15T10:04:00Z AND time<=2024-01-15T10:05:00Z`, nil, nil, time.Time{}, until},
+		{`time<2024-01-15T10:05:00Z AND time>2024-01-15T10:01:00Z AND time<2024-01-15T10:04:00Z AND time>=2024-01-15T10:02:00Z`, nil, nil, since, until},
+	} {
+		f, err := ParseFilter(tc.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tc.expr, err)
+			continue
+		}
+		if f.MinLevel != tc.min || f.MaxLevel != tc.max || !f.Since.Equal(tc.since) || !f.Until.Equal(tc.until) {
+			t.Errorf("ParseFilter(%q) = %v to %v since %v until %v, want %v to %v since %v until %v",
+				tc.expr, f.MinLevel, f.MaxLevel, f.Since, f.Until, tc.min, tc.max, tc.since, tc.until)
+		}
+	}
+
+	// Contradicting bounds match no records.
+	capture := NewCaptureHandler()
+	slog.New(capture).Warn("disk low")
+	if records, err := capture.Query(`level>=warn AND level<=info`); err != nil || len(records) != 0 {
+		t.Errorf("got %v, %v, want no records", records, err)
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		err  string
+	}{
+		{``, "at offset 0: missing condition"},
+		{`level>=warn AND`, "at offset 15: missing condition"},
+		{`level>=warn OR msg~x`, "at offset 12: expected AND"},
+		{`>=warn`, "at offset 0: expected a field"},
+		{`level warn`, "at offset 6: expected an operator after level"},
+		{`level>=`, "at offset 7: expected a value after level>="},
+		{`msg="unterminated`, "at offset 4: unterminated string"},
+		{`level>=loud`, `at offset 0: invalid level "loud"`},
+		{`level~warn`, "at offset 0: operator ~ not supported for level"},
+		{`msg>a`, "operator > not supported for msg"},
+		{`msg~a AND msg~b`, "at offset 10: msg given twice"},
+		{`msg~(`, "missing closing )"},
+		{`time=2024-01-15T10:00:00Z`, "operator = not supported for time"},
+		{`time>yesterday`, "cannot parse"},
+		{`status=200`, `unknown field "status"`},
+		{`attr.=1`, `unknown field "attr."`},
+		{`attr.k~[`, "missing closing ]"},
+	} {
+		_, err := ParseFilter(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("ParseFilter(%q) = %v, want an error containing %q", tc.expr, err, tc.err)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	capture := NewCaptureHandler()
+	l := slog.New(capture)
+	l.Info("a", "n", 1)
+	l.Info("b", "n", 2)
+
+	records, err := capture.Select(Filter{Attrs: []AttrCond{AttrEquals("n", 2)}})
+	if err != nil || len(records) != 1 || records[0].Message != "b" {
+		t.Errorf("Select(n=2) = %v, %v", records, err)
+	}
+	for _, c := range []AttrCond{{Key: "n", Op: "==", Value: "1"}, {Key: "n", Op: "~", Value: "("}} {
+		if _, err := capture.Select(Filter{Attrs: []AttrCond{c}}); err == nil {
+			t.Errorf("Select(%v) succeeded", c)
+		}
+	}
+}
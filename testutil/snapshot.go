@@ -0,0 +1,147 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/corray333/go-log"
+)
+
+// UpdateEnv is the environment variable that makes SnapshotHandler rewrite the
+// golden files when set to a true value like "1".
+const UpdateEnv = "GOLOG_UPDATE_SNAPSHOTS"
+
+// snapshotTime is the time of every record rendered by a snapshot handler.
+var snapshotTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Replacement replaces matches of Pattern in the rendered output with With,
+// using regexp.Regexp.ReplaceAllString.
+type Replacement struct {
+	Pattern string
+	With    string
+}
+
+// SnapshotOptions configures SnapshotHandler.
+type SnapshotOptions struct {
+	// HandlerOptions are passed to logger.NewHandler. Writer, Now and ForceNow
+	// are ignored.
+	HandlerOptions *logger.HandlerOptions
+	// Replacements normalize nondeterministic output, such as durations or
+	// request IDs, in order.
+	Replacements []Replacement
+	// Update rewrites the golden file instead of comparing the output with it.
+	// It is also enabled by UpdateEnv, or by an -update flag registered by the
+	// test.
+	Update bool
+}
+
+type snapshotState struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+type snapshotHandler struct {
+	h slog.Handler
+	s *snapshotState
+}
+
+// SnapshotHandler returns a handler rendering records like logger.NewHandler,
+// with every record timestamped 2000-01-01 00:00:00 UTC. When the test finishes,
+// the output is compared with the golden file at goldenPath, and the test fails
+// if they differ. Setting SnapshotOptions.Update or the environment variable
+// GOLOG_UPDATE_SNAPSHOTS=1 rewrites the golden file, as does running the test
+// with -update if the test defines that flag.
+func SnapshotHandler(t testing.TB, goldenPath string, opts *SnapshotOptions) slog.Handler {
+	t.Helper()
+	if opts == nil {
+		opts = &SnapshotOptions{}
+	}
+
+	var hopts logger.HandlerOptions
+	if opts.HandlerOptions != nil {
+		hopts = *opts.HandlerOptions
+	}
+	s := &snapshotState{}
+	hopts.Writer = &s.b
+	hopts.Now = func() time.Time { return snapshotTime }
+	hopts.ForceNow = true
+
+	replacements := make([]*regexp.Regexp, len(opts.Replacements))
+	for i, r := range opts.Replacements {
+		replacements[i] = regexp.MustCompile(r.Pattern)
+	}
+
+	t.Cleanup(func() {
+		s.m.Lock()
+		got := s.b.String()
+		s.m.Unlock()
+		for i, re := range replacements {
+			got = re.ReplaceAllString(got, opts.Replacements[i].With)
+		}
+		compareSnapshot(t, goldenPath, got, opts.Update || updateRequested())
+	})
+
+	return &snapshotHandler{h: logger.NewHandler(&hopts), s: s}
+}
+
+// updateRequested reports whether UpdateEnv or an -update flag asks for golden
+// files to be rewritten.
+func updateRequested() bool {
+	if update, err := strconv.ParseBool(os.Getenv(UpdateEnv)); err == nil && update {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		update, err := strconv.ParseBool(f.Value.String())
+		return err == nil && update
+	}
+	return false
+}
+
+func compareSnapshot(t testing.TB, goldenPath, got string, update bool) {
+	t.Helper()
+	if update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatalf("create snapshot directory: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+			t.Fatalf("write snapshot: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Errorf("read snapshot: %v (run with %s=1 to create it)", err, UpdateEnv)
+		return
+	}
+	if got != string(want) {
+		t.Errorf("log output doesn't match %s (run with %s=1 to rewrite it)\ngot:\n%s\nwant:\n%s", goldenPath, UpdateEnv, got, want)
+	}
+}
+
+func (h *snapshotHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *snapshotHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &snapshotHandler{h: h.h.WithAttrs(attrs), s: h.s}
+}
+
+func (h *snapshotHandler) WithGroup(name string) slog.Handler {
+	return &snapshotHandler{h: h.h.WithGroup(name), s: h.s}
+}
+
+func (h *snapshotHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.s.m.Lock()
+	defer h.s.m.Unlock()
+	return h.h.Handle(ctx, r)
+}
@@ -0,0 +1,94 @@
+package testutil
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingTB records the failures of a snapshot and runs its cleanups on
+// demand.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Fatalf(format string, args ...any) {
+	tb.Errorf(format, args...)
+}
+
+func (tb *recordingTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+
+func (tb *recordingTB) finish() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func snapshot(t *testing.T, path string, opts *SnapshotOptions, msg string) []string {
+	t.Helper()
+	tb := &recordingTB{TB: t}
+	l := slog.New(SnapshotHandler(tb, path, opts))
+	l.With("req", "r-1").Info(msg, "duration", 1234)
+	tb.finish()
+	return tb.errors
+}
+
+func TestSnapshotHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "checkout.golden")
+	opts := &SnapshotOptions{
+		Replacements: []Replacement{{Pattern: `"duration":\d+`, With: `"duration":0`}},
+		Update:       true,
+	}
+	if errs := snapshot(t, path, opts, "checkout"); len(errs) > 0 {
+		t.Fatalf("writing the snapshot failed: %v", errs)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[2000-01-01 00:00:00.000] INFO: checkout {\"req\":\"r-1\",\"duration\":0}\n"; string(got) != want {
+		t.Errorf("snapshot = %q, want %q", got, want)
+	}
+
+	opts.Update = false
+	if errs := snapshot(t, path, opts, "checkout"); len(errs) > 0 {
+		t.Errorf("matching output failed: %v", errs)
+	}
+	errs := snapshot(t, path, opts, "refund")
+	if len(errs) != 1 || !strings.Contains(errs[0], "doesn't match") {
+		t.Errorf("changed output failed with %v, want a mismatch", errs)
+	}
+}
+
+func TestSnapshotHandlerUpdateEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.golden")
+	t.Setenv(UpdateEnv, "1")
+	if errs := snapshot(t, path, nil, "written"); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if got, _ := os.ReadFile(path); !strings.Contains(string(got), "written") {
+		t.Errorf("snapshot = %q, want it rewritten", got)
+	}
+}
+
+func TestSnapshotHandlerNoGlobalFlag(t *testing.T) {
+	if f := flag.Lookup("update"); f != nil {
+		t.Errorf("the package registers the -update flag")
+	}
+	errs := snapshot(t, filepath.Join(t.TempDir(), "missing.golden"), nil, "msg")
+	if len(errs) != 1 || !strings.Contains(errs[0], UpdateEnv) {
+		t.Errorf("missing snapshot failed with %v, want a hint at %s", errs, UpdateEnv)
+	}
+}
@@ -0,0 +1,115 @@
+// Package testutil provides slog handlers for tests.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	logger "github.com/corray333/go-log"
+)
+
+// Option configures a test handler.
+type Option func(*options)
+
+type options struct {
+	level       slog.Leveler
+	failOnError bool
+}
+
+// WithLevel sets the minimum level of logged records. The default is Debug.
+func WithLevel(level slog.Leveler) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// FailOnError marks the test as failed when an Error level record is logged.
+func FailOnError() Option {
+	return func(o *options) {
+		o.failOnError = true
+	}
+}
+
+type testState struct {
+	t           testing.TB
+	m           sync.Mutex
+	b           bytes.Buffer
+	done        bool
+	failOnError bool
+}
+
+type testHandler struct {
+	h slog.Handler
+	s *testState
+}
+
+// NewTestHandler returns a handler writing rendered records with t.Log, so they
+// are shown next to the output of the test that produced them and only for
+// failing or verbose tests. Each line starts with the file and line of the
+// logging call. Records logged after the test has finished are dropped.
+func NewTestHandler(t testing.TB, opts ...Option) slog.Handler {
+	o := &options{level: slog.LevelDebug}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &testState{t: t, failOnError: o.failOnError}
+	t.Cleanup(func() {
+		s.m.Lock()
+		s.done = true
+		s.m.Unlock()
+	})
+
+	return &testHandler{
+		h: logger.NewHandler(&logger.HandlerOptions{
+			HandlerOptions: &slog.HandlerOptions{Level: o.level},
+			Writer:         &s.b,
+		}),
+		s: s,
+	}
+}
+
+func (h *testHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *testHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &testHandler{h: h.h.WithAttrs(attrs), s: h.s}
+}
+
+func (h *testHandler) WithGroup(name string) slog.Handler {
+	return &testHandler{h: h.h.WithGroup(name), s: h.s}
+}
+
+func (h *testHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.s
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.done {
+		return nil
+	}
+
+	s.b.Reset()
+	if err := h.h.Handle(ctx, r); err != nil {
+		return err
+	}
+	line := strings.TrimSuffix(s.b.String(), "\n")
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		line = fmt.Sprintf("%s:%d: %s", filepath.Base(f.File), f.Line, line)
+	}
+
+	s.t.Helper()
+	s.t.Log(line)
+	if s.failOnError && r.Level >= slog.LevelError {
+		s.t.Fail()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// fakeTB records what a test handler does with its testing.TB.
+type fakeTB struct {
+	testing.TB
+	logs     []string
+	failed   bool
+	cleanups []func()
+}
+
+func (tb *fakeTB) Helper()          {}
+func (tb *fakeTB) Fail()            { tb.failed = true }
+func (tb *fakeTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+func (tb *fakeTB) Log(args ...any)  { tb.logs = append(tb.logs, args[0].(string)) }
+func (tb *fakeTB) finish() {
+	for _, f := range tb.cleanups {
+		f()
+	}
+}
+
+func TestTestHandler(t *testing.T) {
+	tb := &fakeTB{}
+	l := slog.New(NewTestHandler(tb)).With("svc", "api")
+
+	l.Debug("starting", "port", 8080)
+	l.WithGroup("req").Info("served", "status", 200)
+
+	if len(tb.logs) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(tb.logs), tb.logs)
+	}
+	for i, want := range []string{
+		`DEBUG: starting {"svc":"api","port":8080}`,
+		`INFO: served {"svc":"api","req":{"status":200}}`,
+	} {
+		line := tb.logs[i]
+		if !strings.HasPrefix(line, "testhandler_test.go:") {
+			t.Errorf("line %d = %q, want the caller first", i, line)
+		}
+		if !strings.HasSuffix(line, want) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want)
+		}
+	}
+	if tb.failed {
+		t.Error("test failed without FailOnError")
+	}
+}
+
+func TestTestHandlerLevel(t *testing.T) {
+	tb := &fakeTB{}
+	l := slog.New(NewTestHandler(tb, WithLevel(slog.LevelWarn)))
+	l.Info("dropped")
+	l.Warn("kept")
+	if len(tb.logs) != 1 || !strings.Contains(tb.logs[0], "kept") {
+		t.Errorf("logs = %q, want only the warning", tb.logs)
+	}
+}
+
+func TestTestHandlerFailOnError(t *testing.T) {
+	tb := &fakeTB{}
+	l := slog.New(NewTestHandler(tb, FailOnError()))
+	l.Warn("fine")
+	if tb.failed {
+		t.Fatal("failed on a warning")
+	}
+	l.Error("broken")
+	if !tb.failed {
+		t.Error("didn't fail on an error")
+	}
+}
+
+func TestTestHandlerAfterTest(t *testing.T) {
+	tb := &fakeTB{}
+	l := slog.New(NewTestHandler(tb))
+	tb.finish()
+	l.Info("too late")
+	if len(tb.logs) != 0 {
+		t.Errorf("logged after the test finished: %q", tb.logs)
+	}
+}
+
+func TestTestHandlerSubtest(t *testing.T) {
+	var l *slog.Logger
+	t.Run("sub", func(t *testing.T) {
+		l = slog.New(NewTestHandler(t))
+		l.Info("inside the subtest")
+	})
+	// The subtest has finished; logging through its t must not panic.
+	l.Info("after the subtest")
+}
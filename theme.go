@@ -0,0 +1,200 @@
+package logger
+
+import "log/slog"
+
+// Theme sets the colors of the output of a handler with Colorize. Its fields
+// hold SGR parameters, like "1;31" for bold red or "38;5;33" for a color of the
+// 256-color palette. Empty fields leave text unstyled.
+type Theme struct {
+	Time    string
+	Message string
+	// ErrorMessage styles the message of records at Error level and above. It
+	// defaults to Message.
+	ErrorMessage string
+	// Levels styles the level names. Levels not in the map aren't styled.
+	Levels map[slog.Level]string
+	// Attrs styles the whole attrs object, and AttrKey and AttrValue its keys and
+	// values on top of it.
+	Attrs     string
+	AttrKey   string
+	AttrValue string
+	// DiffRemoved and DiffAdded style the lines of Diff attrs.
+	DiffRemoved string
+	DiffAdded   string
+}
+
+var (
+	// ThemeDefault is the theme used unless another one is selected.
+	ThemeDefault = &Theme{
+		Time:    "37",
+		Message: "97",
+		Levels: map[slog.Level]string{
+			LevelTrace:      "2;90",
+			slog.LevelDebug: "90",
+			slog.LevelInfo:  "36",
+			LevelNotice:     "92",
+			slog.LevelWarn:  "93",
+			slog.LevelError: "91",
+			LevelCritical:   "95",
+		},
+		Attrs:       "90",
+		DiffRemoved: "31",
+		DiffAdded:   "32",
+	}
+
+	// ThemeSolarizedDark uses the Solarized palette for dark backgrounds.
+	ThemeSolarizedDark = &Theme{
+		Time:         "38;5;240",
+		Message:      "38;5;245",
+		ErrorMessage: "1;38;5;160",
+		Levels: map[slog.Level]string{
+			LevelTrace:      "2;38;5;240",
+			slog.LevelDebug: "38;5;240",
+			slog.LevelInfo:  "38;5;33",
+			LevelNotice:     "38;5;64",
+			slog.LevelWarn:  "38;5;136",
+			slog.LevelError: "38;5;160",
+			LevelCritical:   "1;38;5;125",
+		},
+		Attrs:       "38;5;240",
+		AttrKey:     "38;5;37",
+		AttrValue:   "38;5;244",
+		DiffRemoved: "38;5;160",
+		DiffAdded:   "38;5;64",
+	}
+
+	// ThemeMonochrome only uses bold and dim text, for terminals without colors
+	// and readers who can't tell colors apart.
+	ThemeMonochrome = &Theme{
+		Time:         "2",
+		ErrorMessage: "1",
+		Levels: map[slog.Level]string{
+			LevelTrace:      "2",
+			slog.LevelDebug: "2",
+			slog.LevelWarn:  "1",
+			slog.LevelError: "1",
+			LevelCritical:   "1",
+		},
+		AttrKey:     "2",
+		DiffRemoved: "2",
+		DiffAdded:   "1",
+	}
+)
+
+// LookupTheme returns the built-in theme named "default", "solarized-dark" or
+// "monochrome".
+func LookupTheme(name string) (*Theme, bool) {
+	switch name {
+	case "default":
+		return ThemeDefault, true
+	case "solarized-dark":
+		return ThemeSolarizedDark, true
+	case "monochrome":
+		return ThemeMonochrome, true
+	}
+	return nil, false
+}
+
+// palette holds the escape sequences of a Theme. The zero palette styles nothing.
+type palette struct {
+	time, message, errorMessage string
+	levels                      map[slog.Level]string
+	attrs, key, value           string
+	removed, added              string
+}
+
+func newPalette(t *Theme) palette {
+	p := palette{
+		time:         sgr(t.Time),
+		message:      sgr(t.Message),
+		errorMessage: sgr(t.ErrorMessage),
+		levels:       make(map[slog.Level]string, len(t.Levels)),
+		attrs:        sgr(t.Attrs),
+		key:          sgr(t.AttrKey),
+		value:        sgr(t.AttrValue),
+		removed:      sgr(t.DiffRemoved),
+		added:        sgr(t.DiffAdded),
+	}
+	if t.ErrorMessage == "" {
+		p.errorMessage = p.message
+	}
+	for level, params := range t.Levels {
+		p.levels[level] = sgr(params)
+	}
+	return p
+}
+
+// sgr returns the escape sequence for the SGR parameters params.
+func sgr(params string) string {
+	if params == "" {
+		return ""
+	}
+	return "\033[" + params + "m"
+}
+
+// appendReset ends the style started with seq.
+func appendReset(b []byte, seq string) []byte {
+	if seq == "" {
+		return b
+	}
+	return append(b, reset...)
+}
+
+// appendStyledJSON appends the JSON src with its keys and values styled by p.
+func (p *palette) appendStyledJSON(b, src []byte) []byte {
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch c {
+		case '{', '}', '[', ']', ',', ':', ' ', '\n':
+			b = append(b, c)
+			i++
+			continue
+		}
+
+		end := i + 1
+		if c == '"' {
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(src))
+		} else {
+			for end < len(src) && !isJSONDelim(src[end]) {
+				end++
+			}
+		}
+
+		style := p.value
+		if c == '"' && isKey(src[end:]) {
+			style = p.key
+		}
+		b = append(b, style...)
+		b = append(b, src[i:end]...)
+		if style != "" {
+			b = append(b, reset...)
+			b = append(b, p.attrs...)
+		}
+		i = end
+	}
+	return b
+}
+
+func isJSONDelim(c byte) bool {
+	switch c {
+	case '{', '}', '[', ']', ',', ':', ' ', '\n':
+		return true
+	}
+	return false
+}
+
+// isKey reports whether the string followed by rest is an object key.
+func isKey(rest []byte) bool {
+	for _, c := range rest {
+		if c != ' ' {
+			return c == ':'
+		}
+	}
+	return false
+}
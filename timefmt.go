@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ShortTime formats t like "02 Jan 15:04:05", for HandlerOptions.FormatTime.
+func ShortTime(t time.Time) string {
+	return t.Format("02 Jan 15:04:05")
+}
+
+// KitchenTime formats t like "3:04:05PM", for HandlerOptions.FormatTime.
+func KitchenTime(t time.Time) string {
+	return t.Format("3:04:05PM")
+}
+
+// ISOWeekTime formats t with its ISO 8601 week date, like "2024-W03-1
+// 15:04:05" for a Monday, for HandlerOptions.FormatTime.
+func ISOWeekTime(t time.Time) string {
+	year, week := t.ISOWeek()
+	day := int(t.Weekday())
+	if day == 0 {
+		day = 7
+	}
+	return fmt.Sprintf("%04d-W%02d-%d %s", year, week, day, t.Format(time.TimeOnly))
+}
+
+// TimeEncoding selects how machine-readable output encodes the time of records.
+type TimeEncoding int
+
+const (
+	// TimeRFC3339Nano writes times as RFC 3339 strings with nanoseconds.
+	TimeRFC3339Nano TimeEncoding = iota
+	// TimeEpochMillis writes the milliseconds since the Unix epoch, dropping
+	// finer precision, like the date_millis format of Elasticsearch.
+	TimeEpochMillis
+	// TimeEpochSeconds writes the seconds since the Unix epoch with nanoseconds
+	// as the fraction, like 1705331045.000123456.
+	TimeEpochSeconds
+	// TimeEpochSplit writes an object with the "seconds" since the Unix epoch and
+	// the "nanos" within the second.
+	TimeEpochSplit
+)
+
+// ReplaceAttr returns a ReplaceAttr func for slog.HandlerOptions that encodes
+// the time of records with e and then calls next, if it isn't nil.
+func (e TimeEncoding) ReplaceAttr(next func(groups []string, a slog.Attr) slog.Attr) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+			a.Value = e.value(a.Value.Time())
+		}
+		if next != nil {
+			a = next(groups, a)
+		}
+		return a
+	}
+}
+
+func (e TimeEncoding) value(t time.Time) slog.Value {
+	switch e {
+	case TimeEpochMillis:
+		return slog.Int64Value(t.UnixMilli())
+	case TimeEpochSeconds:
+		sec, nsec := t.Unix(), t.Nanosecond()
+		if sec < 0 && nsec > 0 {
+			// -1.25 is -2 seconds and 750000000 nanoseconds.
+			return slog.AnyValue(json.Number(fmt.Sprintf("-%d.%09d", -(sec + 1), 1e9-nsec)))
+		}
+		return slog.AnyValue(json.Number(fmt.Sprintf("%d.%09d", sec, nsec)))
+	case TimeEpochSplit:
+		return slog.GroupValue(
+			slog.Int64("seconds", t.Unix()),
+			slog.Int64("nanos", int64(t.Nanosecond())),
+		)
+	default:
+		return slog.StringValue(t.Format(time.RFC3339Nano))
+	}
+}
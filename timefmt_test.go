@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format func(time.Time) string
+		t      time.Time
+		want   string
+	}{
+		{"ShortTime", ShortTime, goldenTime, "15 Jan 10:30:45"},
+		{"KitchenTime", KitchenTime, goldenTime, "10:30:45AM"},
+		{"KitchenTime afternoon", KitchenTime, goldenTime.Add(5 * time.Hour), "3:30:45PM"},
+		{"ISOWeekTime", ISOWeekTime, goldenTime, "2024-W03-1 10:30:45"},
+		{"ISOWeekTime Sunday", ISOWeekTime, time.Date(2024, 1, 21, 8, 0, 0, 0, time.UTC), "2024-W03-7 08:00:00"},
+		{"ISOWeekTime next year", ISOWeekTime, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), "2025-W01-1 00:00:00"},
+		{"ISOWeekTime previous year", ISOWeekTime, time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), "2020-W53-7 00:00:00"},
+	} {
+		if got := tc.format(tc.t); got != tc.want {
+			t.Errorf("%s(%v) = %q, want %q", tc.name, tc.t, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{"default", HandlerOptions{}, "[2024-01-15 10:30:45.123] INFO: msg {}\n"},
+		{"TimeFormat", HandlerOptions{TimeFormat: time.Kitchen}, "[10:30AM] INFO: msg {}\n"},
+		{"FormatTime", HandlerOptions{FormatTime: ShortTime}, "[15 Jan 10:30:45] INFO: msg {}\n"},
+		{"FormatTime over TimeFormat", HandlerOptions{TimeFormat: time.Kitchen, FormatTime: ISOWeekTime}, "[2024-W03-1 10:30:45] INFO: msg {}\n"},
+		{"JSON ignores them", HandlerOptions{JSON: true, TimeFormat: time.Kitchen, FormatTime: ShortTime}, `{"time":"2024-01-15T10:30:45.123Z","level":"INFO","msg":"msg"}` + "\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := tc.opts
+			opts.Writer = &buf
+			opts.Now = func() time.Time { return goldenTime }
+			opts.ForceNow = true
+			slog.New(NewHandler(&opts)).Info("msg")
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTimeEncoding(t *testing.T) {
+	negative := time.Date(1969, 12, 31, 23, 59, 58, 750e6, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		encoding TimeEncoding
+		t        time.Time
+		want     string
+	}{
+		{"RFC3339Nano", TimeRFC3339Nano, goldenTime, `"time":"2024-01-15T10:30:45.123Z"`},
+		{"EpochMillis", TimeEpochMillis, goldenTime.Add(999), `"time":1705314645123`},
+		{"EpochSeconds", TimeEpochSeconds, goldenTime.Add(456), `"time":1705314645.123000456`},
+		{"EpochSeconds negative", TimeEpochSeconds, negative, `"time":-1.250000000`},
+		{"EpochSplit", TimeEpochSplit, goldenTime, `"time":{"seconds":1705314645,"nanos":123000000}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// calls counts the calls of next by each handler.
+			calls := make([]int, 2)
+			counting := func(i int) func(groups []string, a slog.Attr) slog.Attr {
+				return func(groups []string, a slog.Attr) slog.Attr {
+					calls[i]++
+					return a
+				}
+			}
+
+			// The encoding with the JSON of this package and with slog.JSONHandler.
+			var ours, std bytes.Buffer
+			slog.New(NewHandler(&HandlerOptions{
+				Writer:         &ours,
+				JSON:           true,
+				TimeEncoding:   tc.encoding,
+				Now:            func() time.Time { return tc.t },
+				ForceNow:       true,
+				HandlerOptions: &slog.HandlerOptions{ReplaceAttr: counting(0)},
+			})).Info("msg")
+			h := slog.NewJSONHandler(&std, &slog.HandlerOptions{ReplaceAttr: tc.encoding.ReplaceAttr(counting(1))})
+			_ = h.Handle(t.Context(), slog.NewRecord(tc.t, slog.LevelInfo, "msg", 0))
+
+			for _, out := range []string{ours.String(), std.String()} {
+				if !strings.HasPrefix(out, "{"+tc.want+",") {
+					t.Errorf("got %s, want time %s", out, tc.want)
+				}
+			}
+			if calls[0] < 3 || calls[0] != calls[1] {
+				t.Errorf("next called %d times, and %d times by slog.JSONHandler", calls[0], calls[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errWriteTimeout is returned for records the writer didn't take within the
+// WriteTimeout.
+var errWriteTimeout = errors.New("write timed out")
+
+// errHandlerClosed is returned for records logged after Close.
+var errHandlerClosed = errors.New("handler closed")
+
+// timeoutWriter writes records on a background goroutine, so that a writer that
+// blocks, like a stalled pipe, can't block the goroutines logging.
+type timeoutWriter struct {
+	w       io.Writer
+	timeout time.Duration
+	reqs    chan writeRequest
+	// highWater is raised to the number of queued records.
+	highWater *atomic.Uint64
+	// writing is set while w is called.
+	writing atomic.Bool
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// writeRequest is a record to write, or a flush marker when p is nil.
+type writeRequest struct {
+	level    slog.Level
+	p        []byte
+	deadline time.Time
+	done     chan writeResult
+	// abandoned is set for records that turned out to be logged by w, which
+	// are not written.
+	abandoned *atomic.Bool
+}
+
+type writeResult struct {
+	n   int
+	err error
+}
+
+// timeoutQueue is the number of records waiting for a timeoutWriter.
+const timeoutQueue = 64
+
+func newTimeoutWriter(w io.Writer, timeout time.Duration, highWater *atomic.Uint64) *timeoutWriter {
+	tw := &timeoutWriter{
+		w:         w,
+		timeout:   timeout,
+		reqs:      make(chan writeRequest, timeoutQueue),
+		highWater: highWater,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go tw.run()
+	return tw
+}
+
+func (tw *timeoutWriter) run() {
+	defer close(tw.done)
+	for {
+		select {
+		case req := <-tw.reqs:
+			tw.handle(req)
+		case <-tw.stop:
+			// Write the records queued before close.
+			for {
+				select {
+				case req := <-tw.reqs:
+					tw.handle(req)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (tw *timeoutWriter) handle(req writeRequest) {
+	if req.p == nil {
+		req.done <- writeResult{}
+		return
+	}
+	if time.Now().After(req.deadline) || req.abandoned.Load() {
+		// The record was reported as dropped or suppressed already.
+		return
+	}
+	tw.writing.Store(true)
+	n, err := writeLevel(tw.w, req.level, req.p)
+	tw.writing.Store(false)
+	req.done <- writeResult{n, err}
+}
+
+// write writes p, a record at level, or returns errWriteTimeout if that doesn't
+// finish within the timeout. A record being written when the timeout expires
+// may still be written later. It returns errRecursive for records logged by w
+// on the goroutine of tw, which would wait for themselves; it only walks the
+// stack to find out when the record waits for recursionCheckDelay, or half the
+// timeout if that is shorter, while w is called.
+func (tw *timeoutWriter) write(level slog.Level, p []byte) (int, error) {
+	req := writeRequest{
+		level:     level,
+		p:         slices.Clone(p),
+		deadline:  time.Now().Add(tw.timeout),
+		done:      make(chan writeResult, 1),
+		abandoned: new(atomic.Bool),
+	}
+	// The timer is started after the deadline is set, so that records dropped
+	// when it fires are past their deadline.
+	timer := time.NewTimer(tw.timeout)
+	defer timer.Stop()
+	check := time.NewTimer(min(recursionCheckDelay, tw.timeout/2))
+	defer check.Stop()
+
+	select {
+	case <-tw.stop:
+		return 0, errHandlerClosed
+	default:
+	}
+	// Once the record is queued, Close doesn't end the wait for its result.
+	reqs, stop := tw.reqs, tw.stop
+	for {
+		select {
+		case reqs <- req:
+			raise(tw.highWater, uint64(len(reqs)))
+			reqs, stop = nil, nil
+		case res := <-req.done:
+			return res.n, res.err
+		case <-stop:
+			return 0, errHandlerClosed
+		case <-tw.done:
+			// The request may have been queued after the queue was drained.
+			select {
+			case res := <-req.done:
+				return res.n, res.err
+			default:
+				return 0, errHandlerClosed
+			}
+		case <-check.C:
+			if tw.writing.Load() && onStack(timeoutHandleEntry, 1) {
+				req.abandoned.Store(true)
+				return 0, errRecursive
+			}
+		case <-timer.C:
+			return 0, errWriteTimeout
+		}
+	}
+}
+
+// flush waits until the records queued before it are written or dropped.
+func (tw *timeoutWriter) flush(ctx context.Context) error {
+	req := writeRequest{done: make(chan writeResult, 1)}
+	select {
+	case tw.reqs <- req:
+	case <-tw.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-req.done:
+		return nil
+	case <-tw.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close writes the queued records and stops the background goroutine.
+func (tw *timeoutWriter) close() {
+	tw.stopOnce.Do(func() { close(tw.stop) })
+	<-tw.done
+}
+
+// writeLevel writes p, a record at level, to w.
+func writeLevel(w io.Writer, level slog.Level, p []byte) (int, error) {
+	if lw, ok := w.(levelWriter); ok {
+		return lw.writeLevel(level, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks writes until release is closed, and closes writing when
+// the first write starts.
+type blockingWriter struct {
+	release chan struct{}
+	writing chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{release: make(chan struct{}), writing: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.writing) })
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestWriteTimeout(t *testing.T) {
+	w := newBlockingWriter()
+	var reported []error
+	h := NewHandler(&HandlerOptions{
+		Writer:       w,
+		WriteTimeout: 10 * time.Millisecond,
+		OnError:      func(err error) { reported = append(reported, err) },
+	})
+	defer h.Close()
+
+	err := slog.New(h).Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "stalled", 0))
+	if !errors.Is(err, errWriteTimeout) {
+		t.Fatalf("got %v, want errWriteTimeout", err)
+	}
+	if got := h.Stats().Dropped; got != 1 || len(reported) != 1 {
+		t.Errorf("dropped %d, reported %v", got, reported)
+	}
+	close(w.release)
+}
+
+func TestWriteTimeoutFlushClose(t *testing.T) {
+	w := newBlockingWriter()
+	h := NewHandler(&HandlerOptions{Writer: w, WriteTimeout: time.Minute})
+	l := slog.New(h)
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		l.Info("queued")
+	}()
+	<-w.writing
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := h.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush of a blocked writer returned %v", err)
+	}
+
+	close(w.release)
+	<-logged
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.String(); !strings.Contains(got, "queued") {
+		t.Errorf("got %q after Flush", got)
+	}
+
+	before := runtime.NumGoroutine()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-h.timeout.done:
+	default:
+		t.Error("Close didn't stop the writer goroutine")
+	}
+	if after := runtime.NumGoroutine(); after >= before {
+		t.Errorf("goroutines: %d before Close, %d after", before, after)
+	}
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "late", 0)); !errors.Is(err, errHandlerClosed) {
+		t.Errorf("logging after Close returned %v", err)
+	}
+	if err := h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).(*handler).Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+	if err := h.Flush(context.Background()); err != nil {
+		t.Errorf("Flush after Close: %v", err)
+	}
+}
+
+func TestCloseWritesQueued(t *testing.T) {
+	w := newBlockingWriter()
+	close(w.release)
+	h := NewHandler(&HandlerOptions{Writer: w, WriteTimeout: time.Minute})
+	l := slog.New(h)
+	for i := range 10 {
+		l.Info("record", "i", i)
+	}
+	h.Close()
+	if got := strings.Count(w.String(), "record"); got != 10 {
+		t.Errorf("wrote %d records, want 10", got)
+	}
+}
+
+func TestCloseWithoutTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&HandlerOptions{Writer: &buf})
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).Info("still logging")
+	if !strings.Contains(buf.String(), "still logging") {
+		t.Errorf("got %q", buf.String())
+	}
+}
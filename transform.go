@@ -0,0 +1,310 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttrTransform is a rule of HandlerOptions.AttrTransforms: a Rename, Move or
+// Cast. Rules address attrs by their keys and the keys of the groups holding
+// them, joined with dots, like "http.request.id".
+type AttrTransform interface {
+	rule() transformRule
+}
+
+// Rename moves the attr at From to To, creating the groups of To as needed.
+// An attr already at To is replaced.
+type Rename struct {
+	From, To string
+}
+
+// Move moves the attr at Key into the group Under, keeping its key: the rule
+// Move{"user", "actor"} moves user to actor.user. An empty Under moves the
+// attr to the top level.
+type Move struct {
+	Key, Under string
+}
+
+// Cast replaces the value of the attr at Key with To applied to it, like
+// ToString.
+type Cast struct {
+	Key string
+	To  func(v slog.Value) slog.Value
+}
+
+// transformRule is an AttrTransform ready to apply. Casts have no to.
+type transformRule struct {
+	name     string
+	from, to []string
+	cast     func(v slog.Value) slog.Value
+}
+
+func (r Rename) rule() transformRule {
+	return transformRule{
+		name: fmt.Sprintf("rename %q to %q", r.From, r.To),
+		from: strings.Split(r.From, "."),
+		to:   strings.Split(r.To, "."),
+	}
+}
+
+func (m Move) rule() transformRule {
+	from := strings.Split(m.Key, ".")
+	to := []string{from[len(from)-1]}
+	if m.Under != "" {
+		to = append(strings.Split(m.Under, "."), to...)
+	}
+	return transformRule{name: fmt.Sprintf("move %q under %q", m.Key, m.Under), from: from, to: to}
+}
+
+func (c Cast) rule() transformRule {
+	return transformRule{name: fmt.Sprintf("cast %q", c.Key), from: strings.Split(c.Key, "."), cast: c.To}
+}
+
+// ValidateAttrTransforms reports rules that are invalid or conflict, like two
+// rules writing to the same key or a rule reading a key an earlier rule moved
+// away. NewHandler panics with this error, so rules read from configuration
+// should be checked with it first.
+func ValidateAttrTransforms(rules []AttrTransform) error {
+	_, err := compileTransforms(rules)
+	return err
+}
+
+func compileTransforms(rules []AttrTransform) ([]transformRule, error) {
+	compiled := make([]transformRule, 0, len(rules))
+	// written and moved map paths to the rules writing them and moving them
+	// away.
+	written := make(map[string]string)
+	moved := make(map[string]string)
+	for _, t := range rules {
+		r := t.rule()
+		if slices.Contains(r.from, "") || slices.Contains(r.to, "") {
+			return nil, fmt.Errorf("attr transform %s: empty key", r.name)
+		}
+		from := strings.Join(r.from, ".")
+		for path, by := range moved {
+			if from == path || strings.HasPrefix(from, path+".") {
+				return nil, fmt.Errorf("attr transform %s: %q was moved away by %s", r.name, from, by)
+			}
+		}
+		if r.to == nil {
+			if r.cast == nil {
+				return nil, fmt.Errorf("attr transform %s: no cast func", r.name)
+			}
+			compiled = append(compiled, r)
+			continue
+		}
+
+		to := strings.Join(r.to, ".")
+		switch {
+		case to == from:
+			return nil, fmt.Errorf("attr transform %s: source and destination are the same", r.name)
+		case strings.HasPrefix(to, from+"."):
+			return nil, fmt.Errorf("attr transform %s: %q can't be moved into itself", r.name, from)
+		}
+		for path, by := range written {
+			if to == path || strings.HasPrefix(to, path+".") || strings.HasPrefix(path, to+".") {
+				return nil, fmt.Errorf("attr transform %s conflicts with %s writing %q", r.name, by, path)
+			}
+		}
+		delete(written, from)
+		delete(moved, to)
+		moved[from] = r.name
+		written[to] = r.name
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// transformAttrs returns attrs with the rules applied in order.
+func transformAttrs(attrs []slog.Attr, rules []transformRule) []slog.Attr {
+	for _, r := range rules {
+		if r.to == nil {
+			attrs, _ = updatePath(attrs, r.from, func(a slog.Attr) slog.Attr {
+				a.Value = r.cast(a.Value)
+				return a
+			})
+			continue
+		}
+		rest, a, ok := takePath(attrs, r.from)
+		if ok {
+			a.Key = r.to[len(r.to)-1]
+			attrs = putPath(rest, r.to, a)
+		}
+	}
+	return attrs
+}
+
+// takePath removes the attr at path from attrs, looking into groups without a
+// key too. It doesn't modify attrs.
+func takePath(attrs []slog.Attr, path []string) ([]slog.Attr, slog.Attr, bool) {
+	for i, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			if rest, taken, ok := takePath(a.Value.Group(), path); ok {
+				return withGroup(attrs, i, rest), taken, true
+			}
+			continue
+		}
+		if a.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return slices.Delete(slices.Clone(attrs), i, i+1), a, true
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			if rest, taken, ok := takePath(a.Value.Group(), path[1:]); ok {
+				return withGroup(attrs, i, rest), taken, true
+			}
+		}
+	}
+	return attrs, slog.Attr{}, false
+}
+
+// putPath adds a at path to attrs, replacing an attr at path and creating the
+// groups of path as needed. It doesn't modify attrs.
+func putPath(attrs []slog.Attr, path []string, a slog.Attr) []slog.Attr {
+	i := slices.IndexFunc(attrs, func(x slog.Attr) bool { return x.Key == path[0] })
+	if len(path) == 1 {
+		if i < 0 {
+			return append(slices.Clip(attrs), a)
+		}
+		out := slices.Clone(attrs)
+		out[i] = a
+		return out
+	}
+	if i < 0 {
+		return append(slices.Clip(attrs), slog.Attr{Key: path[0], Value: slog.GroupValue(putPath(nil, path[1:], a)...)})
+	}
+	var group []slog.Attr
+	if attrs[i].Value.Kind() == slog.KindGroup {
+		group = attrs[i].Value.Group()
+	}
+	return withGroup(attrs, i, putPath(group, path[1:], a))
+}
+
+// updatePath replaces the attr at path with update applied to it, reporting
+// whether attrs has one. It doesn't modify attrs.
+func updatePath(attrs []slog.Attr, path []string, update func(a slog.Attr) slog.Attr) ([]slog.Attr, bool) {
+	for i, a := range attrs {
+		switch {
+		case a.Value.Kind() == slog.KindGroup && a.Key == "":
+			if group, ok := updatePath(a.Value.Group(), path, update); ok {
+				return withGroup(attrs, i, group), true
+			}
+		case a.Key != path[0]:
+		case len(path) == 1:
+			out := slices.Clone(attrs)
+			out[i] = update(a)
+			return out, true
+		case a.Value.Kind() == slog.KindGroup:
+			if group, ok := updatePath(a.Value.Group(), path[1:], update); ok {
+				return withGroup(attrs, i, group), true
+			}
+		}
+	}
+	return attrs, false
+}
+
+// withGroup returns a copy of attrs with the group at i holding group.
+func withGroup(attrs []slog.Attr, i int, group []slog.Attr) []slog.Attr {
+	out := slices.Clone(attrs)
+	out[i].Value = slog.GroupValue(group...)
+	return out
+}
+
+// replaceAttrs returns attrs resolved and passed to replace like the handler
+// does when rendering them.
+func replaceAttrs(replace func(groups []string, a slog.Attr) slog.Attr, groups []string, attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindLogValuer {
+			if _, ok := a.Value.Any().(*diffValue); ok {
+				// Kept for the listing of Diffs with Colorize.
+				out = append(out, a)
+				continue
+			}
+		}
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			inner := groups
+			if a.Key != "" {
+				inner = append(slices.Clip(groups), a.Key)
+			}
+			a.Value = slog.GroupValue(replaceAttrs(replace, inner, a.Value.Group())...)
+		} else if replace != nil {
+			a = replace(groups, a)
+			a.Value = a.Value.Resolve()
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// ToString casts values to strings, like 200 to "200". Groups are left alone.
+func ToString(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindGroup, slog.KindString:
+		return v
+	case slog.KindTime:
+		return slog.StringValue(v.Time().Format(time.RFC3339Nano))
+	}
+	return slog.StringValue(v.String())
+}
+
+// ToInt64 casts numbers, numeric strings and booleans to integers, truncating
+// fractions. Durations become nanoseconds. Other values are left alone.
+func ToInt64(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindUint64:
+		if v.Uint64() <= math.MaxInt64 {
+			return slog.Int64Value(int64(v.Uint64()))
+		}
+	case slog.KindFloat64:
+		if f := v.Float64(); f >= math.MinInt64 && f < math.MaxInt64 {
+			return slog.Int64Value(int64(f))
+		}
+	case slog.KindDuration:
+		return slog.Int64Value(int64(v.Duration()))
+	case slog.KindBool:
+		if v.Bool() {
+			return slog.Int64Value(1)
+		}
+		return slog.Int64Value(0)
+	case slog.KindString:
+		s := strings.TrimSpace(v.String())
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return slog.Int64Value(i)
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return ToInt64(slog.Float64Value(f))
+		}
+	}
+	return v
+}
+
+// ToFloat64 casts numbers, numeric strings and booleans to floats. Durations
+// become seconds. Other values are left alone.
+func ToFloat64(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return slog.Float64Value(float64(v.Int64()))
+	case slog.KindUint64:
+		return slog.Float64Value(float64(v.Uint64()))
+	case slog.KindDuration:
+		return slog.Float64Value(v.Duration().Seconds())
+	case slog.KindBool:
+		if v.Bool() {
+			return slog.Float64Value(1)
+		}
+		return slog.Float64Value(0)
+	case slog.KindString:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err == nil {
+			return slog.Float64Value(f)
+		}
+	}
+	return v
+}
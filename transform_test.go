@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttrTransforms(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rules []AttrTransform
+		log   func(l *slog.Logger)
+		want  string
+	}{
+		{"rename", []AttrTransform{Rename{"user_id", "user.id"}},
+			func(l *slog.Logger) { l.Info("msg", "user_id", 42, "n", 1) },
+			`{"n":1,"user":{"id":42}}`},
+		{"rename out of group", []AttrTransform{Rename{"http.status", "status"}},
+			func(l *slog.Logger) { l.Info("msg", slog.Group("http", "method", "GET", "status", 200)) },
+			`{"http":{"method":"GET"},"status":200}`},
+		{"rename replaces", []AttrTransform{Rename{"msg_id", "id"}},
+			func(l *slog.Logger) { l.Info("msg", "id", 1, "msg_id", 2) },
+			`{"id":2}`},
+		{"rename into existing group", []AttrTransform{Rename{"trace", "otel.trace_id"}},
+			func(l *slog.Logger) { l.Info("msg", "trace", "t1", slog.Group("otel", "span_id", "s1")) },
+			`{"otel":{"span_id":"s1","trace_id":"t1"}}`},
+		{"move", []AttrTransform{Move{"user", "actor"}},
+			func(l *slog.Logger) { l.Info("msg", "user", "ann") },
+			`{"actor":{"user":"ann"}}`},
+		{"move to top level", []AttrTransform{Move{"req.id", ""}},
+			func(l *slog.Logger) { l.Info("msg", slog.Group("req", "id", "r1")) },
+			`{"id":"r1"}`},
+		{"move group", []AttrTransform{Move{"db", "deps"}},
+			func(l *slog.Logger) { l.Info("msg", slog.Group("db", "host", "h", "port", 5432)) },
+			`{"deps":{"db":{"host":"h","port":5432}}}`},
+		{"cast", []AttrTransform{Cast{"status", ToString}, Cast{"http.size", ToInt64}},
+			func(l *slog.Logger) { l.Info("msg", "status", 200, slog.Group("http", "size", "1024")) },
+			`{"status":"200","http":{"size":1024}}`},
+		{"missing keys", []AttrTransform{Rename{"a", "b"}, Move{"c", "d"}, Cast{"e", ToString}},
+			func(l *slog.Logger) { l.Info("msg", "x", 1) },
+			`{"x":1}`},
+		{"With and WithGroup attrs", []AttrTransform{Rename{"service", "svc"}, Move{"req.id", "trace"}},
+			func(l *slog.Logger) { l.With("service", "api").WithGroup("req").Info("msg", "id", "r1", "path", "/") },
+			`{"req":{"path":"/"},"svc":"api","trace":{"id":"r1"}}`},
+		{"inline group", []AttrTransform{Rename{"a", "b"}},
+			func(l *slog.Logger) { l.Info("msg", slog.Group("", "a", 1)) },
+			`{"b":1}`},
+		{"chained", []AttrTransform{Rename{"a", "b"}, Move{"b", "g"}, Cast{"g.b", ToFloat64}},
+			func(l *slog.Logger) { l.Info("msg", "a", 1) },
+			`{"g":{"b":1}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.log(slog.New(NewHandler(&HandlerOptions{Writer: &buf, AttrTransforms: tc.rules})))
+			if got := buf.String(); !strings.HasSuffix(got, " "+tc.want+"\n") {
+				t.Errorf("got %q, want attrs %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateAttrTransforms(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rules []AttrTransform
+		err   string
+	}{
+		{"valid", []AttrTransform{Rename{"a", "b"}, Move{"b", "g"}, Cast{"g.b", ToString}}, ""},
+		{"swap", []AttrTransform{Rename{"a", "tmp"}, Rename{"b", "a"}, Rename{"tmp", "b"}}, ""},
+		{"empty key", []AttrTransform{Rename{"a..b", "c"}}, "empty key"},
+		{"empty destination", []AttrTransform{Rename{"a", ""}}, "empty key"},
+		{"no cast func", []AttrTransform{Cast{Key: "a"}}, "no cast func"},
+		{"same", []AttrTransform{Rename{"a", "a"}}, "source and destination are the same"},
+		{"into itself", []AttrTransform{Move{"a", "a"}}, "can't be moved into itself"},
+		{"same destination", []AttrTransform{Rename{"a", "c"}, Rename{"b", "c"}}, `conflicts with rename "a" to "c"`},
+		{"destination inside", []AttrTransform{Rename{"a", "c"}, Rename{"b", "c.d"}}, "conflicts"},
+		{"destination around", []AttrTransform{Rename{"a", "c.d"}, Rename{"e", "c"}}, "conflicts"},
+		{"read after move", []AttrTransform{Move{"a", "g"}, Cast{"a", ToString}}, `"a" was moved away by move "a" under "g"`},
+		{"read inside moved", []AttrTransform{Rename{"g", "h"}, Rename{"g.a", "b"}}, "was moved away"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateAttrTransforms(tc.rules)
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got %v, want an error containing %q", err, tc.err)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewHandler didn't panic with conflicting rules")
+		}
+	}()
+	NewHandler(&HandlerOptions{AttrTransforms: []AttrTransform{Rename{"a", "a"}}})
+}
+
+func TestCasts(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cast func(slog.Value) slog.Value
+		v    slog.Value
+		want slog.Value
+	}{
+		{"ToString int", ToString, slog.IntValue(200), slog.StringValue("200")},
+		{"ToString time", ToString, slog.TimeValue(time.Date(2024, 1, 15, 10, 0, 0, 5, time.UTC)), slog.StringValue("2024-01-15T10:00:00.000000005Z")},
+		{"ToString duration", ToString, slog.DurationValue(time.Second), slog.StringValue("1s")},
+		{"ToString group", ToString, slog.GroupValue(slog.Int("a", 1)), slog.GroupValue(slog.Int("a", 1))},
+		{"ToInt64 string", ToInt64, slog.StringValue(" 42 "), slog.Int64Value(42)},
+		{"ToInt64 float string", ToInt64, slog.StringValue("1.9"), slog.Int64Value(1)},
+		{"ToInt64 float", ToInt64, slog.Float64Value(-2.5), slog.Int64Value(-2)},
+		{"ToInt64 huge float", ToInt64, slog.Float64Value(1e300), slog.Float64Value(1e300)},
+		{"ToInt64 NaN", ToInt64, slog.Float64Value(math.NaN()), slog.Float64Value(math.NaN())},
+		{"ToInt64 huge uint", ToInt64, slog.Uint64Value(math.MaxUint64), slog.Uint64Value(math.MaxUint64)},
+		{"ToInt64 bool", ToInt64, slog.BoolValue(true), slog.Int64Value(1)},
+		{"ToInt64 duration", ToInt64, slog.DurationValue(time.Millisecond), slog.Int64Value(1e6)},
+		{"ToInt64 text", ToInt64, slog.StringValue("abc"), slog.StringValue("abc")},
+		{"ToFloat64 int", ToFloat64, slog.IntValue(3), slog.Float64Value(3)},
+		{"ToFloat64 string", ToFloat64, slog.StringValue("1.5"), slog.Float64Value(1.5)},
+		{"ToFloat64 duration", ToFloat64, slog.DurationValue(1500 * time.Millisecond), slog.Float64Value(1.5)},
+		{"ToFloat64 bool", ToFloat64, slog.BoolValue(false), slog.Float64Value(0)},
+		{"ToFloat64 text", ToFloat64, slog.StringValue("abc"), slog.StringValue("abc")},
+	} {
+		got := tc.cast(tc.v)
+		same := got.Equal(tc.want)
+		if tc.want.Kind() == slog.KindFloat64 && math.IsNaN(tc.want.Float64()) {
+			same = got.Kind() == slog.KindFloat64 && math.IsNaN(got.Float64())
+		}
+		if !same {
+			t.Errorf("%s: got %v (%v), want %v (%v)", tc.name, got, got.Kind(), tc.want, tc.want.Kind())
+		}
+	}
+}
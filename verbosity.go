@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	verbosity atomic.Int32
+	vmodules  atomic.Pointer[vmoduleSpec]
+)
+
+// vmoduleSpec holds verbosity overrides by file, with the levels of call sites
+// cached by program counter.
+type vmoduleSpec struct {
+	value    string
+	patterns []vmodulePattern
+	cache    sync.Map
+}
+
+type vmodulePattern struct {
+	pattern string
+	level   int
+}
+
+// SetVerbosity sets the verbosity up to which V loggers log. It can be changed
+// at any time.
+func SetVerbosity(v int) {
+	verbosity.Store(int32(v))
+}
+
+// Verbosity returns the verbosity set with SetVerbosity.
+func Verbosity() int {
+	return int(verbosity.Load())
+}
+
+// SetVModule sets per-file verbosity overrides from a comma-separated list of
+// pattern=N entries, like "store*=3,handler=2". A pattern is matched against
+// the name of the calling file without the .go extension, or against its full
+// path if it contains a slash. The level of the first matching pattern replaces
+// the verbosity set with SetVerbosity for the file, lowering it as well as
+// raising it. An empty spec removes all overrides.
+func SetVModule(spec string) error {
+	if spec == "" {
+		vmodules.Store(nil)
+		return nil
+	}
+
+	vm := &vmoduleSpec{value: spec}
+	for _, entry := range strings.Split(spec, ",") {
+		pattern, level, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || pattern == "" {
+			return fmt.Errorf("invalid vmodule entry %q: want pattern=N", entry)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid vmodule pattern %q: %w", pattern, err)
+		}
+		n, err := strconv.Atoi(level)
+		if err != nil {
+			return fmt.Errorf("invalid vmodule level %q: %w", level, err)
+		}
+		vm.patterns = append(vm.patterns, vmodulePattern{pattern: pattern, level: n})
+	}
+	vmodules.Store(vm)
+	return nil
+}
+
+// level returns the verbosity for the call site pc, or the global verbosity
+// when no pattern matches its file.
+func (vm *vmoduleSpec) level(pc uintptr) int {
+	m, ok := vm.cache.Load(pc)
+	if !ok {
+		m = vm.match(pc)
+		vm.cache.Store(pc, m)
+	}
+	if m := m.(vmoduleMatch); m.ok {
+		return m.level
+	}
+	return Verbosity()
+}
+
+// vmoduleMatch is the level of the first pattern matching a file, if ok.
+type vmoduleMatch struct {
+	level int
+	ok    bool
+}
+
+// match returns the level of the first pattern matching the file of pc.
+func (vm *vmoduleSpec) match(pc uintptr) vmoduleMatch {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := strings.TrimSuffix(frame.File, ".go")
+	for _, p := range vm.patterns {
+		name := filepath.Base(file)
+		if strings.Contains(p.pattern, "/") {
+			name = file
+		}
+		if ok, _ := filepath.Match(p.pattern, name); ok {
+			return vmoduleMatch{p.level, true}
+		}
+	}
+	return vmoduleMatch{}
+}
+
+// RegisterVerbosityFlags registers the -v and -vmodule flags in fs, setting the
+// verbosity and per-file overrides when parsed.
+func RegisterVerbosityFlags(fs *flag.FlagSet) {
+	fs.Var(verbosityFlag{}, "v", "verbosity of V logs")
+	fs.Var(vmoduleFlag{}, "vmodule", "comma-separated list of pattern=N per-file verbosity overrides")
+}
+
+type verbosityFlag struct{}
+
+func (verbosityFlag) String() string {
+	return strconv.Itoa(Verbosity())
+}
+
+func (verbosityFlag) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	SetVerbosity(v)
+	return nil
+}
+
+type vmoduleFlag struct{}
+
+func (vmoduleFlag) String() string {
+	if vm := vmodules.Load(); vm != nil {
+		return vm.value
+	}
+	return ""
+}
+
+func (vmoduleFlag) Set(s string) error {
+	return SetVModule(s)
+}
+
+// V returns a logger writing to the handler of slog.Default at level Debug-v,
+// as long as v is within the verbosity set with SetVerbosity or SetVModule for
+// the calling file. Records are not filtered by the level of the handler.
+func V(v int) *slog.Logger {
+	return slog.New(verbose(v)(slog.Default().Handler()))
+}
+
+// verbose returns the HandlerMiddleware of V.
+func verbose(v int) HandlerMiddleware {
+	return func(next slog.Handler) slog.Handler {
+		return &vHandler{h: next, v: v}
+	}
+}
+
+type vHandler struct {
+	h slog.Handler
+	v int
+}
+
+func (h *vHandler) Enabled(context.Context, slog.Level) bool {
+	return h.v <= Verbosity() || vmodules.Load() != nil
+}
+
+func (h *vHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &vHandler{h: h.h.WithAttrs(attrs), v: h.v}
+}
+
+func (h *vHandler) WithGroup(name string) slog.Handler {
+	return &vHandler{h: h.h.WithGroup(name), v: h.v}
+}
+
+func (h *vHandler) Handle(ctx context.Context, r slog.Record) error {
+	// The verbosity of a matching vmodule pattern applies even when it is
+	// lower than the global one.
+	v := Verbosity()
+	if vm := vmodules.Load(); vm != nil && r.PC != 0 {
+		v = vm.level(r.PC)
+	}
+	if h.v > v {
+		return nil
+	}
+	r.Level = slog.LevelDebug - slog.Level(h.v)
+	return h.h.Handle(ctx, r)
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// vOutput makes V log to a plain handler for the duration of the test and
+// resets the verbosity afterwards.
+func vOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&HandlerOptions{Writer: &buf})))
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+		SetVerbosity(0)
+		SetVModule("")
+	})
+	return &buf
+}
+
+func vMessages(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+	var msgs []string
+	for _, r := range parseLines(t, buf.Bytes()) {
+		msgs = append(msgs, r[slog.LevelKey].(string)+" "+r[slog.MessageKey].(string))
+	}
+	buf.Reset()
+	return strings.Join(msgs, ",")
+}
+
+func TestV(t *testing.T) {
+	buf := vOutput(t)
+	SetVerbosity(2)
+
+	V(0).Info("v0")
+	V(2).Warn("v2")
+	V(3).Info("v3")
+	if got := vMessages(t, buf); got != "DEBUG v0,TRACE+2 v2" {
+		t.Errorf("records = %s, want v0 at Debug and v2 at Debug-2", got)
+	}
+	if V(3).Enabled(t.Context(), slog.LevelInfo) {
+		t.Error("V(3) enabled at verbosity 2")
+	}
+
+	// The verbosity can change at any time, also for existing loggers.
+	l := V(3).With("k", 1)
+	SetVerbosity(3)
+	l.Info("v3")
+	if got := vMessages(t, buf); got != "TRACE+1 v3" {
+		t.Errorf("records = %s, want v3 after raising the verbosity", got)
+	}
+}
+
+func TestVModule(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	path := strings.TrimSuffix(file, ".go")
+
+	for _, tc := range []struct {
+		spec string
+		want string
+	}{
+		{"verbosity_test=3", "TRACE+3 v1,TRACE+1 v3"},
+		{"verb*=1", "TRACE+3 v1"},
+		{"other=5,*_test=2", "TRACE+3 v1"},
+		{"handler=5", ""},
+		{path + "=3", "TRACE+3 v1,TRACE+1 v3"},
+		{"verbosity_test.go=3", ""},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := vOutput(t)
+			if err := SetVModule(tc.spec); err != nil {
+				t.Fatal(err)
+			}
+			V(1).Info("v1")
+			V(3).Info("v3")
+			if got := vMessages(t, buf); got != tc.want {
+				t.Errorf("records = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVModuleLowersVerbosity(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want string
+	}{
+		{"verbosity_test=1", "DEBUG v0,TRACE+3 v1"},
+		{"verbosity_test=0", "DEBUG v0"},
+		{"verbosity_test=-1", ""},
+		// Files no pattern matches keep the global verbosity.
+		{"handler=0", "DEBUG v0,TRACE+3 v1,TRACE+1 v3"},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			buf := vOutput(t)
+			SetVerbosity(3)
+			if err := SetVModule(tc.spec); err != nil {
+				t.Fatal(err)
+			}
+			V(0).Info("v0")
+			V(1).Info("v1")
+			V(3).Info("v3")
+			if got := vMessages(t, buf); got != tc.want {
+				t.Errorf("records = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetVModuleErrors(t *testing.T) {
+	for _, spec := range []string{"store", "=3", "store=x", "[=1"} {
+		if err := SetVModule(spec); err == nil {
+			t.Errorf("SetVModule(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	vOutput(t)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterVerbosityFlags(fs)
+	if err := fs.Parse([]string{"-v=4", "-vmodule=store*=2"}); err != nil {
+		t.Fatal(err)
+	}
+	if Verbosity() != 4 {
+		t.Errorf("Verbosity = %d, want 4", Verbosity())
+	}
+	if got := fs.Lookup("vmodule").Value.String(); got != "store*=2" {
+		t.Errorf("vmodule = %q, want store*=2", got)
+	}
+	if err := fs.Set("v", "many"); err == nil {
+		t.Error("non-numeric -v accepted")
+	}
+}
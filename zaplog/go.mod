@@ -0,0 +1,7 @@
+module github.com/corray333/go-log/zaplog
+
+go 1.25.2
+
+require go.uber.org/zap v1.28.0
+
+require go.uber.org/multierr v1.10.0 // indirect
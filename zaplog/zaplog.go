@@ -0,0 +1,150 @@
+// Package zaplog routes zap loggers through a slog handler, so code written
+// against zap keeps working while new code moves to slog.
+package zaplog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// NewZapCore returns a zapcore.Core writing entries to h. Use it with zap.New:
+//
+//	log := zap.New(zaplog.NewZapCore(handler), zap.AddCaller())
+//
+// Fields are converted to attributes, namespaces become groups, and DPanic,
+// Panic and Fatal entries are logged at Error level.
+func NewZapCore(h slog.Handler) zapcore.Core {
+	return &core{h: h}
+}
+
+type core struct {
+	h slog.Handler
+}
+
+func level(l zapcore.Level) slog.Level {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case l == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case l == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func (c *core) Enabled(l zapcore.Level) bool {
+	return c.h.Enabled(context.Background(), level(l))
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	h := c.h
+	var attrs []slog.Attr
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			if len(attrs) > 0 {
+				h = h.WithAttrs(attrs)
+				attrs = nil
+			}
+			h = h.WithGroup(f.Key)
+			continue
+		}
+		if a, ok := attr(f); ok {
+			attrs = append(attrs, a)
+		}
+	}
+	if len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	return &core{h: h}
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var pc uintptr
+	if ent.Caller.Defined {
+		pc = ent.Caller.PC
+	}
+	r := slog.NewRecord(ent.Time, level(ent.Level), ent.Message, pc)
+	if ent.LoggerName != "" {
+		r.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	r.AddAttrs(attrs(fields)...)
+	if ent.Stack != "" {
+		r.AddAttrs(slog.String("stack", ent.Stack))
+	}
+	return c.h.Handle(context.Background(), r)
+}
+
+func (c *core) Sync() error {
+	return nil
+}
+
+// attrs converts fields to attributes. Fields following a namespace are nested
+// in a group named after it.
+func attrs(fields []zapcore.Field) []slog.Attr {
+	out := make([]slog.Attr, 0, len(fields))
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			return append(out, slog.Attr{Key: f.Key, Value: slog.GroupValue(attrs(fields[i+1:])...)})
+		}
+		if a, ok := attr(f); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func attr(f zapcore.Field) (slog.Attr, bool) {
+	switch f.Type {
+	case zapcore.SkipType:
+		return slog.Attr{}, false
+	case zapcore.BoolType:
+		return slog.Bool(f.Key, f.Integer == 1), true
+	case zapcore.StringType:
+		return slog.String(f.Key, f.String), true
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return slog.Int64(f.Key, f.Integer), true
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return slog.Uint64(f.Key, uint64(f.Integer)), true
+	case zapcore.Float64Type:
+		return slog.Float64(f.Key, math.Float64frombits(uint64(f.Integer))), true
+	case zapcore.Float32Type:
+		return slog.Float64(f.Key, float64(math.Float32frombits(uint32(f.Integer)))), true
+	case zapcore.DurationType:
+		return slog.Duration(f.Key, time.Duration(f.Integer)), true
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return slog.Time(f.Key, t), true
+	case zapcore.TimeFullType:
+		return slog.Time(f.Key, f.Interface.(time.Time)), true
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return slog.String(f.Key, err.Error()), true
+		}
+		return slog.Attr{}, false
+	case zapcore.StringerType:
+		return slog.String(f.Key, fmt.Sprint(f.Interface)), true
+	}
+
+	// Objects, arrays, reflected and binary values are encoded by zap itself.
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	v, ok := enc.Fields[f.Key]
+	return slog.Any(f.Key, v), ok
+}
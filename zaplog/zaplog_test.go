@@ -0,0 +1,165 @@
+package zaplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTest(t *testing.T, level slog.Level, opts ...zap.Option) (*zap.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: level})
+	return zap.New(NewZapCore(h), opts...), &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf, err)
+	}
+	buf.Reset()
+	return m
+}
+
+type user struct {
+	name string
+	age  int
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	enc.AddInt("age", u.age)
+	return nil
+}
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+func TestFields(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name  string
+		field zap.Field
+		want  any
+	}{
+		{"bool", zap.Bool("v", true), true},
+		{"string", zap.String("v", "x"), "x"},
+		{"int", zap.Int("v", -3), -3.0},
+		{"int8", zap.Int8("v", 8), 8.0},
+		{"uint", zap.Uint32("v", 7), 7.0},
+		{"float64", zap.Float64("v", 1.5), 1.5},
+		{"float32", zap.Float32("v", 0.25), 0.25},
+		{"duration", zap.Duration("v", 1500*time.Millisecond), float64(1500 * time.Millisecond)},
+		{"time", zap.Time("v", at), "2024-03-01T12:00:00Z"},
+		{"error", zap.Error(errors.New("boom")), "boom"},
+		{"stringer", zap.Stringer("v", stringer{}), "stringer"},
+		{"object", zap.Object("v", user{"ann", 30}), map[string]any{"name": "ann", "age": 30.0}},
+		{"strings", zap.Strings("v", []string{"a", "b"}), []any{"a", "b"}},
+		{"reflect", zap.Reflect("v", map[string]int{"n": 1}), map[string]any{"n": 1.0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			log, buf := newTest(t, slog.LevelDebug)
+			log.Info("msg", tc.field)
+
+			key := tc.field.Key
+			got, err := json.Marshal(decode(t, buf)[key])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := json.Marshal(tc.want)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s = %s, want %s", key, got, want)
+			}
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	log, buf := newTest(t, slog.LevelDebug)
+	log.Info("msg", zap.Skip(), zap.Error(nil))
+	m := decode(t, buf)
+	if len(m) != 3 { // time, level, msg
+		t.Errorf("record = %v, want no field attrs", m)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	for _, tc := range []struct {
+		level zapcore.Level
+		want  string
+	}{
+		{zapcore.DebugLevel, "DEBUG"},
+		{zapcore.InfoLevel, "INFO"},
+		{zapcore.WarnLevel, "WARN"},
+		{zapcore.ErrorLevel, "ERROR"},
+		{zapcore.DPanicLevel, "ERROR"},
+	} {
+		log, buf := newTest(t, slog.LevelDebug)
+		log.Log(tc.level, "msg")
+		if got := decode(t, buf)["level"]; got != tc.want {
+			t.Errorf("%v level = %v, want %s", tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	log, buf := newTest(t, slog.LevelWarn)
+	log.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Info below Warn wrote %q", buf)
+	}
+	if ce := log.Check(zapcore.WarnLevel, "kept"); ce == nil {
+		t.Error("Check(Warn) = nil at Warn")
+	}
+}
+
+func TestWithAndNamespace(t *testing.T) {
+	log, buf := newTest(t, slog.LevelDebug)
+	log.With(zap.String("svc", "api"), zap.Namespace("req"), zap.Int("id", 1)).
+		Info("msg", zap.Namespace("inner"), zap.Bool("ok", true))
+
+	got, _ := json.Marshal(decode(t, buf)["req"])
+	if want := `{"id":1,"inner":{"ok":true}}`; string(got) != want {
+		t.Errorf("req = %s, want %s", got, want)
+	}
+}
+
+func TestWithFieldsStay(t *testing.T) {
+	log, buf := newTest(t, slog.LevelDebug)
+	child := log.With(zap.String("svc", "api"))
+	child.Info("one")
+	if m := decode(t, buf); m["svc"] != "api" {
+		t.Errorf("svc = %v, want api", m["svc"])
+	}
+	log.Info("two")
+	if m := decode(t, buf); m["svc"] != nil {
+		t.Errorf("parent got svc = %v", m["svc"])
+	}
+}
+
+func TestNameCallerStack(t *testing.T) {
+	log, buf := newTest(t, slog.LevelDebug, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	log.Named("db").Error("failed")
+
+	m := decode(t, buf)
+	if m["logger"] != "db" {
+		t.Errorf("logger = %v, want db", m["logger"])
+	}
+	if s, _ := m["stack"].(string); s == "" {
+		t.Error("stack missing on Error with AddStacktrace")
+	}
+	src, _ := m["source"].(map[string]any)
+	if file, _ := src["file"].(string); filepath.Base(file) != "zaplog_test.go" {
+		t.Errorf("source = %v, want zaplog_test.go", src)
+	}
+}